@@ -1,18 +1,38 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	"log"
+	"log/slog"
 
+	"ticket-booking-system/internal/tracing"
+
+	"github.com/XSAM/otelsql"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 )
 
+// NewConnection opens a connection pool whose queries are traced. Only queries
+// made within a traced operation get spans, so the polling of the background
+// workers does not flood the traces.
 func NewConnection(databaseURL string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", databaseURL)
+	db, err := otelsql.Open("postgres", databaseURL,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			OmitConnectorConnect: true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return tracing.HasParent(ctx)
+			},
+		}),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -28,7 +48,7 @@ func NewConnection(databaseURL string) (*sql.DB, error) {
 	return db, nil
 }
 
-func RunMigrations(databaseURL string) error {
+func RunMigrations(databaseURL string, logger *slog.Logger) error {
 	m, err := migrate.New(
 		"file://migrations",
 		databaseURL,
@@ -42,6 +62,28 @@ func RunMigrations(databaseURL string) error {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	log.Println("Database migrations completed successfully")
+	version, _, err := m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+
+	logger.Info("Database migrations completed", slog.Uint64("version", uint64(version)))
 	return nil
 }
+
+// MigrationVersion returns the version of the last migration applied, and
+// whether it failed part way and left the schema dirty
+func MigrationVersion(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var version int64
+	var dirty bool
+
+	err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+
+	return uint(version), dirty, nil
+}
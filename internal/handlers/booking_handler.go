@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
+	"ticket-booking-system/internal/middleware"
 	"ticket-booking-system/internal/models"
 	"ticket-booking-system/internal/services"
 
@@ -22,6 +25,23 @@ func NewBookingHandler(bookingService *services.BookingService, paymentService *
 	}
 }
 
+// respondBookingLimitError responds to a booking refused by a booking limit,
+// and reports whether err was one
+func respondBookingLimitError(c *gin.Context, err error) bool {
+	var retryAfter *services.RetryAfterError
+	switch {
+	case errors.As(err, &retryAfter):
+		middleware.SetRetryAfter(c, retryAfter.RetryAfter)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTicketLimitExceeded):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+
+	return true
+}
+
 func (h *BookingHandler) CreateBooking(c *gin.Context) {
 	var req models.CreateBookingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -29,14 +49,26 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		return
 	}
 
-	booking, err := h.bookingService.CreateBooking(&req)
+	caller := middleware.CallerFrom(c)
+
+	booking, err := h.bookingService.CreateBooking(c.Request.Context(), caller.UserID, &req)
 	if err != nil {
+		if respondBookingLimitError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrSeatUnavailable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Queue payment processing
-	err = h.paymentService.QueuePayment(booking.ID, booking.TotalAmount)
+	middleware.MarkCommitted(c)
+
+	// Queue payment processing. The booking is already committed, so the job is
+	// queued even if the client has gone away or the request timed out.
+	err = h.paymentService.QueuePayment(context.WithoutCancel(c.Request.Context()), booking.ID, booking.TotalAmount)
 	if err != nil {
 		// Log error but don't fail the booking creation
 		// In production, you might want to handle this differently
@@ -58,8 +90,12 @@ func (h *BookingHandler) GetBooking(c *gin.Context) {
 		return
 	}
 
-	booking, err := h.bookingService.GetBooking(id)
+	booking, err := h.bookingService.GetBooking(c.Request.Context(), id, middleware.CallerFrom(c))
 	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -75,8 +111,16 @@ func (h *BookingHandler) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	err = h.bookingService.CancelBooking(id)
+	err = h.bookingService.CancelBooking(c.Request.Context(), id, middleware.CallerFrom(c))
 	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidStatus) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -84,17 +128,54 @@ func (h *BookingHandler) CancelBooking(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled successfully"})
 }
 
-func (h *BookingHandler) GetUserBookings(c *gin.Context) {
-	userIDStr := c.Param("user_id")
-	userID, err := uuid.Parse(userIDStr)
+// RefundBooking cancels a confirmed booking and refunds it according to the
+// event's cancellation policy
+func (h *BookingHandler) RefundBooking(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	refund, err := h.bookingService.RefundBooking(c.Request.Context(), id, middleware.CallerFrom(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		switch {
+		case errors.Is(err, services.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrBookingNotRefundable), errors.Is(err, services.ErrRefundWindowClosed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	bookings, err := h.bookingService.GetUserBookings(userID)
+	// The booking is refunded at this point; only returning the money can fail
+	err = h.paymentService.ProcessRefund(c.Request.Context(), refund)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":  "Booking refunded but the payment could not be returned yet, retry the request",
+			"refund": refund,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+func (h *BookingHandler) GetMyBookings(c *gin.Context) {
+	var filter models.BookingFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	caller := middleware.CallerFrom(c)
+
+	bookings, err := h.bookingService.GetUserBookings(c.Request.Context(), caller.UserID, &filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
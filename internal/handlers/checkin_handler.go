@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ticket-booking-system/internal/middleware"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// scanStatuses are the response statuses of the scan results
+var scanStatuses = map[models.ScanResult]int{
+	models.ScanResultAdmitted:   http.StatusOK,
+	models.ScanResultDuplicate:  http.StatusConflict,
+	models.ScanResultRevoked:    http.StatusGone,
+	models.ScanResultWrongEvent: http.StatusUnprocessableEntity,
+	models.ScanResultInvalid:    http.StatusUnprocessableEntity,
+}
+
+type CheckInHandler struct {
+	checkInService *services.CheckInService
+}
+
+func NewCheckInHandler(checkInService *services.CheckInService) *CheckInHandler {
+	return &CheckInHandler{checkInService: checkInService}
+}
+
+// CheckIn validates a scanned ticket at the gate of the authenticated scanner
+func (h *CheckInHandler) CheckIn(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req models.CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.checkInService.CheckIn(c.Request.Context(), eventID, middleware.ScannerFrom(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Scanner is registered for another event"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(scanStatuses[result.Scan.Result], result)
+}
+
+// RegisterScanner registers a scanner device for one of the event's gates
+func (h *CheckInHandler) RegisterScanner(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req models.RegisterScannerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	device, err := h.checkInService.RegisterScanner(c.Request.Context(), eventID, middleware.CallerFrom(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, device)
+}
+
+func (h *CheckInHandler) GetScanners(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	devices, err := h.checkInService.GetScanners(c.Request.Context(), eventID, middleware.CallerFrom(c))
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// RevokeScanner stops a scanner device from checking tickets in
+func (h *CheckInHandler) RevokeScanner(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	scannerID, err := uuid.Parse(c.Param("scanner_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scanner ID"})
+		return
+	}
+
+	if err := h.checkInService.RevokeScanner(c.Request.Context(), eventID, scannerID, middleware.CallerFrom(c)); err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Scanner revoked successfully"})
+}
+
+// GetScans returns one page of the event's scan log, filterable by gate and
+// result
+func (h *CheckInHandler) GetScans(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var filter models.ScanFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scans, err := h.checkInService.GetScans(c.Request.Context(), eventID, middleware.CallerFrom(c), &filter)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, scans)
+}
+
+// GetBundle returns a signed bundle of the event's valid tickets for the
+// scanner to check tickets in with while offline
+func (h *CheckInHandler) GetBundle(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	bundle, err := h.checkInService.GetBundle(c.Request.Context(), eventID, middleware.ScannerFrom(c))
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Scanner is registered for another event"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, bundle)
+}
+
+// SyncScans uploads the scans the scanner made while offline
+func (h *CheckInHandler) SyncScans(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req models.SyncScansRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.checkInService.SyncScans(c.Request.Context(), eventID, middleware.ScannerFrom(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Scanner is registered for another event"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetConflicts returns one page of the event's conflicting offline scans
+func (h *CheckInHandler) GetConflicts(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var page models.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conflicts, err := h.checkInService.GetConflicts(c.Request.Context(), eventID, middleware.CallerFrom(c), &page)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conflicts)
+}
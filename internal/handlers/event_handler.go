@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
+	"ticket-booking-system/internal/middleware"
 	"ticket-booking-system/internal/models"
 	"ticket-booking-system/internal/services"
 
@@ -27,8 +29,12 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 		return
 	}
 
-	event, err := h.eventService.CreateEvent(&req)
+	event, err := h.eventService.CreateEvent(c.Request.Context(), middleware.CallerFrom(c), &req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidEvent) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -44,7 +50,7 @@ func (h *EventHandler) GetEvent(c *gin.Context) {
 		return
 	}
 
-	event, err := h.eventService.GetEvent(id)
+	event, err := h.eventService.GetEvent(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -54,9 +60,24 @@ func (h *EventHandler) GetEvent(c *gin.Context) {
 }
 
 func (h *EventHandler) GetEvents(c *gin.Context) {
-	events, err := h.eventService.GetEvents()
+	var filter models.EventFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_price must not be greater than max_price"})
+		return
+	}
+
+	events, err := h.eventService.GetEvents(c.Request.Context(), &filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondListError(c, err)
 		return
 	}
 
@@ -77,8 +98,16 @@ func (h *EventHandler) UpdateEvent(c *gin.Context) {
 		return
 	}
 
-	event, err := h.eventService.UpdateEvent(id, &req)
+	event, err := h.eventService.UpdateEvent(c.Request.Context(), id, middleware.CallerFrom(c), &req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidEvent) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -94,8 +123,12 @@ func (h *EventHandler) DeleteEvent(c *gin.Context) {
 		return
 	}
 
-	err = h.eventService.DeleteEvent(id)
+	err = h.eventService.DeleteEvent(c.Request.Context(), id, middleware.CallerFrom(c))
 	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -111,11 +144,36 @@ func (h *EventHandler) GetEventStatistics(c *gin.Context) {
 		return
 	}
 
-	stats, err := h.eventService.GetEventStatistics(id)
+	stats, err := h.eventService.GetEventStatistics(c.Request.Context(), id, middleware.CallerFrom(c))
 	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, stats)
 }
+
+func (h *EventHandler) GetSeatMap(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	seatMap, err := h.eventService.GetSeatMap(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotSeated) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, seatMap)
+}
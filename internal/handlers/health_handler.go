@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	healthService *services.HealthService
+}
+
+func NewHealthHandler(healthService *services.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Liveness reports that the process is up and serving requests. It checks no
+// dependencies, so an outage of Postgres or Redis does not get the process
+// restarted.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": models.HealthStatusUp})
+}
+
+// Readiness checks the service's dependencies and workers, responding 503
+// while a critical one is down so that no traffic is sent to this instance
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.healthService.Check(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status == models.HealthStatusDown {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
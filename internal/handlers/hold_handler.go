@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"ticket-booking-system/internal/middleware"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type HoldHandler struct {
+	holdService    *services.HoldService
+	bookingService *services.BookingService
+	paymentService *services.PaymentService
+}
+
+func NewHoldHandler(holdService *services.HoldService, bookingService *services.BookingService, paymentService *services.PaymentService) *HoldHandler {
+	return &HoldHandler{
+		holdService:    holdService,
+		bookingService: bookingService,
+		paymentService: paymentService,
+	}
+}
+
+// respondHoldError maps hold service errors to responses, falling back to
+// fallback for anything unexpected
+func respondHoldError(c *gin.Context, err error, fallback int) {
+	if respondBookingLimitError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrHoldNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrHoldAlreadyExtended), errors.Is(err, services.ErrSeatUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(fallback, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *HoldHandler) CreateHold(c *gin.Context) {
+	var req models.CreateHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	caller := middleware.CallerFrom(c)
+
+	hold, err := h.holdService.CreateHold(c.Request.Context(), caller.UserID, &req)
+	if err != nil {
+		respondHoldError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+func (h *HoldHandler) GetHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hold ID"})
+		return
+	}
+
+	hold, err := h.holdService.GetHold(c.Request.Context(), id, middleware.CallerFrom(c))
+	if err != nil {
+		respondHoldError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+// ExtendHold keeps a hold for another hold TTL, once
+func (h *HoldHandler) ExtendHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hold ID"})
+		return
+	}
+
+	hold, err := h.holdService.ExtendHold(c.Request.Context(), id, middleware.CallerFrom(c))
+	if err != nil {
+		respondHoldError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+// ReleaseHold gives a hold's tickets back before it expires
+func (h *HoldHandler) ReleaseHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hold ID"})
+		return
+	}
+
+	err = h.holdService.ReleaseHold(c.Request.Context(), id, middleware.CallerFrom(c))
+	if err != nil {
+		respondHoldError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hold released successfully"})
+}
+
+// CheckoutHold turns a hold into a pending booking and queues its payment. The
+// request body is optional.
+func (h *HoldHandler) CheckoutHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hold ID"})
+		return
+	}
+
+	var req models.CheckoutHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, err := h.bookingService.CheckoutHold(c.Request.Context(), id, middleware.CallerFrom(c), &req)
+	if err != nil {
+		respondHoldError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	middleware.MarkCommitted(c)
+
+	// The booking is already committed, so the job is queued even if the
+	// client has gone away
+	err = h.paymentService.QueuePayment(context.WithoutCancel(c.Request.Context()), booking.ID, booking.TotalAmount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Booking created but payment processing failed",
+			"booking": booking,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, booking)
+}
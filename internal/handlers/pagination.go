@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondListError reports a failed list query. A cursor that cannot be
+// decoded is the client's fault.
+func respondListError(c *gin.Context, err error) {
+	if errors.Is(err, models.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
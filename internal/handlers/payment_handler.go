@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PaymentHandler struct {
+	paymentService *services.PaymentService
+}
+
+func NewPaymentHandler(paymentService *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+func (h *PaymentHandler) GetDeadLetterJobs(c *gin.Context) {
+	jobs, err := h.paymentService.GetDeadLetterJobs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+func (h *PaymentHandler) ReplayDeadLetterJob(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	err = h.paymentService.ReplayDeadLetterJob(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payment job requeued successfully"})
+}
+
+func (h *PaymentHandler) ReplayDeadLetterJobs(c *gin.Context) {
+	replayed, err := h.paymentService.ReplayDeadLetterJobs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "replayed": replayed})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PromoCodeHandler struct {
+	promoCodeService *services.PromoCodeService
+}
+
+func NewPromoCodeHandler(promoCodeService *services.PromoCodeService) *PromoCodeHandler {
+	return &PromoCodeHandler{
+		promoCodeService: promoCodeService,
+	}
+}
+
+func (h *PromoCodeHandler) CreatePromoCode(c *gin.Context) {
+	var req models.CreatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promoCode, err := h.promoCodeService.CreatePromoCode(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPromoCodeExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidPromoCode):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, promoCode)
+}
+
+func (h *PromoCodeHandler) GetPromoCode(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code ID"})
+		return
+	}
+
+	promoCode, err := h.promoCodeService.GetPromoCode(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, promoCode)
+}
+
+func (h *PromoCodeHandler) GetPromoCodes(c *gin.Context) {
+	var page models.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promoCodes, err := h.promoCodeService.GetPromoCodes(c.Request.Context(), &page)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promoCodes)
+}
+
+func (h *PromoCodeHandler) UpdatePromoCode(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code ID"})
+		return
+	}
+
+	var req models.UpdatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promoCode, err := h.promoCodeService.UpdatePromoCode(c.Request.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPromoCode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, promoCode)
+}
+
+func (h *PromoCodeHandler) DeletePromoCode(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promo code ID"})
+		return
+	}
+
+	err = h.promoCodeService.DeletePromoCode(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrPromoCodeInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
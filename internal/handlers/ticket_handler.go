@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ticket-booking-system/internal/middleware"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TicketHandler struct {
+	ticketService *services.TicketService
+}
+
+func NewTicketHandler(ticketService *services.TicketService) *TicketHandler {
+	return &TicketHandler{ticketService: ticketService}
+}
+
+// GetTickets lists a booking's tickets with their signed payloads
+func (h *TicketHandler) GetTickets(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	tickets, err := h.ticketService.GetTickets(c.Request.Context(), bookingID, middleware.CallerFrom(c))
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tickets)
+}
+
+// GetTicketQR returns a ticket's QR code as a PNG image
+func (h *TicketHandler) GetTicketQR(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	ticketID, err := uuid.Parse(c.Param("ticket_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+
+	png, err := h.ticketService.GetTicketQR(c.Request.Context(), bookingID, ticketID, middleware.CallerFrom(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTicketRevoked):
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// Tickets admit their holder, so keep them out of shared caches
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "image/png", png)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ticket-booking-system/internal/middleware"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TicketTypeHandler struct {
+	ticketTypeService *services.TicketTypeService
+}
+
+func NewTicketTypeHandler(ticketTypeService *services.TicketTypeService) *TicketTypeHandler {
+	return &TicketTypeHandler{
+		ticketTypeService: ticketTypeService,
+	}
+}
+
+func (h *TicketTypeHandler) GetTicketTypes(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	ticketTypes, err := h.ticketTypeService.GetTicketTypes(c.Request.Context(), eventID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticketTypes)
+}
+
+func (h *TicketTypeHandler) CreateTicketType(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req models.CreateTicketTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticketType, err := h.ticketTypeService.CreateTicketType(c.Request.Context(), eventID, middleware.CallerFrom(c), &req)
+	if err != nil {
+		respondTicketTypeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticketType)
+}
+
+func (h *TicketTypeHandler) UpdateTicketType(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("ticket_type_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket type ID"})
+		return
+	}
+
+	var req models.UpdateTicketTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticketType, err := h.ticketTypeService.UpdateTicketType(c.Request.Context(), eventID, id, middleware.CallerFrom(c), &req)
+	if err != nil {
+		respondTicketTypeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticketType)
+}
+
+func (h *TicketTypeHandler) DeleteTicketType(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("ticket_type_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket type ID"})
+		return
+	}
+
+	err = h.ticketTypeService.DeleteTicketType(c.Request.Context(), eventID, id, middleware.CallerFrom(c))
+	if err != nil {
+		respondTicketTypeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondTicketTypeError reports a failed ticket type change. Anything that is
+// not a validation or conflict error means the event or ticket type is missing.
+func respondTicketTypeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTicketType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTicketTypeInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type VenueHandler struct {
+	venueService *services.VenueService
+}
+
+func NewVenueHandler(venueService *services.VenueService) *VenueHandler {
+	return &VenueHandler{
+		venueService: venueService,
+	}
+}
+
+func (h *VenueHandler) CreateVenue(c *gin.Context) {
+	var req models.CreateVenueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	venue, err := h.venueService.CreateVenue(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSeatMap) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, venue)
+}
+
+func (h *VenueHandler) GetVenue(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid venue ID"})
+		return
+	}
+
+	venue, err := h.venueService.GetVenue(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, venue)
+}
+
+func (h *VenueHandler) GetVenues(c *gin.Context) {
+	var page models.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	venues, err := h.venueService.GetVenues(c.Request.Context(), &page)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, venues)
+}
+
+func (h *VenueHandler) DeleteVenue(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid venue ID"})
+		return
+	}
+
+	err = h.venueService.DeleteVenue(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrVenueInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
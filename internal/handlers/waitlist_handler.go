@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"ticket-booking-system/internal/middleware"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WaitlistHandler struct {
+	waitlistService *services.WaitlistService
+	paymentService  *services.PaymentService
+}
+
+func NewWaitlistHandler(waitlistService *services.WaitlistService, paymentService *services.PaymentService) *WaitlistHandler {
+	return &WaitlistHandler{
+		waitlistService: waitlistService,
+		paymentService:  paymentService,
+	}
+}
+
+func (h *WaitlistHandler) JoinWaitlist(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req models.JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	caller := middleware.CallerFrom(c)
+
+	entry, err := h.waitlistService.JoinWaitlist(c.Request.Context(), eventID, caller.UserID, &req)
+	if err != nil {
+		if respondBookingLimitError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrAlreadyOnWaitlist), errors.Is(err, services.ErrTicketsAvailable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// GetPosition returns the caller's waitlist entry for the event
+func (h *WaitlistHandler) GetPosition(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	caller := middleware.CallerFrom(c)
+
+	entry, err := h.waitlistService.GetPosition(c.Request.Context(), eventID, caller.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// LeaveWaitlist takes the caller off the event's waitlist, declining any open
+// offer
+func (h *WaitlistHandler) LeaveWaitlist(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	caller := middleware.CallerFrom(c)
+
+	err = h.waitlistService.LeaveWaitlist(c.Request.Context(), eventID, caller.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotOnWaitlist):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNoOffer):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left the waitlist successfully"})
+}
+
+// AcceptOffer queues the payment for the booking offered to the caller
+func (h *WaitlistHandler) AcceptOffer(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	caller := middleware.CallerFrom(c)
+
+	booking, err := h.waitlistService.AcceptOffer(c.Request.Context(), eventID, caller.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoOffer) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.paymentService.QueuePayment(context.WithoutCancel(c.Request.Context()), booking.ID, booking.TotalAmount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue the payment, retry the request"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, booking)
+}
+
+// GetWaitlist returns one page of an event's waitlist in queue order
+func (h *WaitlistHandler) GetWaitlist(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var filter models.WaitlistFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := h.waitlistService.GetWaitlist(c.Request.Context(), eventID, &filter)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ticket-booking-system/internal/middleware"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// respondWebhookError maps webhook service errors to responses, falling back
+// to fallback for anything unexpected
+func respondWebhookError(c *gin.Context, err error, fallback int) {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrWebhookNotFound), errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(fallback, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateWebhook subscribes a URL to booking events. The response carries the
+// signing secret, which cannot be read again.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webhookService.CreateWebhook(c.Request.Context(), middleware.CallerFrom(c), &req)
+	if err != nil {
+		respondWebhookError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	subscriptions, err := h.webhookService.GetWebhooks(c.Request.Context(), middleware.CallerFrom(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	subscription, err := h.webhookService.GetWebhook(c.Request.Context(), id, middleware.CallerFrom(c))
+	if err != nil {
+		respondWebhookError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webhookService.UpdateWebhook(c.Request.Context(), id, middleware.CallerFrom(c), &req)
+	if err != nil {
+		respondWebhookError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), id, middleware.CallerFrom(c)); err != nil {
+		respondWebhookError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries returns one page of a webhook's deliveries, newest first
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	var filter models.WebhookDeliveryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(c.Request.Context(), id, middleware.CallerFrom(c), &filter)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCursor) {
+			respondListError(c, err)
+			return
+		}
+		respondWebhookError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayDelivery queues a delivery to be sent again
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := h.webhookService.ReplayDelivery(c.Request.Context(), id, deliveryID, middleware.CallerFrom(c))
+	if err != nil {
+		respondWebhookError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,131 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type closer struct {
+	name string
+	fn   func() error
+}
+
+// Manager runs the HTTP server and background workers and shuts them down in
+// order: the HTTP server drains first, then workers finish their current job,
+// then resources such as Redis and the database are closed.
+type Manager struct {
+	server          *http.Server
+	shutdownTimeout time.Duration
+	logger          *slog.Logger
+
+	workerCtx    context.Context
+	cancelWorker context.CancelFunc
+	workers      sync.WaitGroup
+	closers      []closer
+}
+
+func NewManager(server *http.Server, shutdownTimeout time.Duration, logger *slog.Logger) *Manager {
+	workerCtx, cancelWorker := context.WithCancel(context.Background())
+
+	return &Manager{
+		server:          server,
+		shutdownTimeout: shutdownTimeout,
+		logger:          logger,
+		workerCtx:       workerCtx,
+		cancelWorker:    cancelWorker,
+	}
+}
+
+// Go starts a background worker. The worker must return once its context is
+// cancelled, after finishing the job it is working on.
+func (m *Manager) Go(name string, worker func(ctx context.Context)) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		worker(m.workerCtx)
+		m.logger.Info("Worker stopped", slog.String("worker", name))
+	}()
+}
+
+// OnShutdown registers a resource to close once the server and workers have
+// stopped. Resources are closed in the order they were registered.
+func (m *Manager) OnShutdown(name string, fn func() error) {
+	m.closers = append(m.closers, closer{name: name, fn: fn})
+}
+
+// Run serves HTTP until ctx is cancelled or the server fails, then shuts
+// everything down.
+func (m *Manager) Run(ctx context.Context) error {
+	serverErr := make(chan error, 1)
+	go func() {
+		m.logger.Info("Server starting", slog.String("addr", m.server.Addr))
+		if err := m.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		m.logger.Info("Shutdown signal received")
+	case err := <-serverErr:
+		if err != nil {
+			runErr = fmt.Errorf("server failed: %w", err)
+		}
+	}
+
+	if err := m.Shutdown(); err != nil && runErr == nil {
+		runErr = err
+	}
+
+	return runErr
+}
+
+// Shutdown stops the server, then the workers, then closes resources, all
+// within the configured shutdown timeout.
+func (m *Manager) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	m.logger.Info("Shutting down HTTP server")
+	if err := m.server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+	}
+
+	m.logger.Info("Stopping background workers")
+	m.cancelWorker()
+
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("workers did not stop before the shutdown timeout"))
+	}
+
+	for _, c := range m.closers {
+		m.logger.Info("Closing resource", slog.String("resource", c.name))
+		if err := c.fn(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	m.logger.Info("Shutdown complete")
+	return nil
+}
@@ -0,0 +1,80 @@
+package lifecycle
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func newTestServer() *http.Server {
+	return &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler(), ReadHeaderTimeout: time.Second}
+}
+
+func TestManager_ShutdownOrder(t *testing.T) {
+	events := &recorder{}
+	manager := NewManager(newTestServer(), 5*time.Second, logging.Discard())
+
+	manager.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		// Simulate finishing the job in progress
+		time.Sleep(20 * time.Millisecond)
+		events.add("worker")
+	})
+	manager.OnShutdown("redis", func() error { events.add("redis"); return nil })
+	manager.OnShutdown("database", func() error { events.add("database"); return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- manager.Run(ctx) }()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("manager did not shut down")
+	}
+
+	assert.Equal(t, []string{"worker", "redis", "database"}, events.list())
+}
+
+func TestManager_ShutdownTimeout(t *testing.T) {
+	events := &recorder{}
+	manager := NewManager(newTestServer(), 50*time.Millisecond, logging.Discard())
+
+	stuck := make(chan struct{})
+	t.Cleanup(func() { close(stuck) })
+	manager.Go("stuck worker", func(ctx context.Context) { <-stuck })
+	manager.OnShutdown("database", func() error { events.add("database"); return nil })
+
+	err := manager.Shutdown()
+
+	// Resources are still closed when a worker overruns the timeout
+	assert.Error(t, err)
+	assert.Equal(t, []string{"database"}, events.list())
+}
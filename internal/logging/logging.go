@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// New returns a logger that writes JSON records at level and above to w.
+// Records logged with a context carry the fields attached to it with With,
+// and the trace and span IDs of its span.
+func New(w io.Writer, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return nil, fmt.Errorf("invalid log level %q, want debug, info, warn or error", level)
+	}
+
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl})
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// Discard returns a logger that writes nothing, for tests
+func Discard() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// With returns ctx with fields attached, so that every record logged with
+// the context, in this layer or below, carries them
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(contextKey{}).([]slog.Attr)
+
+	fields := make([]slog.Attr, 0, len(existing)+len(attrs))
+	fields = append(fields, existing...)
+	fields = append(fields, attrs...)
+
+	return context.WithValue(ctx, contextKey{}, fields)
+}
+
+// RequestID returns the request ID attached to ctx, if any
+func RequestID(ctx context.Context) string {
+	fields, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	for _, field := range fields {
+		if field.Key == RequestIDKey {
+			return field.Value.String()
+		}
+	}
+
+	return ""
+}
+
+// Field names shared by all records
+const (
+	RequestIDKey = "request_id"
+	UserIDKey    = "user_id"
+	EventIDKey   = "event_id"
+	BookingIDKey = "booking_id"
+	ErrorKey     = "error"
+)
+
+func UserID(id uuid.UUID) slog.Attr {
+	return slog.String(UserIDKey, id.String())
+}
+
+func EventID(id uuid.UUID) slog.Attr {
+	return slog.String(EventIDKey, id.String())
+}
+
+func BookingID(id uuid.UUID) slog.Attr {
+	return slog.String(BookingIDKey, id.String())
+}
+
+func Err(err error) slog.Attr {
+	return slog.Any(ErrorKey, err)
+}
+
+// contextHandler adds the fields attached to a record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if fields, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		record.AddAttrs(fields...)
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// decode returns the fields of each JSON record written to buf
+func decode(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var record map[string]any
+		require.NoError(t, decoder.Decode(&record))
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn")
+	require.NoError(t, err)
+
+	logger.Info("dropped")
+	logger.Warn("kept")
+
+	records := decode(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "kept", records[0]["msg"])
+	assert.Equal(t, "WARN", records[0]["level"])
+}
+
+func TestNew_InvalidLevel(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "verbose")
+	assert.Error(t, err)
+}
+
+func TestWith_AddsContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info")
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	bookingID := uuid.New()
+
+	ctx := With(context.Background(), UserID(userID))
+	ctx = With(ctx, BookingID(bookingID))
+
+	logger.InfoContext(ctx, "with fields")
+	logger.Info("without context")
+
+	records := decode(t, &buf)
+	require.Len(t, records, 2)
+	assert.Equal(t, userID.String(), records[0][UserIDKey])
+	assert.Equal(t, bookingID.String(), records[0][BookingIDKey])
+	assert.NotContains(t, records[1], UserIDKey)
+}
+
+func TestWith_DoesNotChangeParent(t *testing.T) {
+	parent := With(context.Background(), EventID(uuid.New()))
+	_ = With(parent, BookingID(uuid.New()))
+
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info")
+	require.NoError(t, err)
+	logger.InfoContext(parent, "parent")
+
+	records := decode(t, &buf)
+	require.Len(t, records, 1)
+	assert.Contains(t, records[0], EventIDKey)
+	assert.NotContains(t, records[0], BookingIDKey)
+}
+
+func TestHandler_AddsTraceIDs(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info")
+	require.NoError(t, err)
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	logger.InfoContext(ctx, "traced")
+
+	records := decode(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, spanContext.TraceID().String(), records[0]["trace_id"])
+	assert.Equal(t, spanContext.SpanID().String(), records[0]["span_id"])
+}
+
+func TestRequestID(t *testing.T) {
+	assert.Empty(t, RequestID(context.Background()))
+
+	ctx := With(context.Background(), slog.String(RequestIDKey, "abc-123"))
+	assert.Equal(t, "abc-123", RequestID(ctx))
+}
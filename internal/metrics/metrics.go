@@ -0,0 +1,250 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ticket_booking"
+
+// collectTimeout bounds the queries made while a scrape is collected
+const collectTimeout = 5 * time.Second
+
+// Reasons a ticket reservation fails
+const (
+	ReservationSoldOut = "sold_out"
+	ReservationError   = "error"
+)
+
+// Outcomes of a payment job
+const (
+	PaymentSucceeded = "succeeded"
+	PaymentDeclined  = "declined"
+	PaymentFailed    = "failed" // retried or dead-lettered
+)
+
+// PaymentQueueSource reports the number of payment jobs in each queue
+type PaymentQueueSource interface {
+	QueueDepths(ctx context.Context) (map[string]int64, error)
+}
+
+// SellThroughSource reports the sales of upcoming events
+type SellThroughSource interface {
+	GetSellThrough(ctx context.Context) ([]*models.EventSellThrough, error)
+}
+
+// Metrics records the service's Prometheus metrics in a registry. A nil
+// *Metrics records nothing, so components work without metrics.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequestDuration *prometheus.HistogramVec
+	bookings            *prometheus.CounterVec
+	paymentJobDuration  *prometheus.HistogramVec
+	reservationFailures *prometheus.CounterVec
+}
+
+// New registers the service's metrics, and the Go runtime and process
+// metrics, in registry
+func New(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		registry: registry,
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route and response status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		bookings: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "bookings_total",
+			Help:      "Booking state changes by the status changed to.",
+		}, []string{"status"}),
+		paymentJobDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "payment_job_duration_seconds",
+			Help:      "Time taken to process a payment job by outcome.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"outcome"}),
+		reservationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ticket_reservation_failures_total",
+			Help:      "Ticket reservations that failed, by event and reason.",
+		}, []string{"event_id", "reason"}),
+	}
+
+	registry.MustRegister(
+		m.httpRequestDuration,
+		m.bookings,
+		m.paymentJobDuration,
+		m.reservationFailures,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Handler serves the registry's metrics in the Prometheus text format. A
+// collector that fails is logged and left out of the scrape.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		ErrorLog:      log.Default(),
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// RegisterDB reports the connection pool statistics of db
+func (m *Metrics) RegisterDB(db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// RegisterPaymentQueue reports the depth of the payment queues, read when
+// metrics are scraped
+func (m *Metrics) RegisterPaymentQueue(source PaymentQueueSource) {
+	m.registry.MustRegister(&paymentQueueCollector{
+		source: source,
+		depth: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "payment", "queue_depth"),
+			"Payment jobs in each queue.",
+			[]string{"queue"}, nil,
+		),
+	})
+}
+
+// RegisterSellThrough reports the sales of upcoming events, read when metrics
+// are scraped
+func (m *Metrics) RegisterSellThrough(source SellThroughSource) {
+	labels := []string{"event_id"}
+	m.registry.MustRegister(&sellThroughCollector{
+		source: source,
+		capacity: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "event", "tickets_total"),
+			"Tickets of an upcoming event.", labels, nil,
+		),
+		sold: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "event", "tickets_sold"),
+			"Tickets of an upcoming event in confirmed bookings.", labels, nil,
+		),
+		reserved: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "event", "tickets_reserved"),
+			"Tickets of an upcoming event in pending bookings.", labels, nil,
+		),
+		sellThrough: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "event", "sell_through_ratio"),
+			"Share of an upcoming event's tickets that have been sold.", labels, nil,
+		),
+	})
+}
+
+// ObserveHTTPRequest records a request to route, the pattern it matched
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// BookingEvent counts a booking event, such as booking.confirmed, by the
+// status it changed the booking to
+func (m *Metrics) BookingEvent(eventType string) {
+	if m == nil {
+		return
+	}
+
+	m.bookings.WithLabelValues(strings.TrimPrefix(eventType, "booking.")).Inc()
+}
+
+// ObservePaymentJob records the processing time of a payment job
+func (m *Metrics) ObservePaymentJob(outcome string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.paymentJobDuration.WithLabelValues(outcome).Observe(duration.Seconds())
+}
+
+// ReservationFailed counts a ticket reservation for an event that failed
+func (m *Metrics) ReservationFailed(eventID uuid.UUID, reason string) {
+	if m == nil {
+		return
+	}
+
+	m.reservationFailures.WithLabelValues(eventID.String(), reason).Inc()
+}
+
+type paymentQueueCollector struct {
+	source PaymentQueueSource
+	depth  *prometheus.Desc
+}
+
+func (c *paymentQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+}
+
+func (c *paymentQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	depths, err := c.source.QueueDepths(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.depth, err)
+		return
+	}
+
+	for queue, depth := range depths {
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(depth), queue)
+	}
+}
+
+type sellThroughCollector struct {
+	source      SellThroughSource
+	capacity    *prometheus.Desc
+	sold        *prometheus.Desc
+	reserved    *prometheus.Desc
+	sellThrough *prometheus.Desc
+}
+
+func (c *sellThroughCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.capacity
+	ch <- c.sold
+	ch <- c.reserved
+	ch <- c.sellThrough
+}
+
+func (c *sellThroughCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	events, err := c.source.GetSellThrough(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.sellThrough, err)
+		return
+	}
+
+	for _, event := range events {
+		eventID := event.EventID.String()
+		ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(event.TotalTickets), eventID)
+		ch <- prometheus.MustNewConstMetric(c.sold, prometheus.GaugeValue, float64(event.Sold), eventID)
+		ch <- prometheus.MustNewConstMetric(c.reserved, prometheus.GaugeValue, float64(event.Reserved), eventID)
+
+		ratio := 0.0
+		if event.TotalTickets > 0 {
+			ratio = float64(event.Sold) / float64(event.TotalTickets)
+		}
+		ch <- prometheus.MustNewConstMetric(c.sellThrough, prometheus.GaugeValue, ratio, eventID)
+	}
+}
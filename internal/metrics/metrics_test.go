@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sellThroughFunc func(ctx context.Context) ([]*models.EventSellThrough, error)
+
+func (f sellThroughFunc) GetSellThrough(ctx context.Context) ([]*models.EventSellThrough, error) {
+	return f(ctx)
+}
+
+func TestMetrics_SellThrough(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+
+	eventID := uuid.New()
+	m.RegisterSellThrough(sellThroughFunc(func(context.Context) ([]*models.EventSellThrough, error) {
+		return []*models.EventSellThrough{{EventID: eventID, TotalTickets: 200, Sold: 50, Reserved: 10}}, nil
+	}))
+
+	expected := fmt.Sprintf(`
+# HELP ticket_booking_event_sell_through_ratio Share of an upcoming event's tickets that have been sold.
+# TYPE ticket_booking_event_sell_through_ratio gauge
+ticket_booking_event_sell_through_ratio{event_id=%[1]q} 0.25
+# HELP ticket_booking_event_tickets_reserved Tickets of an upcoming event in pending bookings.
+# TYPE ticket_booking_event_tickets_reserved gauge
+ticket_booking_event_tickets_reserved{event_id=%[1]q} 10
+`, eventID)
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"ticket_booking_event_sell_through_ratio", "ticket_booking_event_tickets_reserved"))
+}
+
+func TestMetrics_Handler_SkipsFailingCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+	m.RegisterSellThrough(sellThroughFunc(func(context.Context) ([]*models.EventSellThrough, error) {
+		return nil, errors.New("database is down")
+	}))
+	m.ObserveHTTPRequest(http.MethodGet, "/api/v1/events", http.StatusOK, 20*time.Millisecond)
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, `ticket_booking_http_request_duration_seconds_count{method="GET",route="/api/v1/events",status="200"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+	assert.NotContains(t, body, "ticket_booking_event_sell_through_ratio{")
+}
+
+func TestMetrics_NilRecordsNothing(t *testing.T) {
+	var m *Metrics
+
+	assert.NotPanics(t, func() {
+		m.ObserveHTTPRequest(http.MethodGet, "/", http.StatusOK, time.Millisecond)
+		m.BookingEvent(models.BookingEventCreated)
+		m.ObservePaymentJob(PaymentSucceeded, time.Millisecond)
+		m.ReservationFailed(uuid.New(), ReservationSoldOut)
+	})
+}
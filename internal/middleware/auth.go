@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	callerKey  = "caller"
+	scannerKey = "scanner"
+)
+
+// ScannerTokenHeader carries the token of a gate scanner device
+const ScannerTokenHeader = "X-Scanner-Token"
+
+// Authenticate requires a valid bearer access token and stores the caller it
+// identifies in the gin context.
+func Authenticate(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or malformed Authorization header"})
+			return
+		}
+
+		caller, err := authService.Authenticate(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(callerKey, caller)
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), logging.UserID(caller.UserID)))
+		c.Next()
+	}
+}
+
+// RequireRole only lets callers with one of the given roles through. It must
+// be used after Authenticate.
+func RequireRole(roles ...models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := CallerFrom(c)
+		if caller == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+
+		for _, role := range roles {
+			if caller.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	}
+}
+
+// CallerFrom returns the authenticated caller, or nil if the request was not
+// authenticated
+func CallerFrom(c *gin.Context) *models.Caller {
+	value, ok := c.Get(callerKey)
+	if !ok {
+		return nil
+	}
+
+	caller, _ := value.(*models.Caller)
+	return caller
+}
+
+// AuthenticateScanner requires a valid scanner device token and stores the
+// device it identifies in the gin context.
+func AuthenticateScanner(checkInService *services.CheckInService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader(ScannerTokenHeader)
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing " + ScannerTokenHeader + " header"})
+			return
+		}
+
+		device, err := checkInService.AuthenticateScanner(c.Request.Context(), token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(scannerKey, device)
+		c.Next()
+	}
+}
+
+// ScannerFrom returns the authenticated scanner device, or nil if the request
+// was not authenticated by one
+func ScannerFrom(c *gin.Context) *models.ScannerDevice {
+	value, ok := c.Get(scannerKey)
+	if !ok {
+		return nil
+	}
+
+	device, _ := value.(*models.ScannerDevice)
+	return device
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	committedKey = "idempotency_committed"
+)
+
+// MarkCommitted records that the request's effects, such as a booking, have
+// been committed, so that its response is replayed on retries whatever its
+// status
+func MarkCommitted(c *gin.Context) {
+	c.Set(committedKey, true)
+}
+
+// replayable reports whether the response to a request should be replayed on
+// retries: when its effects were committed, or when it failed in a way that
+// retrying cannot change. Server errors, conflicts, timeouts, requests refused
+// by a limit until Retry-After and cancelled requests that committed nothing
+// are processed again on a retry.
+func replayable(c *gin.Context) bool {
+	if c.GetBool(committedKey) {
+		return true
+	}
+	if c.Request.Context().Err() != nil {
+		return false
+	}
+
+	status := c.Writer.Status()
+	return status < http.StatusInternalServerError &&
+		status != http.StatusRequestTimeout &&
+		status != http.StatusConflict &&
+		status != http.StatusTooManyRequests
+}
+
+// responseRecorder keeps a copy of the response body while writing it through
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// Idempotency makes a route safe to retry. Requests that carry an
+// Idempotency-Key header are processed once; repeats with the same body get the
+// original response replayed, and reusing the key with a different body is
+// rejected. A request whose response is not replayable frees its key for a
+// retry. Handlers call MarkCommitted once their effects are committed.
+// Requests without the header are passed through unchanged.
+func Idempotency(idempotencyService *services.IdempotencyService, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(hash[:])
+		scope := c.Request.Method + " " + c.FullPath()
+		if caller := CallerFrom(c); caller != nil {
+			// Keys are chosen by clients, so keep each user's keys apart
+			scope += " " + caller.UserID.String()
+		}
+
+		record, err := idempotencyService.Begin(c.Request.Context(), scope, key, requestHash)
+		switch {
+		case errors.Is(err, services.ErrIdempotencyKeyReused):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		case record != nil:
+			c.Header(IdempotencyReplayedHeader, "true")
+			c.Data(*record.StatusCode, "application/json; charset=utf-8", record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// The key is stored or released even if the request context has been
+		// cancelled or timed out
+		ctx := context.WithoutCancel(c.Request.Context())
+		if !replayable(c) {
+			if err := idempotencyService.Release(ctx, scope, key); err != nil {
+				logger.ErrorContext(ctx, "Failed to release idempotency key", slog.String("idempotency_key", key), logging.Err(err))
+			}
+			return
+		}
+
+		// A committed request's response is stored even if a later step of it
+		// failed, as retrying would repeat its effects
+		if err := idempotencyService.Complete(ctx, scope, key, c.Writer.Status(), recorder.body.Bytes()); err != nil {
+			logger.ErrorContext(ctx, "Failed to store idempotent response", slog.String("idempotency_key", key), logging.Err(err))
+		}
+	}
+}
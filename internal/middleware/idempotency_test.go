@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryIdempotencyRepository is an in-memory IdempotencyRepositoryInterface
+type memoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+}
+
+func newMemoryIdempotencyRepository() *memoryIdempotencyRepository {
+	return &memoryIdempotencyRepository{records: make(map[string]*models.IdempotencyRecord)}
+}
+
+func (r *memoryIdempotencyRepository) Acquire(ctx context.Context, scope, key, requestHash string, staleBefore, expiredBefore time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.records[scope+key]
+	if ok && !(existing.CompletedAt == nil && existing.CreatedAt.Before(staleBefore)) && !existing.CreatedAt.Before(expiredBefore) {
+		return false, nil
+	}
+
+	r.records[scope+key] = &models.IdempotencyRecord{Scope: scope, Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+	return true, nil
+}
+
+func (r *memoryIdempotencyRepository) Get(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[scope+key]
+	if !ok {
+		return nil, fmt.Errorf("idempotency key not found")
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (r *memoryIdempotencyRepository) Complete(ctx context.Context, scope, key string, statusCode int, responseBody []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	record := r.records[scope+key]
+	record.StatusCode = &statusCode
+	record.ResponseBody = responseBody
+	record.CompletedAt = &now
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.records[scope+key]; ok && record.CompletedAt == nil {
+		delete(r.records, scope+key)
+	}
+	return nil
+}
+
+func newIdempotentRouter(calls *int) *gin.Engine {
+	return newIdempotentRouterWith(func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusCreated, gin.H{"booking": *calls})
+	})
+}
+
+func newIdempotentRouterWith(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	service := services.NewIdempotencyService(newMemoryIdempotencyRepository(), time.Hour)
+
+	router := gin.New()
+	router.POST("/bookings", Idempotency(service, logging.Discard()), handler)
+	return router
+}
+
+func doRequest(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysSameRequest(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls)
+
+	first := doRequest(router, "key-1", `{"quantity":2}`)
+	second := doRequest(router, "key-1", `{"quantity":2}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(IdempotencyReplayedHeader))
+}
+
+func TestIdempotency_RejectsDifferentBody(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls)
+
+	doRequest(router, "key-1", `{"quantity":2}`)
+	w := doRequest(router, "key-1", `{"quantity":3}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls)
+
+	doRequest(router, "", `{"quantity":2}`)
+	doRequest(router, "", `{"quantity":2}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_RetriesAfterServerError(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouterWith(func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database unavailable"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"booking": calls})
+	})
+
+	first := doRequest(router, "key-1", `{"quantity":2}`)
+	second := doRequest(router, "key-1", `{"quantity":2}`)
+	third := doRequest(router, "key-1", `{"quantity":2}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Empty(t, second.Header().Get(IdempotencyReplayedHeader))
+	assert.Equal(t, http.StatusCreated, third.Code)
+	assert.Equal(t, "true", third.Header().Get(IdempotencyReplayedHeader))
+}
+
+func TestIdempotency_RetriesAfterCancelledRequest(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouterWith(func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			// The request timed out and its transaction was rolled back
+			ctx, cancel := context.WithCancel(c.Request.Context())
+			cancel()
+			c.Request = c.Request.WithContext(ctx)
+			c.JSON(http.StatusBadRequest, gin.H{"error": ctx.Err().Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"booking": calls})
+	})
+
+	doRequest(router, "key-1", `{"quantity":2}`)
+	second := doRequest(router, "key-1", `{"quantity":2}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+}
+
+func TestIdempotency_ReplaysCommittedServerError(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouterWith(func(c *gin.Context) {
+		calls++
+		MarkCommitted(c)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Booking created but payment processing failed"})
+	})
+
+	doRequest(router, "key-1", `{"quantity":2}`)
+	second := doRequest(router, "key-1", `{"quantity":2}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusInternalServerError, second.Code)
+	assert.Equal(t, "true", second.Header().Get(IdempotencyReplayedHeader))
+}
+
+func TestIdempotency_RetriesAfterRetryAfter(t *testing.T) {
+	calls := 0
+	limited := true
+	router := newIdempotentRouterWith(func(c *gin.Context) {
+		calls++
+		if limited {
+			SetRetryAfter(c, time.Minute)
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many pending bookings"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"booking": calls})
+	})
+
+	first := doRequest(router, "key-1", `{"quantity":2}`)
+
+	// The client waits out Retry-After, by which time the limit has cleared
+	limited = false
+	second := doRequest(router, "key-1", `{"quantity":2}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusTooManyRequests, first.Code)
+	assert.Equal(t, "60", first.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Empty(t, second.Header().Get(IdempotencyReplayedHeader))
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that ties a request to its log records
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits the request IDs taken from clients to what is safe to
+// log and echo back
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID gives each request an ID, taken from the X-Request-ID header
+// when the client or a proxy sent a usable one and generated otherwise. The
+// ID is returned in the response header and attached to every record logged
+// with the request's context.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), slog.String(logging.RequestIDKey, id)))
+		c.Next()
+	}
+}
+
+// AccessLog logs each request once it has been handled, at error level for
+// server errors and warn level for client errors. Successful requests to
+// quietPaths, such as health probes, are logged at debug level.
+func AccessLog(logger *slog.Logger, quietPaths ...string) gin.HandlerFunc {
+	quiet := make(map[string]bool, len(quietPaths))
+	for _, path := range quietPaths {
+		quiet[path] = true
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case quiet[c.Request.URL.Path]:
+			level = slog.LevelDebug
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String(logging.ErrorKey, c.Errors.String()))
+		}
+
+		// The request's context carries the user once Authenticate has run
+		logger.LogAttrs(c.Request.Context(), level, "Request handled", attrs...)
+	}
+}
+
+// Recovery turns a panic in a handler into a 500 response and logs it with
+// the request's context
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		logger.ErrorContext(c.Request.Context(), "Recovered from panic",
+			slog.Any("panic", recovered),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("stack", string(debug.Stack())),
+		)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	})
+}
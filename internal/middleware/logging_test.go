@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ticket-booking-system/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLoggingRouter(t *testing.T, buf *bytes.Buffer) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	logger, err := logging.New(buf, "info")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(RequestID(), AccessLog(logger), Recovery(logger))
+	return router
+}
+
+func lastRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &record))
+	return record
+}
+
+func TestRequestID_GeneratesID(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggingRouter(t, &buf)
+
+	var seen string
+	router.GET("/events", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	id := w.Header().Get(RequestIDHeader)
+	_, err := uuid.Parse(id)
+	require.NoError(t, err)
+	assert.Equal(t, id, seen)
+	assert.Equal(t, id, lastRecord(t, &buf)[logging.RequestIDKey])
+}
+
+func TestRequestID_PropagatesClientID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected bool
+	}{
+		{name: "valid", header: "req-42.a:b_c", expected: true},
+		{name: "unsafe characters", header: "bad id\n", expected: false},
+		{name: "too long", header: string(bytes.Repeat([]byte("a"), 129)), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			router := newLoggingRouter(t, &buf)
+			router.GET("/events", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/events", nil)
+			req.Header.Set(RequestIDHeader, tt.header)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if tt.expected {
+				assert.Equal(t, tt.header, w.Header().Get(RequestIDHeader))
+			} else {
+				assert.NotEqual(t, tt.header, w.Header().Get(RequestIDHeader))
+				assert.NotEmpty(t, w.Header().Get(RequestIDHeader))
+			}
+		})
+	}
+}
+
+func TestAccessLog_Fields(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggingRouter(t, &buf)
+
+	userID := uuid.New()
+	router.GET("/events/:id", func(c *gin.Context) {
+		// As Authenticate does
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), logging.UserID(userID)))
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events/123", nil))
+
+	record := lastRecord(t, &buf)
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, http.MethodGet, record["method"])
+	assert.Equal(t, "/events/:id", record["route"])
+	assert.Equal(t, "/events/123", record["path"])
+	assert.Equal(t, float64(http.StatusNotFound), record["status"])
+	assert.Equal(t, userID.String(), record[logging.UserIDKey])
+	assert.Contains(t, record, "duration")
+}
+
+func TestRecovery_LogsPanic(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggingRouter(t, &buf)
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	records := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, records, 2, "the panic and the access log")
+
+	var panicRecord map[string]any
+	require.NoError(t, json.Unmarshal(records[0], &panicRecord))
+	assert.Equal(t, "boom", panicRecord["panic"])
+	assert.Equal(t, w.Header().Get(RequestIDHeader), panicRecord[logging.RequestIDKey])
+
+	access := lastRecord(t, &buf)
+	assert.Equal(t, "ERROR", access["level"])
+	assert.Equal(t, float64(http.StatusInternalServerError), access["status"])
+}
+
+func TestAccessLog_QuietPaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info")
+	require.NoError(t, err)
+
+	healthy := true
+	router := gin.New()
+	router.Use(AccessLog(logger, "/readyz"))
+	router.GET("/readyz", func(c *gin.Context) {
+		if healthy {
+			c.Status(http.StatusOK)
+			return
+		}
+		c.Status(http.StatusServiceUnavailable)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Zero(t, buf.Len(), "successful probes are logged at debug level")
+
+	healthy = false
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, "ERROR", lastRecord(t, &buf)["level"])
+}
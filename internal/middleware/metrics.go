@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"time"
+
+	"ticket-booking-system/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the duration of each request by the route pattern it
+// matched, so that /events/:id is one series however many events there are.
+// Requests matching no route are recorded as "unmatched".
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		m.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
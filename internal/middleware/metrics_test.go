@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ticket-booking-system/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_RecordsRoutePattern(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := prometheus.NewRegistry()
+	router := gin.New()
+	router.Use(Metrics(metrics.New(registry)))
+	router.GET("/events/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+	})
+
+	for _, path := range []string{"/events/1", "/events/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	count, err := testutil.GatherAndCount(registry, "ticket_booking_http_request_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "one series per route and status")
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "ticket_booking_http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			switch labels["route"] {
+			case "/events/:id":
+				assert.Equal(t, "200", labels["status"])
+				assert.Equal(t, uint64(2), metric.GetHistogram().GetSampleCount())
+			case "unmatched":
+				assert.Equal(t, "404", labels["status"])
+			default:
+				t.Errorf("unexpected route %q", labels["route"])
+			}
+		}
+	}
+}
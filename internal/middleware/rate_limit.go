@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+)
+
+// RateLimit refuses requests over the limit configured for their route with
+// a 429 and a Retry-After header. Requests are counted per route and per
+// client IP and, when authenticated, per caller as well, so that neither
+// rotating IPs nor rotating accounts gets around the limit. It must be used
+// after Authenticate on authenticated routes. Routes without a limit are
+// passed through, as are all requests while Redis is unavailable.
+func RateLimit(limiter *services.RateLimiter, limits map[string]services.RateLimit, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		limit, ok := limits[route]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		keys := []string{route + ":ip:" + c.ClientIP()}
+		if caller := CallerFrom(c); caller != nil {
+			keys = append(keys, route+":user:"+caller.UserID.String())
+		}
+
+		result, err := limiter.AllowAll(c.Request.Context(), keys, limit)
+		if err != nil {
+			logger.WarnContext(c.Request.Context(), "Rate limit check failed, allowing request", logging.Err(err))
+			c.Next()
+			return
+		}
+
+		c.Header(RateLimitLimitHeader, strconv.Itoa(limit.Limit))
+		c.Header(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+
+		if !result.Allowed {
+			SetRetryAfter(c, result.RetryAfter)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please retry later"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SetRetryAfter sets the Retry-After header to d in whole seconds, rounded up
+// so that a client retrying on time is not refused again
+func SetRetryAfter(c *gin.Context, d time.Duration) {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+}
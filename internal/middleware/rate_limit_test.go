@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRateLimitRouter(t *testing.T, limits map[string]services.RateLimit) (*gin.Engine, *miniredis.Miniredis) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	router := gin.New()
+	// Stands in for Authenticate, taking the caller from a test header
+	router.Use(func(c *gin.Context) {
+		if id := c.GetHeader("X-Test-User"); id != "" {
+			c.Set(callerKey, &models.Caller{UserID: uuid.MustParse(id), Role: models.RoleCustomer})
+		}
+	})
+	router.Use(RateLimit(services.NewRateLimiter(rdb), limits, logging.Discard()))
+	router.POST("/bookings", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.GET("/bookings", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	return router, mr
+}
+
+func send(router *gin.Engine, method, remoteAddr, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/bookings", nil)
+	req.RemoteAddr = remoteAddr
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_RefusesOverLimit(t *testing.T) {
+	router, _ := newRateLimitRouter(t, map[string]services.RateLimit{
+		"POST /bookings": {Limit: 2, Window: time.Minute},
+	})
+	userID := uuid.NewString()
+
+	first := send(router, http.MethodPost, "192.0.2.1:1234", userID)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, "2", first.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "1", first.Header().Get(RateLimitRemainingHeader))
+
+	assert.Equal(t, http.StatusCreated, send(router, http.MethodPost, "192.0.2.1:1234", userID).Code)
+
+	refused := send(router, http.MethodPost, "192.0.2.1:1234", userID)
+	assert.Equal(t, http.StatusTooManyRequests, refused.Code)
+	assert.Equal(t, "60", refused.Header().Get("Retry-After"))
+	assert.Equal(t, "0", refused.Header().Get(RateLimitRemainingHeader))
+
+	// Routes without a limit are not counted
+	assert.Equal(t, http.StatusOK, send(router, http.MethodGet, "192.0.2.1:1234", userID).Code)
+}
+
+func TestRateLimit_KeysByUserAndIP(t *testing.T) {
+	router, _ := newRateLimitRouter(t, map[string]services.RateLimit{
+		"POST /bookings": {Limit: 1, Window: time.Minute},
+	})
+	userID := uuid.NewString()
+
+	// A user switching IPs is still counted as one
+	assert.Equal(t, http.StatusCreated, send(router, http.MethodPost, "192.0.2.1:1234", userID).Code)
+	assert.Equal(t, http.StatusTooManyRequests, send(router, http.MethodPost, "192.0.2.2:1234", userID).Code)
+
+	// Accounts sharing an IP are counted against the IP too
+	assert.Equal(t, http.StatusTooManyRequests, send(router, http.MethodPost, "192.0.2.1:1234", uuid.NewString()).Code)
+	assert.Equal(t, http.StatusTooManyRequests, send(router, http.MethodPost, "192.0.2.1:5678", "").Code)
+
+	// The refused request from 192.0.2.2 was not counted against it
+	assert.Equal(t, http.StatusCreated, send(router, http.MethodPost, "192.0.2.2:1234", uuid.NewString()).Code)
+	assert.Equal(t, http.StatusCreated, send(router, http.MethodPost, "192.0.2.3:1234", "").Code)
+}
+
+func TestRateLimit_AllowsWhenRedisIsDown(t *testing.T) {
+	router, mr := newRateLimitRouter(t, map[string]services.RateLimit{
+		"POST /bookings": {Limit: 1, Window: time.Minute},
+	})
+	mr.Close()
+
+	assert.Equal(t, http.StatusCreated, send(router, http.MethodPost, "192.0.2.1:1234", "").Code)
+	assert.Equal(t, http.StatusCreated, send(router, http.MethodPost, "192.0.2.1:1234", "").Code)
+}
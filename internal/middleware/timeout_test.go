@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout_CancelsRequestContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Timeout(20 * time.Millisecond))
+	router.GET("/slow", func(c *gin.Context) {
+		// Stands in for a query that honours the request context
+		<-c.Request.Context().Done()
+	})
+	router.GET("/fast", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	slow := httptest.NewRecorder()
+	router.ServeHTTP(slow, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, slow.Code)
+
+	fast := httptest.NewRecorder()
+	router.ServeHTTP(fast, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Equal(t, http.StatusOK, fast.Code)
+}
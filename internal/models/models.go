@@ -1,28 +1,156 @@
 package models
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Event struct {
+	ID                 uuid.UUID          `json:"id" db:"id"`
+	Name               string             `json:"name" db:"name"`
+	Description        string             `json:"description" db:"description"`
+	DateTime           time.Time          `json:"date_time" db:"date_time"`
+	TotalTickets       int                `json:"total_tickets" db:"total_tickets"`
+	Currency           string             `json:"currency" db:"currency"`                   // of all the event's prices
+	TicketPrice        Money              `json:"ticket_price" db:"ticket_price"`           // cheapest ticket type
+	VenueID            *uuid.UUID         `json:"venue_id,omitempty" db:"venue_id"`         // set for reserved seating
+	OrganizerID        *uuid.UUID         `json:"organizer_id,omitempty" db:"organizer_id"` // who created it
+	CancellationPolicy CancellationPolicy `json:"cancellation_policy"`
+	TicketTypes        []*TicketType      `json:"ticket_types,omitempty"` // only loaded for a single event
+	CreatedAt          time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// TicketType is a tier of an event's tickets, such as VIP or student, with its
+// own price and allocation
+type TicketType struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	EventID     uuid.UUID `json:"event_id" db:"event_id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Price       Money     `json:"price" db:"price"`
+	Allocation  int       `json:"allocation" db:"allocation"`
+	Zone        string    `json:"zone,omitempty" db:"zone"` // the seats it is sold for at reserved seating events
+	Available   int       `json:"available"`                // allocation minus pending and confirmed bookings and live holds
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Reserved returns the tickets of this type taken by pending or confirmed
+// bookings or live holds
+func (t *TicketType) Reserved() int {
+	return t.Allocation - t.Available
+}
+
+// Venue is a location with a seat map of sections, rows and seats
+type Venue struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Address   string     `json:"address" db:"address"`
+	Sections  []*Section `json:"sections,omitempty"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type Section struct {
+	ID   uuid.UUID  `json:"id" db:"id"`
+	Name string     `json:"name" db:"name"`
+	Rows []*SeatRow `json:"rows"`
+}
+
+type SeatRow struct {
+	Label string  `json:"label"`
+	Seats []*Seat `json:"seats"`
+}
+
+type SeatStatus string
+
+const (
+	SeatStatusAvailable SeatStatus = "available"
+	SeatStatusHeld      SeatStatus = "held" // claimed by a pending booking or a live hold
+	SeatStatusSold      SeatStatus = "sold"
+)
+
+type Seat struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	SectionID uuid.UUID  `json:"-" db:"section_id"`
+	Row       string     `json:"-" db:"row_label"`
+	Number    int        `json:"number" db:"seat_number"`
+	Zone      string     `json:"zone,omitempty" db:"zone"` // price zone, matched against ticket types
+	Status    SeatStatus `json:"status,omitempty"`         // only set in seat maps
+}
+
+// SeatMap is a venue's layout with the status of every seat for one event
+type SeatMap struct {
+	EventID  uuid.UUID  `json:"event_id"`
+	VenueID  uuid.UUID  `json:"venue_id"`
+	Sections []*Section `json:"sections"`
+}
+
+// CancellationPolicy decides how much of a confirmed booking is refunded: all
+// of it up to FullRefundHours before the event, PartialRefundPercent of it
+// until NoRefundHours before the event, and nothing after that.
+type CancellationPolicy struct {
+	FullRefundHours      int `json:"full_refund_hours" db:"full_refund_hours" binding:"min=0,gtefield=NoRefundHours"`
+	NoRefundHours        int `json:"no_refund_hours" db:"no_refund_hours" binding:"min=0"`
+	PartialRefundPercent int `json:"partial_refund_percent" db:"partial_refund_percent" binding:"min=0,max=100"`
+}
+
+// DefaultCancellationPolicy applies to events created without a policy
+var DefaultCancellationPolicy = CancellationPolicy{
+	FullRefundHours:      48,
+	NoRefundHours:        24,
+	PartialRefundPercent: 50,
+}
+
+// RefundAmount returns the part of paid that is refunded when cancelling at
+// now. It returns false once the policy no longer allows a refund. A partial
+// refund keeps a rounded cancellation fee and returns the rest.
+func (p CancellationPolicy) RefundAmount(paid Money, eventTime, now time.Time) (Money, bool) {
+	remaining := eventTime.Sub(now)
+
+	switch {
+	case remaining >= time.Duration(p.FullRefundHours)*time.Hour:
+		return paid, true
+	case remaining >= time.Duration(p.NoRefundHours)*time.Hour:
+		fee := paid.Percent(Percent(100-p.PartialRefundPercent) * OnePercent)
+		return paid.Sub(fee), true
+	default:
+		return Zero(paid.Currency), false
+	}
+}
+
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"
+	RoleOrganizer Role = "organizer"
+	RoleCustomer  Role = "customer"
+)
+
+type User struct {
 	ID           uuid.UUID `json:"id" db:"id"`
 	Name         string    `json:"name" db:"name"`
-	Description  string    `json:"description" db:"description"`
-	DateTime     time.Time `json:"date_time" db:"date_time"`
-	TotalTickets int       `json:"total_tickets" db:"total_tickets"`
-	TicketPrice  float64   `json:"ticket_price" db:"ticket_price"`
+	Email        string    `json:"email" db:"email"`
+	Role         Role      `json:"role" db:"role"`
+	PasswordHash *string   `json:"-" db:"password_hash"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
-type User struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Email     string    `json:"email" db:"email"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+// Caller identifies the authenticated user making a request
+type Caller struct {
+	UserID uuid.UUID
+	Role   Role
+}
+
+func (c *Caller) IsAdmin() bool {
+	return c.Role == RoleAdmin
 }
 
 type BookingStatus string
@@ -31,33 +159,430 @@ const (
 	BookingStatusPending   BookingStatus = "PENDING"
 	BookingStatusConfirmed BookingStatus = "CONFIRMED"
 	BookingStatusCancelled BookingStatus = "CANCELLED"
+	BookingStatusFailed    BookingStatus = "FAILED"
+	BookingStatusRefunded  BookingStatus = "REFUNDED"
 )
 
 type Booking struct {
-	ID              uuid.UUID     `json:"id" db:"id"`
-	UserID          uuid.UUID     `json:"user_id" db:"user_id"`
-	EventID         uuid.UUID     `json:"event_id" db:"event_id"`
-	Quantity        int           `json:"quantity" db:"quantity"`
-	Status          BookingStatus `json:"status" db:"status"`
-	TotalAmount     float64       `json:"total_amount" db:"total_amount"`
-	PaymentDeadline *time.Time    `json:"payment_deadline" db:"payment_deadline"`
-	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
+	ID               uuid.UUID      `json:"id" db:"id"`
+	UserID           uuid.UUID      `json:"user_id" db:"user_id"`
+	EventID          uuid.UUID      `json:"event_id" db:"event_id"`
+	Quantity         int            `json:"quantity" db:"quantity"`
+	Status           BookingStatus  `json:"status" db:"status"`
+	Items            []*BookingItem `json:"items,omitempty"`
+	PromoCodeID      *uuid.UUID     `json:"promo_code_id,omitempty" db:"promo_code_id"`
+	DiscountAmount   Money          `json:"discount_amount" db:"discount_amount"` // already taken off TotalAmount
+	SeatIDs          []uuid.UUID    `json:"seat_ids,omitempty"`                   // claimed seats for reserved seating
+	TotalAmount      Money          `json:"total_amount" db:"total_amount"`
+	PaymentDeadline  *time.Time     `json:"payment_deadline" db:"payment_deadline"`
+	PaymentReference *string        `json:"payment_reference,omitempty" db:"payment_reference"`
+	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// BookingItem is the number of tickets of one type in a booking, at the price
+// they were booked for
+type BookingItem struct {
+	TicketTypeID uuid.UUID `json:"ticket_type_id"`
+	Quantity     int       `json:"quantity"`
+	UnitPrice    Money     `json:"unit_price"`
+}
+
+type TicketStatus string
+
+const (
+	TicketStatusValid   TicketStatus = "VALID"
+	TicketStatusRevoked TicketStatus = "REVOKED" // the booking was refunded or cancelled
+)
+
+// Ticket admits one person to an event. Tickets are issued when their booking
+// is confirmed and carry a signed payload that is shown as a QR code.
+type Ticket struct {
+	ID           uuid.UUID    `json:"id" db:"id"`
+	BookingID    uuid.UUID    `json:"booking_id" db:"booking_id"`
+	EventID      uuid.UUID    `json:"event_id" db:"event_id"`
+	Number       int          `json:"number" db:"number"` // 1 to the booking's quantity
+	TicketTypeID uuid.UUID    `json:"ticket_type_id" db:"ticket_type_id"`
+	SeatID       *uuid.UUID   `json:"seat_id,omitempty" db:"seat_id"`
+	Status       TicketStatus `json:"status" db:"status"`
+	Payload      string       `json:"payload,omitempty"` // signed, only for valid tickets
+	CheckedInAt  *time.Time   `json:"checked_in_at,omitempty" db:"checked_in_at"`
+	CheckedInBy  *uuid.UUID   `json:"checked_in_by,omitempty" db:"checked_in_device_id"` // scanner device
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+	RevokedAt    *time.Time   `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// ScannerDevice is a gate scanner registered for one event. It authenticates
+// with a token that is only returned when the device is registered.
+type ScannerDevice struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	EventID   uuid.UUID  `json:"event_id" db:"event_id"`
+	Name      string     `json:"name" db:"name"`
+	Gate      string     `json:"gate" db:"gate"`
+	Token     string     `json:"token,omitempty"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+type ScanResult string
+
+const (
+	ScanResultAdmitted   ScanResult = "ADMITTED"
+	ScanResultDuplicate  ScanResult = "DUPLICATE"   // the ticket was already checked in
+	ScanResultRevoked    ScanResult = "REVOKED"     // the booking was refunded or cancelled
+	ScanResultWrongEvent ScanResult = "WRONG_EVENT" // a valid ticket for another event
+	ScanResultInvalid    ScanResult = "INVALID"     // malformed, forged or unknown
+)
+
+// TicketScan is one entry of an event's scan log. Every scan is logged,
+// whether or not it admitted anyone. Scans made offline also record the
+// device's own ID for the scan and what the device decided.
+type TicketScan struct {
+	ID           uuid.UUID   `json:"id" db:"id"`
+	EventID      uuid.UUID   `json:"event_id" db:"event_id"`
+	DeviceID     uuid.UUID   `json:"device_id" db:"device_id"`
+	Gate         string      `json:"gate" db:"gate"`
+	TicketID     *uuid.UUID  `json:"ticket_id,omitempty" db:"ticket_id"`
+	Result       ScanResult  `json:"result" db:"result"`
+	ClientScanID *uuid.UUID  `json:"client_scan_id,omitempty" db:"client_scan_id"`
+	DeviceResult *ScanResult `json:"device_result,omitempty" db:"device_result"`
+	ScannedAt    time.Time   `json:"scanned_at" db:"scanned_at"`
+	SyncedAt     *time.Time  `json:"synced_at,omitempty" db:"synced_at"`
+}
+
+// Conflicting reports whether an offline scan admitted someone with a ticket
+// the server did not accept
+func (s *TicketScan) Conflicting() bool {
+	return s.DeviceResult != nil && *s.DeviceResult == ScanResultAdmitted && s.Result != ScanResultAdmitted
+}
+
+// CheckInConflict is a conflicting offline scan together with the check-in
+// that was accepted for its ticket, if any
+type CheckInConflict struct {
+	Scan              *TicketScan `json:"scan"`
+	CheckedInAt       *time.Time  `json:"checked_in_at,omitempty"`
+	CheckedInDeviceID *uuid.UUID  `json:"checked_in_device_id,omitempty"`
+	CheckedInGate     *string     `json:"checked_in_gate,omitempty"`
+}
+
+// CheckInBundle is a signed list of an event's valid tickets, with the public
+// key that verifies it and the tickets' payloads
+type CheckInBundle struct {
+	EventID     uuid.UUID `json:"event_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Tickets     int       `json:"tickets"`
+	PublicKey   string    `json:"public_key"`
+	Bundle      string    `json:"bundle"`
+}
+
+// SyncScansResult is what the server made of an upload of offline scans, in
+// the order they were scanned
+type SyncScansResult struct {
+	Scans     []*TicketScan `json:"scans"`
+	Conflicts int           `json:"conflicts"`
+}
+
+// CheckInResult tells the scanner whether to let the ticket holder in
+type CheckInResult struct {
+	Result  ScanResult  `json:"result"`
+	Message string      `json:"message"`
+	Ticket  *Ticket     `json:"ticket,omitempty"`
+	Scan    *TicketScan `json:"scan"`
+}
+
+// Booking event types, published when a booking is created and on each change
+// of its status
+const (
+	BookingEventCreated   = "booking.created"
+	BookingEventConfirmed = "booking.confirmed"
+	BookingEventCancelled = "booking.cancelled"
+	BookingEventExpired   = "booking.expired" // cancelled because it was not paid in time
+	BookingEventFailed    = "booking.failed"
+	BookingEventRefunded  = "booking.refunded"
+)
+
+// OutboxEvent is a domain event recorded in the same transaction as the change
+// it describes, until it is published
+type OutboxEvent struct {
+	ID            int64           `json:"id" db:"id"`
+	AggregateType string          `json:"aggregate_type" db:"aggregate_type"`
+	AggregateID   uuid.UUID       `json:"aggregate_id" db:"aggregate_id"`
+	Type          string          `json:"type" db:"event_type"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty" db:"published_at"`
+}
+
+// WebhookSubscription sends booking events of the given types to URL, for
+// one event or, without EventID, for all of them. Secret is only shown when it
+// is set.
+type WebhookSubscription struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	URL        string     `json:"url" db:"url"`
+	Secret     string     `json:"secret,omitempty" db:"secret"`
+	EventTypes []string   `json:"event_types" db:"event_types"`
+	EventID    *uuid.UUID `json:"event_id,omitempty" db:"event_id"`
+	Active     bool       `json:"active" db:"active"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "FAILED" // out of attempts
+)
+
+// WebhookDelivery is one outbox event sent to one subscription, with the
+// outcome of its latest attempt
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id" db:"id"`
+	SubscriptionID uuid.UUID             `json:"subscription_id" db:"subscription_id"`
+	OutboxEventID  int64                 `json:"outbox_event_id" db:"outbox_event_id"`
+	EventType      string                `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage       `json:"payload" db:"payload"`
+	Status         WebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts       int                   `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	ResponseStatus *int                  `json:"response_status,omitempty" db:"response_status"`
+	LastError      *string               `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at" db:"updated_at"`
+}
+
+type DiscountType string
+
+const (
+	DiscountTypePercent DiscountType = "PERCENT"
+	DiscountTypeFixed   DiscountType = "FIXED"
+)
+
+// PromoCode discounts bookings by a percentage or a fixed amount. A code can be
+// limited to one event, or to one of its ticket types, and to a number of
+// redemptions overall and per user.
+type PromoCode struct {
+	ID             uuid.UUID    `json:"id" db:"id"`
+	Code           string       `json:"code" db:"code"`
+	Description    string       `json:"description" db:"description"`
+	DiscountType   DiscountType `json:"discount_type" db:"discount_type"`
+	PercentOff     *Percent     `json:"percent_off,omitempty" db:"percent_off"` // set for PERCENT codes
+	AmountOff      *Money       `json:"amount_off,omitempty" db:"amount_off"`   // set for FIXED codes
+	MaxRedemptions *int         `json:"max_redemptions" db:"max_redemptions"`
+	MaxPerUser     *int         `json:"max_per_user" db:"max_per_user"`
+	Redemptions    int          `json:"redemptions"` // pending and confirmed bookings using the code
+	MinQuantity    int          `json:"min_quantity" db:"min_quantity"`
+	ValidFrom      *time.Time   `json:"valid_from" db:"valid_from"`
+	ValidUntil     *time.Time   `json:"valid_until" db:"valid_until"`
+	EventID        *uuid.UUID   `json:"event_id" db:"event_id"`
+	TicketTypeID   *uuid.UUID   `json:"ticket_type_id" db:"ticket_type_id"`
+	Active         bool         `json:"active" db:"active"`
+	CreatedAt      time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// Discount returns the discount on a booking of items, which must be priced
+// in the code's currency for a fixed discount. Only items of the code's ticket
+// type count when the code is limited to one. A fixed discount never exceeds
+// the discounted items' price, and percentages are rounded to the minor unit.
+func (p *PromoCode) Discount(items []*BookingItem) Money {
+	eligible := Money{}
+	for _, item := range items {
+		if p.TicketTypeID == nil || *p.TicketTypeID == item.TicketTypeID {
+			eligible = eligible.Add(item.UnitPrice.Mul(item.Quantity))
+		}
+	}
+
+	switch {
+	case p.DiscountType == DiscountTypePercent && p.PercentOff != nil:
+		return eligible.Percent(*p.PercentOff)
+	case p.DiscountType == DiscountTypeFixed && p.AmountOff != nil:
+		return p.AmountOff.Min(eligible)
+	default:
+		return Zero(eligible.Currency)
+	}
+}
+
+// EligibleQuantity counts the tickets in items that the code applies to
+func (p *PromoCode) EligibleQuantity(items []*BookingItem) int {
+	quantity := 0
+	for _, item := range items {
+		if p.TicketTypeID == nil || *p.TicketTypeID == item.TicketTypeID {
+			quantity += item.Quantity
+		}
+	}
+	return quantity
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "PENDING"
+	RefundStatusCompleted RefundStatus = "COMPLETED"
+	RefundStatusFailed    RefundStatus = "FAILED"
+)
+
+// Refund records the money returned for a refunded booking. It stays PENDING
+// until the payment gateway has processed it.
+type Refund struct {
+	ID              uuid.UUID    `json:"id" db:"id"`
+	BookingID       uuid.UUID    `json:"booking_id" db:"booking_id"`
+	Amount          Money        `json:"amount" db:"amount"`
+	Status          RefundStatus `json:"status" db:"status"`
+	RefundReference *string      `json:"refund_reference,omitempty" db:"refund_reference"`
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// SeatHold keeps tickets, and seats at reserved seating events, for a user for
+// a short time before checkout. Holds live in Redis rather than the database
+// and simply disappear when they expire. A hold can be extended once.
+type SeatHold struct {
+	ID        uuid.UUID      `json:"id"`
+	EventID   uuid.UUID      `json:"event_id"`
+	UserID    uuid.UUID      `json:"user_id"`
+	Items     []*BookingItem `json:"items"`
+	SeatIDs   []uuid.UUID    `json:"seat_ids,omitempty"`
+	Extended  bool           `json:"extended"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Quantity returns the number of tickets held
+func (h *SeatHold) Quantity() int {
+	quantity := 0
+	for _, item := range h.Items {
+		quantity += item.Quantity
+	}
+	return quantity
+}
+
+type WaitlistStatus string
+
+const (
+	WaitlistStatusWaiting  WaitlistStatus = "WAITING"
+	WaitlistStatusOffered  WaitlistStatus = "OFFERED"  // a pending booking is held for the user
+	WaitlistStatusAccepted WaitlistStatus = "ACCEPTED" // the offered booking was paid for
+	WaitlistStatusExpired  WaitlistStatus = "EXPIRED"  // the offered booking expired or was cancelled
+	WaitlistStatusLeft     WaitlistStatus = "LEFT"
+)
+
+// WaitlistEntry is a user waiting for Quantity tickets of a sold out ticket
+// type. Entries are served first come, first served: when tickets are
+// released, the first waiting entry is offered a pending booking that must be
+// paid for before its payment deadline.
+type WaitlistEntry struct {
+	ID             uuid.UUID      `json:"id" db:"id"`
+	EventID        uuid.UUID      `json:"event_id" db:"event_id"`
+	UserID         uuid.UUID      `json:"user_id" db:"user_id"`
+	TicketTypeID   uuid.UUID      `json:"ticket_type_id" db:"ticket_type_id"`
+	Quantity       int            `json:"quantity" db:"quantity"`
+	Status         WaitlistStatus `json:"status" db:"status"`
+	Position       int            `json:"position,omitempty"` // 1 for the next entry to be offered tickets, while waiting
+	BookingID      *uuid.UUID     `json:"booking_id,omitempty" db:"booking_id"`
+	OfferExpiresAt *time.Time     `json:"offer_expires_at,omitempty" db:"offer_expires_at"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// UserBookingCounts is what a user has booked, checked before they book more
+type UserBookingCounts struct {
+	PendingBookings     int        // unpaid bookings within their deadline
+	NextPaymentDeadline *time.Time // the earliest deadline of those bookings
+	EventTickets        int        // in pending and confirmed bookings for one event
+}
+
+// EventSellThrough is how much of an upcoming event has been sold
+type EventSellThrough struct {
+	EventID      uuid.UUID `json:"event_id"`
+	TotalTickets int       `json:"total_tickets"` // allocated to its ticket types
+	Sold         int       `json:"sold"`          // in confirmed bookings
+	Reserved     int       `json:"reserved"`      // in pending bookings
 }
 
 type EventStatistics struct {
 	EventID          uuid.UUID `json:"event_id"`
 	TotalSold        int       `json:"total_sold"`
-	EstimatedRevenue float64   `json:"estimated_revenue"`
-	AvailableTickets int       `json:"available_tickets"`
+	GrossRevenue     Money     `json:"gross_revenue"` // list price of confirmed and refunded bookings
+	DiscountAmount   Money     `json:"discount_amount"`
+	NetRevenue       Money     `json:"net_revenue"`       // gross minus discounts and refunds
+	EstimatedRevenue Money     `json:"estimated_revenue"` // same as NetRevenue
+	RefundedAmount   Money     `json:"refunded_amount"`
+	AvailableTickets int       `json:"available_tickets"` // of all ticket types, neither booked nor held
+	TicketsOnHold    int       `json:"tickets_on_hold"`   // in live holds
+	CheckedIn        int       `json:"checked_in"`        // tickets scanned in at the gates
+
+	TicketTypes []*TicketTypeStatistics `json:"ticket_types"`
+	Gates       []*GateStatistics       `json:"gates"`
+}
+
+// GateStatistics counts the scans at one gate
+type GateStatistics struct {
+	Gate     string `json:"gate"`
+	Admitted int    `json:"admitted"`
+	Rejected int    `json:"rejected"`
+}
+
+type TicketTypeStatistics struct {
+	TicketTypeID uuid.UUID `json:"ticket_type_id"`
+	Name         string    `json:"name"`
+	Price        Money     `json:"price"`
+	Allocation   int       `json:"allocation"`
+	Sold         int       `json:"sold"`
+	Held         int       `json:"held"`    // in pending bookings
+	OnHold       int       `json:"on_hold"` // in live holds
+	Available    int       `json:"available"`
+	Revenue      Money     `json:"revenue"` // confirmed bookings, before discounts
+	CheckedIn    int       `json:"checked_in"`
+}
+
+type HealthStatus string
+
+const (
+	HealthStatusUp       HealthStatus = "UP"
+	HealthStatusDegraded HealthStatus = "DEGRADED" // a non-critical check is down
+	HealthStatusDown     HealthStatus = "DOWN"
+)
+
+// HealthCheck is the outcome of checking one dependency or worker
+type HealthCheck struct {
+	Status    HealthStatus `json:"status"`
+	Critical  bool         `json:"critical"`
+	LatencyMS float64      `json:"latency_ms"`
+	Error     string       `json:"error,omitempty"`
+
+	// Set by the migrations check
+	MigrationVersion *uint `json:"migration_version,omitempty"`
+
+	// Set by worker heartbeat checks
+	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"`
+}
+
+// HealthReport is the readiness of the service. It is DOWN if any critical
+// check is down.
+type HealthReport struct {
+	Status HealthStatus            `json:"status"`
+	Checks map[string]*HealthCheck `json:"checks"`
 }
 
 type CreateEventRequest struct {
 	Name         string    `json:"name" binding:"required"`
 	Description  string    `json:"description"`
 	DateTime     time.Time `json:"date_time" binding:"required"`
-	TotalTickets int       `json:"total_tickets" binding:"required,min=1"`
-	TicketPrice  float64   `json:"ticket_price" binding:"required,min=0"`
+	TotalTickets int       `json:"total_tickets" binding:"omitempty,min=1"` // defaults to the venue's seats or the ticket types' allocations
+	TicketPrice  *Money    `json:"ticket_price"`                            // required unless TicketTypes are given
+
+	// Reserved seating: tickets are the venue's seats
+	VenueID *string `json:"venue_id" binding:"omitempty,uuid"`
+
+	// Ticket tiers; without them the event sells one tier at TicketPrice
+	TicketTypes []CreateTicketTypeRequest `json:"ticket_types" binding:"omitempty,max=20,dive"`
+
+	// Defaults to DefaultCancellationPolicy when omitted
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy"`
 }
 
 type UpdateEventRequest struct {
@@ -65,12 +590,33 @@ type UpdateEventRequest struct {
 	Description  *string    `json:"description"`
 	DateTime     *time.Time `json:"date_time"`
 	TotalTickets *int       `json:"total_tickets" binding:"omitempty,min=1"`
-	TicketPrice  *float64   `json:"ticket_price" binding:"omitempty,min=0"`
+	TicketPrice  *Money     `json:"ticket_price"` // rejected, prices belong to ticket types
+
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy"`
+}
+
+// CreateTicketTypeRequest adds a ticket type. Its price must be in the
+// event's currency; all ticket types of a new event share one currency.
+type CreateTicketTypeRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	Price       Money  `json:"price"`
+	Allocation  int    `json:"allocation" binding:"required,min=1"`
+	Zone        string `json:"zone" binding:"omitempty,max=50"` // reserved seating only
+}
+
+type UpdateTicketTypeRequest struct {
+	Name        *string `json:"name" binding:"omitempty,max=100"`
+	Description *string `json:"description"`
+	Price       *Money  `json:"price"`
+	Allocation  *int    `json:"allocation" binding:"omitempty,min=1"`
 }
 
 type CreateUserRequest struct {
-	Name  string `json:"name" binding:"required"`
-	Email string `json:"email" binding:"required,email"`
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"omitempty,min=8"`
+	Role     Role   `json:"role" binding:"omitempty,oneof=admin organizer customer"`
 }
 
 type UpdateUserRequest struct {
@@ -78,8 +624,274 @@ type UpdateUserRequest struct {
 	Email *string `json:"email" binding:"omitempty,email"`
 }
 
+type UpdateUserRoleRequest struct {
+	Role Role `json:"role" binding:"required,oneof=admin organizer customer"`
+}
+
+type SignupRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type AuthResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // access token lifetime in seconds
+	User         *User  `json:"user"`
+}
+
+// CreateBookingRequest books Quantity tickets for general admission events,
+// or the seats in SeatIDs for reserved seating events. Items choose the ticket
+// types and can be omitted when the event sells a single ticket type.
 type CreateBookingRequest struct {
-	UserID   string `json:"user_id" binding:"required"`
-	EventID  string `json:"event_id" binding:"required"`
-	Quantity int    `json:"quantity" binding:"required,min=1"`
+	EventID  string               `json:"event_id" binding:"required"`
+	Quantity int                  `json:"quantity" binding:"omitempty,min=1"`
+	Items    []BookingItemRequest `json:"items" binding:"omitempty,max=10,dive"`
+	SeatIDs  []string             `json:"seat_ids" binding:"omitempty,max=50,dive,uuid"`
+
+	PromoCode string `json:"promo_code" binding:"omitempty,max=50"`
+}
+
+// CreateHoldRequest holds tickets like CreateBookingRequest books them
+type CreateHoldRequest struct {
+	EventID  string               `json:"event_id" binding:"required"`
+	Quantity int                  `json:"quantity" binding:"omitempty,min=1,max=50"`
+	Items    []BookingItemRequest `json:"items" binding:"omitempty,max=10,dive"`
+	SeatIDs  []string             `json:"seat_ids" binding:"omitempty,max=50,dive,uuid"`
+}
+
+// CheckoutHoldRequest turns a hold into a booking
+type CheckoutHoldRequest struct {
+	PromoCode string `json:"promo_code" binding:"omitempty,max=50"`
+}
+
+// RegisterScannerRequest registers a scanner device for an event's gate
+type RegisterScannerRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	Gate string `json:"gate" binding:"required,max=50"`
+}
+
+// CheckInRequest carries the signed payload read from a ticket's QR code
+type CheckInRequest struct {
+	Payload string `json:"payload" binding:"required,max=1024"`
+}
+
+// OfflineScan is a scan a gate device decided on while offline. ID is the
+// device's own ID for the scan, so that an upload can be retried.
+type OfflineScan struct {
+	ID        string     `json:"id" binding:"required,uuid"`
+	TicketID  string     `json:"ticket_id" binding:"omitempty,uuid"`
+	Result    ScanResult `json:"result" binding:"required,oneof=ADMITTED DUPLICATE REVOKED WRONG_EVENT INVALID"`
+	ScannedAt time.Time  `json:"scanned_at" binding:"required"`
+}
+
+// SyncScansRequest uploads a gate device's offline scan log
+type SyncScansRequest struct {
+	Scans []OfflineScan `json:"scans" binding:"required,min=1,max=1000,dive"`
+}
+
+// CreateWebhookRequest subscribes URL to booking events. A secret is generated
+// when none is given.
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,oneof=booking.created booking.confirmed booking.cancelled booking.expired booking.failed booking.refunded"`
+	EventID    string   `json:"event_id" binding:"omitempty,uuid"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=100"`
+}
+
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url" binding:"omitempty,url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"omitempty,min=1,dive,oneof=booking.created booking.confirmed booking.cancelled booking.expired booking.failed booking.refunded"`
+	Secret     *string  `json:"secret" binding:"omitempty,min=16,max=100"`
+	Active     *bool    `json:"active"`
+}
+
+// JoinWaitlistRequest waits for Quantity tickets of a ticket type, which can
+// be omitted when the event sells a single ticket type
+type JoinWaitlistRequest struct {
+	Quantity     int    `json:"quantity" binding:"required,min=1"`
+	TicketTypeID string `json:"ticket_type_id" binding:"omitempty,uuid"`
+}
+
+type CreatePromoCodeRequest struct {
+	Code           string       `json:"code" binding:"required,min=3,max=50,alphanum"`
+	Description    string       `json:"description"`
+	DiscountType   DiscountType `json:"discount_type" binding:"required,oneof=PERCENT FIXED"`
+	PercentOff     *Percent     `json:"percent_off"` // required for PERCENT codes
+	AmountOff      *Money       `json:"amount_off"`  // required for FIXED codes
+	MaxRedemptions *int         `json:"max_redemptions" binding:"omitempty,min=1"`
+	MaxPerUser     *int         `json:"max_per_user" binding:"omitempty,min=1"`
+	MinQuantity    int          `json:"min_quantity" binding:"omitempty,min=1"` // defaults to 1
+	ValidFrom      *time.Time   `json:"valid_from"`
+	ValidUntil     *time.Time   `json:"valid_until"`
+	EventID        *string      `json:"event_id" binding:"omitempty,uuid"`
+	TicketTypeID   *string      `json:"ticket_type_id" binding:"omitempty,uuid"` // requires EventID
+}
+
+// UpdatePromoCodeRequest changes a promo code's limits. The code, its discount
+// type and currency and what it applies to cannot change once customers may
+// have used it.
+type UpdatePromoCodeRequest struct {
+	Description    *string    `json:"description"`
+	PercentOff     *Percent   `json:"percent_off"`
+	AmountOff      *Money     `json:"amount_off"`
+	MaxRedemptions *int       `json:"max_redemptions" binding:"omitempty,min=1"`
+	MaxPerUser     *int       `json:"max_per_user" binding:"omitempty,min=1"`
+	MinQuantity    *int       `json:"min_quantity" binding:"omitempty,min=1"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	Active         *bool      `json:"active"`
+}
+
+type BookingItemRequest struct {
+	TicketTypeID string `json:"ticket_type_id" binding:"required,uuid"`
+	Quantity     int    `json:"quantity" binding:"required,min=1"`
+}
+
+type CreateVenueRequest struct {
+	Name     string                 `json:"name" binding:"required"`
+	Address  string                 `json:"address"`
+	Sections []CreateSectionRequest `json:"sections" binding:"required,min=1,dive"`
+}
+
+type CreateSectionRequest struct {
+	Name string             `json:"name" binding:"required,max=100"`
+	Zone string             `json:"zone" binding:"omitempty,max=50"` // price zone of its seats
+	Rows []CreateRowRequest `json:"rows" binding:"required,min=1,dive"`
+}
+
+// CreateRowRequest describes a row of Seats seats numbered from 1. Zone
+// overrides the section's price zone for the row.
+type CreateRowRequest struct {
+	Label string `json:"label" binding:"required,max=10"`
+	Seats int    `json:"seats" binding:"required,min=1,max=500"`
+	Zone  string `json:"zone" binding:"omitempty,max=50"`
+}
+
+// IdempotencyRecord stores the outcome of a request made with an
+// Idempotency-Key so that retries can be answered with the same response.
+type IdempotencyRecord struct {
+	Scope        string     `json:"scope" db:"scope"`
+	Key          string     `json:"key" db:"key"`
+	RequestHash  string     `json:"request_hash" db:"request_hash"`
+	StatusCode   *int       `json:"status_code" db:"status_code"`
+	ResponseBody []byte     `json:"response_body" db:"response_body"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at" db:"completed_at"`
+}
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest selects one page of a keyset-paginated list. Cursor is the
+// next_cursor returned with the previous page.
+type PageRequest struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor string `form:"cursor"`
+}
+
+// PageLimit returns the requested limit, or the default when none was given
+func (p *PageRequest) PageLimit() int {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return p.Limit
+}
+
+// Page is the envelope returned by list endpoints. NextCursor is null on the
+// last page.
+type Page[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// Cursor is the position after the last row of a page: the value of the sort
+// column and the row ID, which breaks ties between equal sort values.
+type Cursor struct {
+	SortKey time.Time
+	ID      uuid.UUID
+}
+
+func (c Cursor) Encode() string {
+	raw := c.SortKey.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	sortKey, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := Cursor{}
+	if cursor.SortKey, err = time.Parse(time.RFC3339Nano, sortKey); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// EventFilter narrows the event list. Dates are RFC 3339 timestamps and prices
+// are in minor units, so they are best combined with a currency.
+type EventFilter struct {
+	PageRequest
+	From            *time.Time `form:"from"`
+	To              *time.Time `form:"to"`
+	Currency        string     `form:"currency" binding:"omitempty,len=3"`
+	MinPrice        *int64     `form:"min_price" binding:"omitempty,min=0"`
+	MaxPrice        *int64     `form:"max_price" binding:"omitempty,min=0"`
+	Search          string     `form:"q"`
+	HasAvailability bool       `form:"has_availability"`
+}
+
+// BookingFilter narrows a booking list
+type BookingFilter struct {
+	PageRequest
+	Status BookingStatus `form:"status" binding:"omitempty,oneof=PENDING CONFIRMED CANCELLED FAILED REFUNDED"`
+}
+
+// ScanFilter narrows an event's scan log
+type ScanFilter struct {
+	PageRequest
+	Gate   string     `form:"gate"`
+	Result ScanResult `form:"result" binding:"omitempty,oneof=ADMITTED DUPLICATE REVOKED WRONG_EVENT INVALID"`
+}
+
+// WebhookDeliveryFilter narrows a subscription's deliveries
+type WebhookDeliveryFilter struct {
+	PageRequest
+	Status WebhookDeliveryStatus `form:"status" binding:"omitempty,oneof=PENDING SUCCEEDED FAILED"`
+}
+
+// WaitlistFilter narrows an event's waitlist
+type WaitlistFilter struct {
+	PageRequest
+	Status WaitlistStatus `form:"status" binding:"omitempty,oneof=WAITING OFFERED ACCEPTED EXPIRED LEFT"`
 }
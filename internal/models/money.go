@@ -0,0 +1,281 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// currencyExponents maps the supported ISO 4217 currency codes to the number
+// of decimal places of their minor unit
+var currencyExponents = map[string]int{
+	"AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2,
+	"ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2,
+	"RON": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2,
+	"USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// ErrUnknownCurrency is returned for a currency code that is not supported
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// ValidateCurrency checks that code is a supported ISO 4217 currency code
+func ValidateCurrency(code string) error {
+	if _, ok := currencyExponents[code]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownCurrency, code)
+	}
+	return nil
+}
+
+// Money is an exact amount in the minor unit of its currency, e.g. cents for
+// USD or yen for JPY. It is encoded in JSON as
+// {"amount": 1999, "currency": "USD"}.
+//
+// Amounts of different currencies cannot be combined; doing so is a
+// programming error and panics. The zero Money has no currency and takes the
+// currency of the first amount added to it.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Zero returns no money in currency
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) Add(other Money) Money {
+	currency := m.matchCurrency(other)
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}
+}
+
+func (m Money) Sub(other Money) Money {
+	currency := m.matchCurrency(other)
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}
+}
+
+// Mul returns the amount multiplied by a quantity
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Percent returns p percent of the amount, rounded half away from zero to the
+// minor unit. Discounts are the rounded percentage of the price; fees are
+// rounded the same way and the rest goes to the customer, so the parts always
+// add up to the original amount.
+func (m Money) Percent(p Percent) Money {
+	return Money{Amount: divRound(m.Amount*int64(p), int64(HundredPercent)), Currency: m.Currency}
+}
+
+// Cmp returns -1, 0 or 1 when m is less than, equal to or greater than other
+func (m Money) Cmp(other Money) int {
+	m.matchCurrency(other)
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Min returns the smaller of two amounts
+func (m Money) Min(other Money) Money {
+	if m.Cmp(other) <= 0 {
+		return m
+	}
+	return other
+}
+
+// String formats the amount in major units, e.g. "19.99 USD" or "500 JPY"
+func (m Money) String() string {
+	exponent := currencyExponents[m.Currency]
+	return formatDecimal(m.Amount, exponent) + " " + m.Currency
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	// A local type without methods avoids recursing into UnmarshalJSON
+	var raw struct {
+		Amount   *int64 `json:"amount"`
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("money must be an object with an integer amount in minor units and a currency: %w", err)
+	}
+	if raw.Amount == nil {
+		return fmt.Errorf("money amount is required")
+	}
+
+	currency := strings.ToUpper(raw.Currency)
+	if err := ValidateCurrency(currency); err != nil {
+		return err
+	}
+
+	m.Amount = *raw.Amount
+	m.Currency = currency
+	return nil
+}
+
+// matchCurrency returns the currency shared by m and other
+func (m Money) matchCurrency(other Money) string {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency
+	case m.Currency == "" && m.Amount == 0:
+		return other.Currency
+	case other.Currency == "" && other.Amount == 0:
+		return m.Currency
+	default:
+		panic(fmt.Sprintf("money: cannot combine %s and %s", m.Currency, other.Currency))
+	}
+}
+
+// Percent is a percentage in hundredths of a percent, so 12.5% is 1250. It is
+// encoded in JSON and stored as a decimal number such as 12.5.
+type Percent int64
+
+const (
+	OnePercent     Percent = 100
+	HundredPercent Percent = 100 * OnePercent
+)
+
+// ParsePercent parses a decimal percentage with at most two decimal places
+func ParsePercent(s string) (Percent, error) {
+	hundredths, err := parseDecimal(s, 2)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage %q: %w", s, err)
+	}
+	return Percent(hundredths), nil
+}
+
+func (p Percent) String() string {
+	return strings.TrimSuffix(strings.TrimRight(formatDecimal(int64(p), 2), "0"), ".")
+}
+
+func (p Percent) MarshalJSON() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	parsed, err := ParsePercent(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// Scan reads a NUMERIC column without going through float64
+func (p *Percent) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*p = Percent(v) * OnePercent
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Percent", src)
+	}
+
+	parsed, err := ParsePercent(s)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+func (p Percent) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// divRound divides n by a positive d, rounding half away from zero
+func divRound(n, d int64) int64 {
+	q, r := n/d, n%d
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= d {
+		if n < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+// formatDecimal formats n / 10^exponent without rounding
+func formatDecimal(n int64, exponent int) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	digits := strconv.FormatInt(n, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	point := len(digits) - exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// parseDecimal parses a decimal number into an integer count of 10^-exponent
+// units, rejecting numbers with more decimal places than exponent
+func parseDecimal(s string, exponent int) (int64, error) {
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("not a number")
+	}
+	if len(fraction) > exponent {
+		return 0, fmt.Errorf("more than %d decimal places", exponent)
+	}
+
+	digits := whole + fraction + strings.Repeat("0", exponent-len(fraction))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("not a number")
+		}
+	}
+
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		n = -n
+	}
+	return n, nil
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoney_Arithmetic(t *testing.T) {
+	price := NewMoney(1999, "USD")
+
+	// 3 x 19.99 is exact in minor units
+	assert.Equal(t, NewMoney(5997, "USD"), price.Mul(3))
+	assert.Equal(t, NewMoney(3998, "USD"), price.Add(price))
+	assert.Equal(t, NewMoney(0, "USD"), price.Sub(price))
+
+	// The zero Money takes the currency of what is added to it
+	assert.Equal(t, price, Money{}.Add(price))
+
+	assert.Panics(t, func() { price.Add(NewMoney(100, "EUR")) })
+}
+
+func TestMoney_Percent(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		percent  Percent
+		expected Money
+	}{
+		{"exact", NewMoney(10000, "USD"), 20 * OnePercent, NewMoney(2000, "USD")},
+		{"half rounds up", NewMoney(7551, "USD"), 50 * OnePercent, NewMoney(3776, "USD")},
+		{"below half rounds down", NewMoney(9999, "USD"), 15 * OnePercent, NewMoney(1500, "USD")},
+		{"fractional percentage", NewMoney(10000, "USD"), 1250, NewMoney(1250, "USD")},
+		{"zero decimal currency", NewMoney(1999, "JPY"), 10 * OnePercent, NewMoney(200, "JPY")},
+		{"negative half rounds away from zero", NewMoney(-7551, "USD"), 50 * OnePercent, NewMoney(-3776, "USD")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.amount.Percent(tt.percent))
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "19.99 USD", NewMoney(1999, "USD").String())
+	assert.Equal(t, "0.05 EUR", NewMoney(5, "EUR").String())
+	assert.Equal(t, "-1.50 GBP", NewMoney(-150, "GBP").String())
+	assert.Equal(t, "500 JPY", NewMoney(500, "JPY").String())
+	assert.Equal(t, "1.234 KWD", NewMoney(1234, "KWD").String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	encoded, err := json.Marshal(NewMoney(1999, "USD"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount": 1999, "currency": "USD"}`, string(encoded))
+
+	var decoded Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": 2500, "currency": "eur"}`), &decoded))
+	assert.Equal(t, NewMoney(2500, "EUR"), decoded)
+
+	for _, invalid := range []string{
+		`{"amount": 19.99, "currency": "USD"}`,
+		`{"currency": "USD"}`,
+		`{"amount": 100, "currency": "XYZ"}`,
+		`{"amount": 100}`,
+		`19.99`,
+	} {
+		assert.Error(t, json.Unmarshal([]byte(invalid), &decoded), invalid)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	var p Percent
+	require.NoError(t, json.Unmarshal([]byte(`12.5`), &p))
+	assert.Equal(t, Percent(1250), p)
+
+	encoded, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.Equal(t, "12.5", string(encoded))
+
+	require.NoError(t, p.Scan([]byte("20.00")))
+	assert.Equal(t, 20*OnePercent, p)
+
+	assert.Error(t, json.Unmarshal([]byte(`12.345`), &p))
+	assert.Error(t, json.Unmarshal([]byte(`"abc"`), &p))
+}
@@ -1,63 +1,226 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"ticket-booking-system/internal/models"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const bookingColumns = `id, user_id, event_id, quantity, status, currency, total_amount, payment_deadline, payment_reference,
+	promo_code_id, discount_amount, created_at, updated_at,
+	ARRAY(SELECT bs.seat_id::text FROM booking_seats bs WHERE bs.booking_id = bookings.id ORDER BY bs.seat_id) AS seat_ids,
+	COALESCE((
+		SELECT json_agg(json_build_object('ticket_type_id', bi.ticket_type_id, 'quantity', bi.quantity,
+			'unit_price', json_build_object('amount', bi.unit_price, 'currency', bookings.currency))
+			ORDER BY bi.ticket_type_id)
+		FROM booking_items bi
+		WHERE bi.booking_id = bookings.id
+	), '[]') AS items`
+
+// ErrSeatTaken is returned when a seat is already claimed by another active
+// booking for the same event
+var ErrSeatTaken = errors.New("seat already taken")
+
+// ErrBookingNotPending is returned by UpdatePendingStatus and ConfirmPayment
+// for bookings that are no longer pending
+var ErrBookingNotPending = errors.New("booking not found or no longer pending")
+
+// issueTickets is a CTE that issues the tickets of the booking returned by a
+// confirmed CTE: one per booked ticket, paired with the booking's seats in order
+// at reserved seating events. Ticket numbers make issuing again a no-op.
+const issueTickets = `issued AS (
+			INSERT INTO tickets (booking_id, event_id, number, ticket_type_id, seat_id)
+			SELECT u.booking_id, u.event_id, u.number, u.ticket_type_id, s.seat_id
+			FROM (
+				SELECT b.id AS booking_id, b.event_id, bi.ticket_type_id,
+					ROW_NUMBER() OVER (ORDER BY bi.ticket_type_id, g.n) AS number
+				FROM confirmed b
+				JOIN booking_items bi ON bi.booking_id = b.id
+				CROSS JOIN generate_series(1, bi.quantity) AS g(n)
+			) u
+			LEFT JOIN (
+				SELECT bs.seat_id, ROW_NUMBER() OVER (ORDER BY bs.seat_id) AS number
+				FROM booking_seats bs
+				WHERE bs.booking_id IN (SELECT id FROM confirmed) AND NOT bs.released
+			) s ON s.number = u.number
+			ON CONFLICT (booking_id, number) DO NOTHING
+		)`
+
+// bookingEventColumns are returned by the statements that change bookings, as
+// b, for recordBookingEvent to describe the change
+const bookingEventColumns = `b.id, b.user_id, b.event_id, b.status, b.quantity, b.currency, b.total_amount,
+	b.discount_amount, b.payment_reference, b.updated_at`
+
+// recordBookingEvent returns a CTE that writes an outbox event of type
+// eventType, a SQL expression, for each booking returned by the CTE source whose
+// status differs from its previous_status
+func recordBookingEvent(source, eventType string) string {
+	return `outboxed AS (
+			INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload)
+			SELECT 'booking', b.id, ` + eventType + `, json_build_object(
+				'booking_id', b.id,
+				'user_id', b.user_id,
+				'event_id', b.event_id,
+				'status', b.status,
+				'previous_status', b.previous_status,
+				'quantity', b.quantity,
+				'total_amount', json_build_object('amount', b.total_amount, 'currency', b.currency),
+				'discount_amount', json_build_object('amount', b.discount_amount, 'currency', b.currency),
+				'payment_reference', b.payment_reference,
+				'occurred_at', b.updated_at
+			)
+			FROM ` + source + ` b
+			WHERE b.previous_status IS DISTINCT FROM b.status
+		)`
+}
+
+// bookingEventTypes are the outbox event types of moving a booking to a status
+var bookingEventTypes = map[models.BookingStatus]string{
+	models.BookingStatusPending:   models.BookingEventCreated,
+	models.BookingStatusConfirmed: models.BookingEventConfirmed,
+	models.BookingStatusCancelled: models.BookingEventCancelled,
+	models.BookingStatusFailed:    models.BookingEventFailed,
+	models.BookingStatusRefunded:  models.BookingEventRefunded,
+}
+
+// uniqueViolation is the Postgres error code for a unique constraint violation
+const uniqueViolation = "23505"
+
 type BookingRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewBookingRepository(db *sql.DB) *BookingRepository {
+func NewBookingRepository(db DBTX) *BookingRepository {
 	return &BookingRepository{db: db}
 }
 
-func (r *BookingRepository) Create(booking *models.Booking) error {
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBooking(row rowScanner) (*models.Booking, error) {
+	booking := &models.Booking{}
+	var seatIDs pq.StringArray
+	var items []byte
+	err := row.Scan(
+		&booking.ID,
+		&booking.UserID,
+		&booking.EventID,
+		&booking.Quantity,
+		&booking.Status,
+		&booking.TotalAmount.Currency,
+		&booking.TotalAmount.Amount,
+		&booking.PaymentDeadline,
+		&booking.PaymentReference,
+		&booking.PromoCodeID,
+		&booking.DiscountAmount.Amount,
+		&booking.CreatedAt,
+		&booking.UpdatedAt,
+		&seatIDs,
+		&items,
+	)
+	if err != nil {
+		return nil, err
+	}
+	booking.DiscountAmount.Currency = booking.TotalAmount.Currency
+
+	if err := json.Unmarshal(items, &booking.Items); err != nil {
+		return nil, err
+	}
+
+	for _, seatID := range seatIDs {
+		id, err := uuid.Parse(seatID)
+		if err != nil {
+			return nil, err
+		}
+		booking.SeatIDs = append(booking.SeatIDs, id)
+	}
+
+	return booking, nil
+}
+
+func (r *BookingRepository) queryBookings(ctx context.Context, query string, args ...interface{}) ([]*models.Booking, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookings []*models.Booking
+	for rows.Next() {
+		booking, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+
+	return bookings, rows.Err()
+}
+
+// Create inserts a booking together with its line items and records a
+// booking.created event
+func (r *BookingRepository) Create(ctx context.Context, booking *models.Booking) error {
 	query := `
-		INSERT INTO bookings (user_id, event_id, quantity, status, total_amount, payment_deadline)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at
+		WITH booking AS (
+			INSERT INTO bookings AS b (user_id, event_id, quantity, status, currency, total_amount, payment_deadline,
+				promo_code_id, discount_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING ` + bookingEventColumns + `, b.created_at, NULL::varchar AS previous_status
+		), items AS (
+			INSERT INTO booking_items (booking_id, ticket_type_id, quantity, unit_price)
+			SELECT booking.id, item.ticket_type_id, item.quantity, item.unit_price
+			FROM booking, unnest($10::uuid[], $11::int[], $12::bigint[]) AS item(ticket_type_id, quantity, unit_price)
+		), ` + recordBookingEvent("booking", "'"+models.BookingEventCreated+"'") + `
+		SELECT id, created_at, updated_at FROM booking
 	`
 
-	err := r.db.QueryRow(
+	ticketTypeIDs := make([]string, len(booking.Items))
+	quantities := make([]int64, len(booking.Items))
+	unitPrices := make([]int64, len(booking.Items))
+	for i, item := range booking.Items {
+		ticketTypeIDs[i] = item.TicketTypeID.String()
+		quantities[i] = int64(item.Quantity)
+		unitPrices[i] = item.UnitPrice.Amount
+	}
+
+	err := r.db.QueryRowContext(
+		ctx,
 		query,
 		booking.UserID,
 		booking.EventID,
 		booking.Quantity,
 		booking.Status,
-		booking.TotalAmount,
+		booking.TotalAmount.Currency,
+		booking.TotalAmount.Amount,
 		booking.PaymentDeadline,
+		booking.PromoCodeID,
+		booking.DiscountAmount.Amount,
+		pq.Array(ticketTypeIDs),
+		pq.Array(quantities),
+		pq.Array(unitPrices),
 	).Scan(&booking.ID, &booking.CreatedAt, &booking.UpdatedAt)
 
 	return err
 }
 
-func (r *BookingRepository) GetByID(id uuid.UUID) (*models.Booking, error) {
+func (r *BookingRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Booking, error) {
 	query := `
-		SELECT id, user_id, event_id, quantity, status, total_amount, payment_deadline, created_at, updated_at
+		SELECT ` + bookingColumns + `
 		FROM bookings
 		WHERE id = $1
 	`
 
-	booking := &models.Booking{}
-	err := r.db.QueryRow(query, id).Scan(
-		&booking.ID,
-		&booking.UserID,
-		&booking.EventID,
-		&booking.Quantity,
-		&booking.Status,
-		&booking.TotalAmount,
-		&booking.PaymentDeadline,
-		&booking.CreatedAt,
-		&booking.UpdatedAt,
-	)
-
+	booking, err := scanBooking(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("booking not found")
@@ -68,169 +231,288 @@ func (r *BookingRepository) GetByID(id uuid.UUID) (*models.Booking, error) {
 	return booking, nil
 }
 
-func (r *BookingRepository) GetByUserID(userID uuid.UUID) ([]*models.Booking, error) {
+// GetByIDForUpdate reads a booking and locks its row until the surrounding
+// transaction ends
+func (r *BookingRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Booking, error) {
+	if _, ok := r.db.(*sql.Tx); !ok {
+		return nil, fmt.Errorf("locking a booking must run inside a transaction")
+	}
+
 	query := `
-		SELECT id, user_id, event_id, quantity, status, total_amount, payment_deadline, created_at, updated_at
+		SELECT ` + bookingColumns + `
 		FROM bookings
-		WHERE user_id = $1
-		ORDER BY created_at DESC
+		WHERE id = $1
+		FOR UPDATE
 	`
 
-	rows, err := r.db.Query(query, userID)
+	booking, err := scanBooking(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("booking not found")
+		}
 		return nil, err
 	}
-	defer rows.Close()
 
-	var bookings []*models.Booking
-	for rows.Next() {
-		booking := &models.Booking{}
-		err := rows.Scan(
-			&booking.ID,
-			&booking.UserID,
-			&booking.EventID,
-			&booking.Quantity,
-			&booking.Status,
-			&booking.TotalAmount,
-			&booking.PaymentDeadline,
-			&booking.CreatedAt,
-			&booking.UpdatedAt,
-		)
+	return booking, nil
+}
+
+// GetByUserID returns one page of a user's bookings matching filter, newest
+// first
+func (r *BookingRepository) GetByUserID(ctx context.Context, userID uuid.UUID, filter *models.BookingFilter) (*models.Page[*models.Booking], error) {
+	var args queryArgs
+	conditions := []string{"user_id = " + args.add(userID)}
+
+	if filter.Cursor != "" {
+		condition, err := keysetCondition(&args, "created_at", "id", filter.Cursor, true)
 		if err != nil {
 			return nil, err
 		}
-		bookings = append(bookings, booking)
+		conditions = append(conditions, condition)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+args.add(filter.Status))
+	}
+
+	limit := filter.PageLimit()
+	query := `
+		SELECT ` + bookingColumns + `
+		FROM bookings
+		` + whereClause(conditions) + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + args.add(limit+1)
+
+	bookings, err := r.queryBookings(ctx, query, args...)
+	if err != nil {
+		return nil, err
 	}
 
-	return bookings, nil
+	return newPage(bookings, limit, func(booking *models.Booking) models.Cursor {
+		return models.Cursor{SortKey: booking.CreatedAt, ID: booking.ID}
+	}), nil
 }
 
-func (r *BookingRepository) UpdateStatus(id uuid.UUID, status models.BookingStatus) error {
-	query := `
-		UPDATE bookings
-		SET status = $2, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
-	`
+// UpdateStatus changes a booking's status. Confirming it issues its tickets,
+// and moving it to a status that no longer holds tickets releases its seats
+// and revokes its tickets, in the same statement. A change of status also
+// records a booking event.
+func (r *BookingRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.BookingStatus) error {
+	updated, err := r.changeStatus(ctx, id, status, bookingEventTypes[status], "TRUE")
+	if err != nil {
+		return err
+	}
 
-	result, err := r.db.Exec(query, id, status)
+	if !updated {
+		return fmt.Errorf("booking not found")
+	}
+
+	return nil
+}
+
+// UpdatePendingStatus moves a booking to status like UpdateStatus, but only if
+// it is still pending, so that a booking paid or cancelled in the meantime is
+// left as it is. It returns ErrBookingNotPending otherwise.
+func (r *BookingRepository) UpdatePendingStatus(ctx context.Context, id uuid.UUID, status models.BookingStatus) error {
+	updated, err := r.changeStatus(ctx, id, status, bookingEventTypes[status], "b.status = 'PENDING'")
 	if err != nil {
 		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if !updated {
+		return ErrBookingNotPending
+	}
+
+	return nil
+}
+
+// Expire cancels a pending booking whose payment deadline has passed, like
+// UpdateStatus, and records a booking.expired event. It fails if the booking
+// has been paid or cancelled in the meantime.
+func (r *BookingRepository) Expire(ctx context.Context, id uuid.UUID) error {
+	condition := "b.status = 'PENDING' AND b.payment_deadline < CURRENT_TIMESTAMP"
+	updated, err := r.changeStatus(ctx, id, models.BookingStatusCancelled, models.BookingEventExpired, condition)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("booking not found")
+	if !updated {
+		return fmt.Errorf("booking not found or no longer expired")
 	}
 
 	return nil
 }
 
-func (r *BookingRepository) GetPendingBookings() ([]*models.Booking, error) {
+// changeStatus moves a booking to status if condition holds for it, and
+// reports whether it did. The booking row is locked first, so that the event
+// records the status it really changed from.
+func (r *BookingRepository) changeStatus(ctx context.Context, id uuid.UUID, status models.BookingStatus, eventType, condition string) (bool, error) {
 	query := `
-		SELECT id, user_id, event_id, quantity, status, total_amount, payment_deadline, created_at, updated_at
-		FROM bookings
-		WHERE status = 'PENDING' AND payment_deadline IS NOT NULL
-		ORDER BY payment_deadline ASC
+		WITH previous AS (
+			SELECT id, status FROM bookings WHERE id = $1 FOR UPDATE
+		), updated AS (
+			UPDATE bookings b
+			SET status = $2, updated_at = CURRENT_TIMESTAMP
+			FROM previous
+			WHERE b.id = previous.id AND ` + condition + `
+			RETURNING ` + bookingEventColumns + `, previous.status AS previous_status
+		), released AS (
+			UPDATE booking_seats
+			SET released = TRUE
+			WHERE $3 AND booking_id IN (SELECT id FROM updated)
+		), revoked AS (
+			UPDATE tickets
+			SET status = 'REVOKED', revoked_at = CURRENT_TIMESTAMP
+			WHERE $3 AND status = 'VALID' AND booking_id IN (SELECT id FROM updated)
+		), confirmed AS (
+			SELECT id, event_id FROM updated WHERE $2 = 'CONFIRMED'
+		), ` + issueTickets + `, ` + recordBookingEvent("updated", "$4") + `
+		SELECT COUNT(*) FROM updated
 	`
 
-	rows, err := r.db.Query(query)
+	// Released bookings give back their seats and lose their tickets
+	releaseSeats := status != models.BookingStatusPending && status != models.BookingStatusConfirmed
+
+	var updated int
+	err := r.db.QueryRowContext(ctx, query, id, status, releaseSeats, eventType).Scan(&updated)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	defer rows.Close()
 
-	var bookings []*models.Booking
-	for rows.Next() {
-		booking := &models.Booking{}
-		err := rows.Scan(
-			&booking.ID,
-			&booking.UserID,
-			&booking.EventID,
-			&booking.Quantity,
-			&booking.Status,
-			&booking.TotalAmount,
-			&booking.PaymentDeadline,
-			&booking.CreatedAt,
-			&booking.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
+	return updated > 0, nil
+}
+
+// ClaimSeats assigns seats of the event's venue to a booking. The database
+// rejects a seat that is already claimed by an active booking for the event,
+// in which case ErrSeatTaken is returned.
+func (r *BookingRepository) ClaimSeats(ctx context.Context, bookingID, eventID, venueID uuid.UUID, seatIDs []uuid.UUID) error {
+	query := `
+		INSERT INTO booking_seats (booking_id, event_id, seat_id)
+		SELECT $1, $2, s.id
+		FROM seats s
+		JOIN venue_sections sec ON sec.id = s.section_id
+		WHERE sec.venue_id = $3 AND s.id = ANY($4::uuid[])
+	`
+
+	ids := make([]string, len(seatIDs))
+	for i, seatID := range seatIDs {
+		ids[i] = seatID.String()
+	}
+
+	result, err := r.db.ExecContext(ctx, query, bookingID, eventID, venueID, pq.Array(ids))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return ErrSeatTaken
 		}
-		bookings = append(bookings, booking)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if int(rowsAffected) != len(seatIDs) {
+		return fmt.Errorf("seat not found at this venue")
 	}
 
-	return bookings, nil
+	return nil
 }
 
-func (r *BookingRepository) GetExpiredBookings() ([]*models.Booking, error) {
+// ConfirmPayment marks a pending booking as confirmed, issuing its tickets and
+// recording a booking.confirmed event, and records the payment gateway
+// reference. Confirming again with the same reference is a no-op. It returns
+// ErrBookingNotPending if the booking is no longer pending, e.g. because it
+// expired while the payment was in flight.
+func (r *BookingRepository) ConfirmPayment(ctx context.Context, id uuid.UUID, paymentReference string) error {
 	query := `
-		SELECT id, user_id, event_id, quantity, status, total_amount, payment_deadline, created_at, updated_at
-		FROM bookings
-		WHERE status = 'PENDING' AND payment_deadline < $1
-		ORDER BY payment_deadline ASC
+		WITH previous AS (
+			SELECT id, status FROM bookings WHERE id = $1 FOR UPDATE
+		), updated AS (
+			UPDATE bookings b
+			SET status = $2, payment_reference = $3, updated_at = CURRENT_TIMESTAMP
+			FROM previous
+			WHERE b.id = previous.id AND (b.status = $4 OR (b.status = $2 AND b.payment_reference = $3))
+			RETURNING ` + bookingEventColumns + `, previous.status AS previous_status
+		), confirmed AS (
+			SELECT id, event_id FROM updated
+		), ` + issueTickets + `, ` + recordBookingEvent("updated", "'"+models.BookingEventConfirmed+"'") + `
+		SELECT COUNT(*) FROM updated
 	`
 
-	rows, err := r.db.Query(query, time.Now())
+	var updated int
+	err := r.db.QueryRowContext(ctx, query, id, models.BookingStatusConfirmed, paymentReference, models.BookingStatusPending).Scan(&updated)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	var bookings []*models.Booking
-	for rows.Next() {
-		booking := &models.Booking{}
-		err := rows.Scan(
-			&booking.ID,
-			&booking.UserID,
-			&booking.EventID,
-			&booking.Quantity,
-			&booking.Status,
-			&booking.TotalAmount,
-			&booking.PaymentDeadline,
-			&booking.CreatedAt,
-			&booking.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		bookings = append(bookings, booking)
+	if updated == 0 {
+		return ErrBookingNotPending
 	}
 
-	return bookings, nil
+	return nil
+}
+
+func (r *BookingRepository) GetPendingBookings(ctx context.Context) ([]*models.Booking, error) {
+	query := `
+		SELECT ` + bookingColumns + `
+		FROM bookings
+		WHERE status = 'PENDING' AND payment_deadline IS NOT NULL
+		ORDER BY payment_deadline ASC
+	`
+
+	return r.queryBookings(ctx, query)
 }
 
-func (r *BookingRepository) CreateWithTransaction(booking *models.Booking) error {
-	tx, err := r.db.Begin()
+// CountUserBookings returns what the user has booked, to check against the
+// booking limits. The user row is locked first, so concurrent bookings by the
+// same user are counted one after the other.
+func (r *BookingRepository) CountUserBookings(ctx context.Context, userID, eventID uuid.UUID) (*models.UserBookingCounts, error) {
+	if _, ok := r.db.(*sql.Tx); !ok {
+		return nil, fmt.Errorf("counting user bookings must run inside a transaction")
+	}
+
+	var lockedID uuid.UUID
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE`, userID).Scan(&lockedID)
 	if err != nil {
-		return err
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, err
 	}
-	defer tx.Rollback()
 
-	// Insert booking
+	// Pending bookings past their deadline are about to expire, so neither they
+	// nor their tickets count
 	query := `
-		INSERT INTO bookings (user_id, event_id, quantity, status, total_amount, payment_deadline)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'PENDING'),
+			MIN(payment_deadline) FILTER (WHERE status = 'PENDING'),
+			COALESCE(SUM(quantity) FILTER (WHERE event_id = $2), 0)
+		FROM bookings
+		WHERE user_id = $1
+			AND (status = 'CONFIRMED' OR (status = 'PENDING' AND payment_deadline > $3))
 	`
 
-	err = tx.QueryRow(
-		query,
-		booking.UserID,
-		booking.EventID,
-		booking.Quantity,
-		booking.Status,
-		booking.TotalAmount,
-		booking.PaymentDeadline,
-	).Scan(&booking.ID, &booking.CreatedAt, &booking.UpdatedAt)
-
+	counts := &models.UserBookingCounts{}
+	var nextDeadline sql.NullTime
+	err = r.db.QueryRowContext(ctx, query, userID, eventID, time.Now()).
+		Scan(&counts.PendingBookings, &nextDeadline, &counts.EventTickets)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if nextDeadline.Valid {
+		counts.NextPaymentDeadline = &nextDeadline.Time
 	}
 
-	// Commit transaction
-	return tx.Commit()
+	return counts, nil
+}
+
+func (r *BookingRepository) GetExpiredBookings(ctx context.Context) ([]*models.Booking, error) {
+	query := `
+		SELECT ` + bookingColumns + `
+		FROM bookings
+		WHERE status = 'PENDING' AND payment_deadline < $1
+		ORDER BY payment_deadline ASC
+	`
+
+	return r.queryBookings(ctx, query, time.Now())
 }
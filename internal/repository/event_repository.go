@@ -1,60 +1,118 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 
 	"ticket-booking-system/internal/models"
 
 	"github.com/google/uuid"
 )
 
+const eventColumns = `id, name, description, date_time, total_tickets, currency, ticket_price,
+	venue_id, organizer_id, full_refund_hours, no_refund_hours, partial_refund_percent, created_at, updated_at`
+
+// ErrInsufficientTickets is returned by ReserveTickets when a ticket type does
+// not have enough tickets left
+var ErrInsufficientTickets = errors.New("insufficient tickets available")
+
+// EventRepository counts the tickets in live holds as unavailable. holds may
+// be nil, in which case only bookings are counted.
 type EventRepository struct {
-	db *sql.DB
+	db    DBTX
+	holds HoldRepositoryInterface
+}
+
+func NewEventRepository(db DBTX, holds HoldRepositoryInterface) *EventRepository {
+	return &EventRepository{db: db, holds: holds}
 }
 
-func NewEventRepository(db *sql.DB) *EventRepository {
-	return &EventRepository{db: db}
+func scanEvent(row rowScanner) (*models.Event, error) {
+	event := &models.Event{}
+	err := row.Scan(
+		&event.ID,
+		&event.Name,
+		&event.Description,
+		&event.DateTime,
+		&event.TotalTickets,
+		&event.Currency,
+		&event.TicketPrice.Amount,
+		&event.VenueID,
+		&event.OrganizerID,
+		&event.CancellationPolicy.FullRefundHours,
+		&event.CancellationPolicy.NoRefundHours,
+		&event.CancellationPolicy.PartialRefundPercent,
+		&event.CreatedAt,
+		&event.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	event.TicketPrice.Currency = event.Currency
+
+	return event, nil
 }
 
-func (r *EventRepository) Create(event *models.Event) error {
+func (r *EventRepository) Create(ctx context.Context, event *models.Event) error {
 	query := `
-		INSERT INTO events (name, description, date_time, total_tickets, ticket_price)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO events (name, description, date_time, total_tickets, currency, ticket_price, venue_id,
+			organizer_id, full_refund_hours, no_refund_hours, partial_refund_percent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(
+		ctx,
 		query,
 		event.Name,
 		event.Description,
 		event.DateTime,
 		event.TotalTickets,
-		event.TicketPrice,
+		event.Currency,
+		event.TicketPrice.Amount,
+		event.VenueID,
+		event.OrganizerID,
+		event.CancellationPolicy.FullRefundHours,
+		event.CancellationPolicy.NoRefundHours,
+		event.CancellationPolicy.PartialRefundPercent,
 	).Scan(&event.ID, &event.CreatedAt, &event.UpdatedAt)
 
 	return err
 }
 
-func (r *EventRepository) GetByID(id uuid.UUID) (*models.Event, error) {
+// GetByID returns an event with its ticket types
+func (r *EventRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Event, error) {
 	query := `
-		SELECT id, name, description, date_time, total_tickets, ticket_price, created_at, updated_at
+		SELECT ` + eventColumns + `
 		FROM events
 		WHERE id = $1
 	`
 
-	event := &models.Event{}
-	err := r.db.QueryRow(query, id).Scan(
-		&event.ID,
-		&event.Name,
-		&event.Description,
-		&event.DateTime,
-		&event.TotalTickets,
-		&event.TicketPrice,
-		&event.CreatedAt,
-		&event.UpdatedAt,
-	)
+	return r.getEvent(ctx, query, id)
+}
+
+// GetByIDForUpdate returns an event with its ticket types and locks the event
+// row until the surrounding transaction ends
+func (r *EventRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Event, error) {
+	if _, ok := r.db.(*sql.Tx); !ok {
+		return nil, fmt.Errorf("locking an event must run inside a transaction")
+	}
+
+	query := `
+		SELECT ` + eventColumns + `
+		FROM events
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	return r.getEvent(ctx, query, id)
+}
 
+func (r *EventRepository) getEvent(ctx context.Context, query string, id uuid.UUID) (*models.Event, error) {
+	event, err := scanEvent(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("event not found")
@@ -62,17 +120,159 @@ func (r *EventRepository) GetByID(id uuid.UUID) (*models.Event, error) {
 		return nil, err
 	}
 
+	event.TicketTypes, err = r.getTicketTypes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	return event, nil
 }
 
-func (r *EventRepository) GetAll() ([]*models.Event, error) {
+// getTicketTypes returns the event's ticket types with the tickets in live
+// holds taken off their availability
+func (r *EventRepository) getTicketTypes(ctx context.Context, eventID uuid.UUID) ([]*models.TicketType, error) {
+	ticketTypes, err := NewTicketTypeRepository(r.db).GetByEventID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := r.heldTickets(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ticketType := range ticketTypes {
+		ticketType.Available -= held[ticketType.ID]
+	}
+
+	return ticketTypes, nil
+}
+
+// heldTickets returns the number of tickets in live holds per ticket type
+func (r *EventRepository) heldTickets(ctx context.Context, eventID uuid.UUID) (map[uuid.UUID]int, error) {
+	held := make(map[uuid.UUID]int)
+	if r.holds == nil {
+		return held, nil
+	}
+
+	holds, err := r.holds.GetByEventID(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holds: %w", err)
+	}
+
+	for _, hold := range holds {
+		for _, item := range hold.Items {
+			held[item.TicketTypeID] += item.Quantity
+		}
+	}
+
+	return held, nil
+}
+
+// GetAll returns one page of the events matching filter, ordered by date and
+// then ID so that pages stay stable when several events share a date.
+func (r *EventRepository) GetAll(ctx context.Context, filter *models.EventFilter) (*models.Page[*models.Event], error) {
+	var args queryArgs
+	var conditions []string
+
+	if filter.Cursor != "" {
+		condition, err := keysetCondition(&args, "e.date_time", "e.id", filter.Cursor, false)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "e.date_time >= "+args.add(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "e.date_time <= "+args.add(*filter.To))
+	}
+	if filter.Currency != "" {
+		conditions = append(conditions, "e.currency = "+args.add(strings.ToUpper(filter.Currency)))
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "e.ticket_price >= "+args.add(*filter.MinPrice))
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "e.ticket_price <= "+args.add(*filter.MaxPrice))
+	}
+	if filter.Search != "" {
+		conditions = append(conditions, "e.name ILIKE "+args.add("%"+escapeLike(filter.Search)+"%"))
+	}
+	if filter.HasAvailability {
+		// Some ticket type has tickets that are not in pending or confirmed
+		// bookings; live holds are counted below
+		conditions = append(conditions, `EXISTS (
+			SELECT 1
+			FROM ticket_types tt
+			WHERE tt.event_id = e.id AND tt.allocation > COALESCE((
+				SELECT SUM(bi.quantity)
+				FROM booking_items bi
+				JOIN bookings b ON b.id = bi.booking_id
+				WHERE bi.ticket_type_id = tt.id AND b.status IN ('PENDING', 'CONFIRMED')
+			), 0)
+		)`)
+	}
+
+	limit := filter.PageLimit()
+	cursorOf := func(event *models.Event) models.Cursor {
+		return models.Cursor{SortKey: event.DateTime, ID: event.ID}
+	}
+
+	if !filter.HasAvailability || r.holds == nil {
+		events, err := r.listEvents(ctx, conditions, args, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		return newPage(events, limit, cursorOf), nil
+	}
+
+	// Live holds are in Redis, so the query cannot count them. Events whose
+	// remaining tickets are all held are dropped here, reading on until the
+	// page is full or there are no more events.
+	var events []*models.Event
+	for {
+		batch, err := r.listEvents(ctx, conditions, args, limit+1)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range batch {
+			available, err := r.GetAvailableTickets(ctx, event.ID)
+			if err != nil {
+				return nil, err
+			}
+			if available > 0 {
+				events = append(events, event)
+			}
+			if len(events) > limit {
+				return newPage(events, limit, cursorOf), nil
+			}
+		}
+
+		if len(batch) <= limit {
+			return newPage(events, limit, cursorOf), nil
+		}
+
+		last := batch[len(batch)-1]
+		args = append(queryArgs{}, args...)
+		conditions = append(conditions[:len(conditions):len(conditions)],
+			fmt.Sprintf("(e.date_time, e.id) > (%s, %s)", args.add(last.DateTime), args.add(last.ID)))
+	}
+}
+
+// listEvents returns up to limit events matching conditions, ordered by date
+// and then ID
+func (r *EventRepository) listEvents(ctx context.Context, conditions []string, args queryArgs, limit int) ([]*models.Event, error) {
 	query := `
-		SELECT id, name, description, date_time, total_tickets, ticket_price, created_at, updated_at
-		FROM events
-		ORDER BY date_time ASC
-	`
+		SELECT ` + eventColumns + `
+		FROM events e
+		` + whereClause(conditions) + `
+		ORDER BY e.date_time ASC, e.id ASC
+		LIMIT ` + args.add(limit)
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -80,50 +280,45 @@ func (r *EventRepository) GetAll() ([]*models.Event, error) {
 
 	var events []*models.Event
 	for rows.Next() {
-		event := &models.Event{}
-		err := rows.Scan(
-			&event.ID,
-			&event.Name,
-			&event.Description,
-			&event.DateTime,
-			&event.TotalTickets,
-			&event.TicketPrice,
-			&event.CreatedAt,
-			&event.UpdatedAt,
-		)
+		event, err := scanEvent(rows)
 		if err != nil {
 			return nil, err
 		}
 		events = append(events, event)
 	}
 
-	return events, nil
+	return events, rows.Err()
 }
 
-func (r *EventRepository) Update(event *models.Event) error {
+func (r *EventRepository) Update(ctx context.Context, event *models.Event) error {
 	query := `
 		UPDATE events
-		SET name = $2, description = $3, date_time = $4, total_tickets = $5, ticket_price = $6, updated_at = CURRENT_TIMESTAMP
+		SET name = $2, description = $3, date_time = $4, total_tickets = $5, ticket_price = $6,
+			full_refund_hours = $7, no_refund_hours = $8, partial_refund_percent = $9, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1
 		RETURNING updated_at
 	`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(
+		ctx,
 		query,
 		event.ID,
 		event.Name,
 		event.Description,
 		event.DateTime,
 		event.TotalTickets,
-		event.TicketPrice,
+		event.TicketPrice.Amount,
+		event.CancellationPolicy.FullRefundHours,
+		event.CancellationPolicy.NoRefundHours,
+		event.CancellationPolicy.PartialRefundPercent,
 	).Scan(&event.UpdatedAt)
 
 	return err
 }
 
-func (r *EventRepository) Delete(id uuid.UUID) error {
+func (r *EventRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM events WHERE id = $1`
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -140,24 +335,31 @@ func (r *EventRepository) Delete(id uuid.UUID) error {
 	return nil
 }
 
-func (r *EventRepository) GetStatistics(eventID uuid.UUID) (*models.EventStatistics, error) {
+func (r *EventRepository) GetStatistics(ctx context.Context, eventID uuid.UUID) (*models.EventStatistics, error) {
 	query := `
 		SELECT 
 			e.id,
-			e.total_tickets,
+			e.currency,
 			COALESCE(SUM(CASE WHEN b.status = 'CONFIRMED' THEN b.quantity ELSE 0 END), 0) as total_sold,
-			COALESCE(SUM(CASE WHEN b.status = 'CONFIRMED' THEN b.total_amount ELSE 0 END), 0) as estimated_revenue
+			COALESCE(SUM(CASE WHEN b.status IN ('CONFIRMED', 'REFUNDED') THEN b.total_amount + b.discount_amount ELSE 0 END), 0) as gross_revenue,
+			COALESCE(SUM(CASE WHEN b.status IN ('CONFIRMED', 'REFUNDED') THEN b.discount_amount ELSE 0 END), 0) as discount_amount,
+			COALESCE(SUM(CASE WHEN r.status = 'COMPLETED' THEN r.amount ELSE 0 END), 0) as refunded_amount
 		FROM events e
 		LEFT JOIN bookings b ON e.id = b.event_id
+		LEFT JOIN refunds r ON b.id = r.booking_id
 		WHERE e.id = $1
-		GROUP BY e.id, e.total_tickets
+		GROUP BY e.id, e.currency
 	`
 
 	stats := &models.EventStatistics{}
-	err := r.db.QueryRow(query, eventID).Scan(
+	var currency string
+	err := r.db.QueryRowContext(ctx, query, eventID).Scan(
 		&stats.EventID,
+		&currency,
 		&stats.TotalSold,
-		&stats.EstimatedRevenue,
+		&stats.GrossRevenue.Amount,
+		&stats.DiscountAmount.Amount,
+		&stats.RefundedAmount.Amount,
 	)
 
 	if err != nil {
@@ -167,72 +369,229 @@ func (r *EventRepository) GetStatistics(eventID uuid.UUID) (*models.EventStatist
 		return nil, err
 	}
 
-	// Get total tickets from the event
-	eventQuery := `SELECT total_tickets FROM events WHERE id = $1`
-	var totalTickets int
-	err = r.db.QueryRow(eventQuery, eventID).Scan(&totalTickets)
+	stats.GrossRevenue.Currency = currency
+	stats.DiscountAmount.Currency = currency
+	stats.RefundedAmount.Currency = currency
+
+	// Refunded bookings keep whatever the cancellation policy did not return
+	stats.NetRevenue = stats.GrossRevenue.Sub(stats.DiscountAmount).Sub(stats.RefundedAmount)
+	stats.EstimatedRevenue = stats.NetRevenue
+
+	stats.TicketTypes, err = r.getTicketTypeStatistics(ctx, eventID, currency)
 	if err != nil {
 		return nil, err
 	}
 
-	stats.AvailableTickets = totalTickets - stats.TotalSold
+	held, err := r.heldTickets(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tickets on sale are those of each ticket type that are neither booked
+	// nor held
+	for _, ticketType := range stats.TicketTypes {
+		ticketType.OnHold = held[ticketType.TicketTypeID]
+		ticketType.Available -= ticketType.OnHold
+		stats.AvailableTickets += ticketType.Available
+		stats.TicketsOnHold += ticketType.OnHold
+		stats.CheckedIn += ticketType.CheckedIn
+	}
+
+	stats.Gates, err = r.getGateStatistics(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
 
 	return stats, nil
 }
 
-func (r *EventRepository) GetAvailableTickets(eventID uuid.UUID) (int, error) {
+// getGateStatistics counts the scans at each of the event's gates
+func (r *EventRepository) getGateStatistics(ctx context.Context, eventID uuid.UUID) ([]*models.GateStatistics, error) {
 	query := `
-		SELECT 
-			e.total_tickets - COALESCE(SUM(CASE WHEN b.status IN ('PENDING', 'CONFIRMED') THEN b.quantity ELSE 0 END), 0) as available_tickets
-		FROM events e
-		LEFT JOIN bookings b ON e.id = b.event_id
-		WHERE e.id = $1
-		GROUP BY e.total_tickets
+		SELECT
+			gate,
+			COUNT(*) FILTER (WHERE result = 'ADMITTED') as admitted,
+			COUNT(*) FILTER (WHERE result <> 'ADMITTED') as rejected
+		FROM ticket_scans
+		WHERE event_id = $1
+		GROUP BY gate
+		ORDER BY gate
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	gates := []*models.GateStatistics{}
+	for rows.Next() {
+		stats := &models.GateStatistics{}
+		if err := rows.Scan(&stats.Gate, &stats.Admitted, &stats.Rejected); err != nil {
+			return nil, err
+		}
+		gates = append(gates, stats)
+	}
+
+	return gates, rows.Err()
+}
+
+func (r *EventRepository) getTicketTypeStatistics(ctx context.Context, eventID uuid.UUID, currency string) ([]*models.TicketTypeStatistics, error) {
+	query := `
+		SELECT
+			tt.id,
+			tt.name,
+			tt.price,
+			tt.allocation,
+			COALESCE(SUM(CASE WHEN b.status = 'CONFIRMED' THEN bi.quantity ELSE 0 END), 0) as sold,
+			COALESCE(SUM(CASE WHEN b.status = 'PENDING' THEN bi.quantity ELSE 0 END), 0) as held,
+			COALESCE(SUM(CASE WHEN b.status = 'CONFIRMED' THEN bi.quantity * bi.unit_price ELSE 0 END), 0) as revenue,
+			(SELECT COUNT(*) FROM tickets t WHERE t.ticket_type_id = tt.id AND t.checked_in_at IS NOT NULL) as checked_in
+		FROM ticket_types tt
+		LEFT JOIN booking_items bi ON bi.ticket_type_id = tt.id
+		LEFT JOIN bookings b ON b.id = bi.booking_id
+		WHERE tt.event_id = $1
+		GROUP BY tt.id, tt.name, tt.price, tt.allocation
+		ORDER BY tt.price, tt.name
 	`
 
-	var availableTickets int
-	err := r.db.QueryRow(query, eventID).Scan(&availableTickets)
+	rows, err := r.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ticketTypes := []*models.TicketTypeStatistics{}
+	for rows.Next() {
+		stats := &models.TicketTypeStatistics{}
+		err := rows.Scan(
+			&stats.TicketTypeID,
+			&stats.Name,
+			&stats.Price.Amount,
+			&stats.Allocation,
+			&stats.Sold,
+			&stats.Held,
+			&stats.Revenue.Amount,
+			&stats.CheckedIn,
+		)
+		if err != nil {
+			return nil, err
+		}
+		stats.Price.Currency = currency
+		stats.Revenue.Currency = currency
+		stats.Available = stats.Allocation - stats.Sold - stats.Held
+		ticketTypes = append(ticketTypes, stats)
+	}
+
+	return ticketTypes, rows.Err()
+}
+
+// GetAvailableTickets returns the tickets of the event's ticket types that are
+// neither booked nor held
+func (r *EventRepository) GetAvailableTickets(ctx context.Context, eventID uuid.UUID) (int, error) {
+	ticketTypes, err := r.getTicketTypes(ctx, eventID)
 	if err != nil {
 		return 0, err
 	}
 
-	return availableTickets, nil
+	available := 0
+	for _, ticketType := range ticketTypes {
+		available += ticketType.Available
+	}
+
+	return available, nil
 }
 
-func (r *EventRepository) ReserveTickets(eventID uuid.UUID, quantity int) error {
-	// Use a transaction with row locking to prevent race conditions
-	tx, err := r.db.Begin()
+// GetSellThrough returns the tickets sold and reserved of every event that has
+// not taken place yet. Like the event statistics, it counts its ticket types'
+// allocations and booked items, so tickets left unallocated are not on sale.
+func (r *EventRepository) GetSellThrough(ctx context.Context) ([]*models.EventSellThrough, error) {
+	query := `
+		SELECT
+			e.id,
+			COALESCE(tt.allocation, 0) as total_tickets,
+			COALESCE(bi.sold, 0) as sold,
+			COALESCE(bi.reserved, 0) as reserved
+		FROM events e
+		LEFT JOIN (
+			SELECT event_id, SUM(allocation) as allocation
+			FROM ticket_types
+			GROUP BY event_id
+		) tt ON tt.event_id = e.id
+		LEFT JOIN (
+			SELECT
+				b.event_id,
+				SUM(CASE WHEN b.status = 'CONFIRMED' THEN bi.quantity ELSE 0 END) as sold,
+				SUM(CASE WHEN b.status = 'PENDING' THEN bi.quantity ELSE 0 END) as reserved
+			FROM bookings b
+			JOIN booking_items bi ON bi.booking_id = b.id
+			WHERE b.status IN ('CONFIRMED', 'PENDING')
+			GROUP BY b.event_id
+		) bi ON bi.event_id = e.id
+		WHERE e.date_time > CURRENT_TIMESTAMP
+		ORDER BY e.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.EventSellThrough
+	for rows.Next() {
+		event := &models.EventSellThrough{}
+		if err := rows.Scan(&event.EventID, &event.TotalTickets, &event.Sold, &event.Reserved); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// ReserveTickets locks the event row and checks that each item's ticket type
+// still has enough tickets available, counting live holds as taken. It must
+// run inside a unit of work so the lock is held until the booking row or hold
+// is saved and the transaction commits.
+func (r *EventRepository) ReserveTickets(ctx context.Context, eventID uuid.UUID, items []*models.BookingItem) error {
+	if _, ok := r.db.(*sql.Tx); !ok {
+		return fmt.Errorf("reserve tickets must run inside a transaction")
 	}
-	defer tx.Rollback()
 
 	// Lock the event row for update
-	query := `SELECT total_tickets FROM events WHERE id = $1 FOR UPDATE`
-	var totalTickets int
-	err = tx.QueryRow(query, eventID).Scan(&totalTickets)
+	query := `SELECT id FROM events WHERE id = $1 FOR UPDATE`
+	var lockedID uuid.UUID
+	err := r.db.QueryRowContext(ctx, query, eventID).Scan(&lockedID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("event not found")
+		}
 		return err
 	}
 
-	// Check available tickets
-	availableQuery := `
-		SELECT 
-			$1 - COALESCE(SUM(CASE WHEN status IN ('PENDING', 'CONFIRMED') THEN quantity ELSE 0 END), 0) as available_tickets
-		FROM bookings
-		WHERE event_id = $2
-	`
-
-	var availableTickets int
-	err = tx.QueryRow(availableQuery, totalTickets, eventID).Scan(&availableTickets)
+	// Check available tickets per ticket type
+	ticketTypes, err := r.getTicketTypes(ctx, eventID)
 	if err != nil {
 		return err
 	}
 
-	if availableTickets < quantity {
-		return fmt.Errorf("insufficient tickets available. Available: %d, Requested: %d", availableTickets, quantity)
+	byID := make(map[uuid.UUID]*models.TicketType, len(ticketTypes))
+	for _, ticketType := range ticketTypes {
+		byID[ticketType.ID] = ticketType
 	}
 
-	// Commit the transaction
-	return tx.Commit()
+	for _, item := range items {
+		ticketType, ok := byID[item.TicketTypeID]
+		if !ok {
+			return fmt.Errorf("ticket type not found")
+		}
+
+		if ticketType.Available < item.Quantity {
+			return fmt.Errorf("%w for %s. Available: %d, Requested: %d",
+				ErrInsufficientTickets, ticketType.Name, ticketType.Available, item.Quantity)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrHoldNotFound is returned for holds that never existed, have expired or
+// have been released or checked out
+var ErrHoldNotFound = errors.New("hold not found")
+
+// Holds are kept twice in Redis: under hold:<id>, which expires with the hold,
+// for lookups by ID, and in the event_holds:<event id> hash, which is read to
+// count an event's held tickets. Expired hash fields are skipped when read and
+// removed lazily; the hash itself expires once its last hold could have.
+
+// Saves a hold in both places. ARGV: hold ID, hold JSON, hold TTL in ms,
+// hash TTL in ms, and 1 to only update a hold that has not expired yet.
+var saveHoldScript = redis.NewScript(`
+if ARGV[5] == '1' and redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+local ttl = redis.call('PTTL', KEYS[2])
+if ttl < tonumber(ARGV[4]) then
+	redis.call('PEXPIRE', KEYS[2], ARGV[4])
+end
+return 1
+`)
+
+// Deletes a hold, returning 1 if it had not expired yet
+var deleteHoldScript = redis.NewScript(`
+redis.call('HDEL', KEYS[2], ARGV[1])
+return redis.call('DEL', KEYS[1])
+`)
+
+type HoldRepository struct {
+	rdb *redis.Client
+
+	// maxLifetime bounds how long any hold saved from now on can live,
+	// including an extension
+	maxLifetime time.Duration
+}
+
+func NewHoldRepository(rdb *redis.Client, maxLifetime time.Duration) *HoldRepository {
+	return &HoldRepository{rdb: rdb, maxLifetime: maxLifetime}
+}
+
+func holdKey(id uuid.UUID) string {
+	return "hold:" + id.String()
+}
+
+func eventHoldsKey(eventID uuid.UUID) string {
+	return "event_holds:" + eventID.String()
+}
+
+// Create saves a new hold until its ExpiresAt
+func (r *HoldRepository) Create(ctx context.Context, hold *models.SeatHold) error {
+	_, err := r.save(ctx, hold, false)
+	return err
+}
+
+// Update saves a changed hold. It fails if the hold has expired or has been
+// deleted in the meantime.
+func (r *HoldRepository) Update(ctx context.Context, hold *models.SeatHold) error {
+	saved, err := r.save(ctx, hold, true)
+	if err != nil {
+		return err
+	}
+
+	if !saved {
+		return ErrHoldNotFound
+	}
+
+	return nil
+}
+
+func (r *HoldRepository) save(ctx context.Context, hold *models.SeatHold, existing bool) (bool, error) {
+	ttl := time.Until(hold.ExpiresAt)
+	if ttl <= 0 {
+		return false, ErrHoldNotFound
+	}
+
+	data, err := json.Marshal(hold)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal hold: %w", err)
+	}
+
+	onlyExisting := "0"
+	if existing {
+		onlyExisting = "1"
+	}
+
+	saved, err := saveHoldScript.Run(ctx, r.rdb,
+		[]string{holdKey(hold.ID), eventHoldsKey(hold.EventID)},
+		hold.ID.String(), data, ttl.Milliseconds(), r.maxLifetime.Milliseconds(), onlyExisting,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to save hold: %w", err)
+	}
+
+	return saved == 1, nil
+}
+
+func (r *HoldRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.SeatHold, error) {
+	data, err := r.rdb.Get(ctx, holdKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hold := &models.SeatHold{}
+	if err := json.Unmarshal(data, hold); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal hold: %w", err)
+	}
+
+	return hold, nil
+}
+
+// GetByEventID returns the event's live holds
+func (r *HoldRepository) GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*models.SeatHold, error) {
+	key := eventHoldsKey(eventID)
+	entries, err := r.rdb.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var holds []*models.SeatHold
+	var expired []string
+	for id, data := range entries {
+		hold := &models.SeatHold{}
+		if err := json.Unmarshal([]byte(data), hold); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal hold: %w", err)
+		}
+
+		if !hold.ExpiresAt.After(now) {
+			expired = append(expired, id)
+			continue
+		}
+		holds = append(holds, hold)
+	}
+
+	// An expired hold cannot be saved again, so removing it here is safe
+	if len(expired) > 0 {
+		if err := r.rdb.HDel(ctx, key, expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return holds, nil
+}
+
+// Delete removes a hold. It returns false if the hold had already expired or
+// been deleted, so that only one caller can ever check a hold out.
+func (r *HoldRepository) Delete(ctx context.Context, hold *models.SeatHold) (bool, error) {
+	deleted, err := deleteHoldScript.Run(ctx, r.rdb,
+		[]string{holdKey(hold.ID), eventHoldsKey(hold.EventID)},
+		hold.ID.String(),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete hold: %w", err)
+	}
+
+	return deleted == 1, nil
+}
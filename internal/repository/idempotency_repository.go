@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"ticket-booking-system/internal/models"
+)
+
+type IdempotencyRepository struct {
+	db DBTX
+}
+
+func NewIdempotencyRepository(db DBTX) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Acquire claims the key for a new request. An existing record is taken over
+// only if it was never completed and is older than staleBefore, or if it is
+// older than expiredBefore. It returns false if the key is held by another
+// record, which can then be read with Get.
+func (r *IdempotencyRepository) Acquire(ctx context.Context, scope, key, requestHash string, staleBefore, expiredBefore time.Time) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (scope, key, request_hash)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (scope, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			response_body = NULL,
+			created_at = CURRENT_TIMESTAMP,
+			completed_at = NULL
+		WHERE (idempotency_keys.completed_at IS NULL AND idempotency_keys.created_at < $4)
+			OR idempotency_keys.created_at < $5
+		RETURNING scope
+	`
+
+	var acquired string
+	err := r.db.QueryRowContext(ctx, query, scope, key, requestHash, staleBefore, expiredBefore).Scan(&acquired)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	query := `
+		SELECT scope, key, request_hash, status_code, response_body, created_at, completed_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND key = $2
+	`
+
+	record := &models.IdempotencyRecord{}
+	err := r.db.QueryRowContext(ctx, query, scope, key).Scan(
+		&record.Scope,
+		&record.Key,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.CompletedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("idempotency key not found")
+		}
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, scope, key string, statusCode int, responseBody []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, response_body = $4, completed_at = CURRENT_TIMESTAMP
+		WHERE scope = $1 AND key = $2
+	`
+
+	_, err := r.db.ExecContext(ctx, query, scope, key, statusCode, responseBody)
+	return err
+}
+
+// Release deletes a key whose request was not completed, so that a retry with
+// it is processed again
+func (r *IdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE scope = $1 AND key = $2 AND completed_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, scope, key)
+	return err
+}
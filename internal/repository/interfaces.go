@@ -1,37 +1,151 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"ticket-booking-system/internal/models"
 
 	"github.com/google/uuid"
 )
 
 type EventRepositoryInterface interface {
-	Create(event *models.Event) error
-	GetByID(id uuid.UUID) (*models.Event, error)
-	GetAll() ([]*models.Event, error)
-	Update(event *models.Event) error
-	Delete(id uuid.UUID) error
-	GetStatistics(eventID uuid.UUID) (*models.EventStatistics, error)
-	GetAvailableTickets(eventID uuid.UUID) (int, error)
-	ReserveTickets(eventID uuid.UUID, quantity int) error
+	Create(ctx context.Context, event *models.Event) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Event, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Event, error)
+	GetAll(ctx context.Context, filter *models.EventFilter) (*models.Page[*models.Event], error)
+	Update(ctx context.Context, event *models.Event) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetStatistics(ctx context.Context, eventID uuid.UUID) (*models.EventStatistics, error)
+	GetAvailableTickets(ctx context.Context, eventID uuid.UUID) (int, error)
+	GetSellThrough(ctx context.Context) ([]*models.EventSellThrough, error)
+	ReserveTickets(ctx context.Context, eventID uuid.UUID, items []*models.BookingItem) error
+}
+
+type TicketTypeRepositoryInterface interface {
+	Create(ctx context.Context, ticketType *models.TicketType) error
+	GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*models.TicketType, error)
+	Update(ctx context.Context, ticketType *models.TicketType) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type UserRepositoryInterface interface {
-	Create(user *models.User) error
-	GetByID(id uuid.UUID) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetAll() ([]*models.User, error)
-	Update(user *models.User) error
-	Delete(id uuid.UUID) error
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetAll(ctx context.Context, page *models.PageRequest) (*models.Page[*models.User], error)
+	Update(ctx context.Context, user *models.User) error
+	UpdateRole(ctx context.Context, id uuid.UUID, role models.Role) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type BookingRepositoryInterface interface {
-	Create(booking *models.Booking) error
-	GetByID(id uuid.UUID) (*models.Booking, error)
-	GetByUserID(userID uuid.UUID) ([]*models.Booking, error)
-	UpdateStatus(id uuid.UUID, status models.BookingStatus) error
-	GetPendingBookings() ([]*models.Booking, error)
-	GetExpiredBookings() ([]*models.Booking, error)
-	CreateWithTransaction(booking *models.Booking) error
+	Create(ctx context.Context, booking *models.Booking) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Booking, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Booking, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, filter *models.BookingFilter) (*models.Page[*models.Booking], error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status models.BookingStatus) error
+	UpdatePendingStatus(ctx context.Context, id uuid.UUID, status models.BookingStatus) error
+	Expire(ctx context.Context, id uuid.UUID) error
+	ConfirmPayment(ctx context.Context, id uuid.UUID, paymentReference string) error
+	ClaimSeats(ctx context.Context, bookingID, eventID, venueID uuid.UUID, seatIDs []uuid.UUID) error
+	GetPendingBookings(ctx context.Context) ([]*models.Booking, error)
+	GetExpiredBookings(ctx context.Context) ([]*models.Booking, error)
+	CountUserBookings(ctx context.Context, userID, eventID uuid.UUID) (*models.UserBookingCounts, error)
+}
+
+type VenueRepositoryInterface interface {
+	Create(ctx context.Context, venue *models.Venue) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Venue, error)
+	GetAll(ctx context.Context, page *models.PageRequest) (*models.Page[*models.Venue], error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	CountSeats(ctx context.Context, id uuid.UUID) (int, error)
+	GetSeatMap(ctx context.Context, venueID, eventID uuid.UUID) ([]*models.Section, error)
+	GetSeatZones(ctx context.Context, venueID uuid.UUID, seatIDs []uuid.UUID) (map[uuid.UUID]string, error)
+}
+
+type PromoCodeRepositoryInterface interface {
+	Create(ctx context.Context, promoCode *models.PromoCode) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.PromoCode, error)
+	GetByCodeForUpdate(ctx context.Context, code string) (*models.PromoCode, error)
+	CountRedemptions(ctx context.Context, id, userID uuid.UUID) (int, int, error)
+	GetAll(ctx context.Context, page *models.PageRequest) (*models.Page[*models.PromoCode], error)
+	Update(ctx context.Context, promoCode *models.PromoCode) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type RefundRepositoryInterface interface {
+	Create(ctx context.Context, refund *models.Refund) error
+	GetByBookingID(ctx context.Context, bookingID uuid.UUID) (*models.Refund, error)
+	UpdateStatus(ctx context.Context, refund *models.Refund) error
+}
+
+type WaitlistRepositoryInterface interface {
+	Create(ctx context.Context, entry *models.WaitlistEntry) error
+	GetByUser(ctx context.Context, eventID, userID uuid.UUID) (*models.WaitlistEntry, error)
+	GetByEventID(ctx context.Context, eventID uuid.UUID, filter *models.WaitlistFilter) (*models.Page[*models.WaitlistEntry], error)
+	GetWaitingForUpdate(ctx context.Context, eventID uuid.UUID) ([]*models.WaitlistEntry, error)
+	GetEventIDsWithWaiting(ctx context.Context) ([]uuid.UUID, error)
+	Offer(ctx context.Context, id, bookingID uuid.UUID) error
+	Leave(ctx context.Context, id uuid.UUID) error
+}
+
+type TicketRepositoryInterface interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Ticket, error)
+	GetByBookingID(ctx context.Context, bookingID uuid.UUID) ([]*models.Ticket, error)
+	CheckIn(ctx context.Context, id, eventID, deviceID uuid.UUID, at time.Time) (bool, error)
+	GetValidIDs(ctx context.Context, eventID uuid.UUID) (pending, checkedIn []uuid.UUID, err error)
+}
+
+type ScannerRepositoryInterface interface {
+	Create(ctx context.Context, device *models.ScannerDevice, tokenHash string) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.ScannerDevice, error)
+	GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*models.ScannerDevice, error)
+	Revoke(ctx context.Context, id, eventID uuid.UUID) error
+}
+
+type ScanRepositoryInterface interface {
+	Create(ctx context.Context, scan *models.TicketScan) error
+	GetByClientScanIDs(ctx context.Context, deviceID uuid.UUID, clientScanIDs []uuid.UUID) ([]*models.TicketScan, error)
+	GetConflicts(ctx context.Context, eventID uuid.UUID, page *models.PageRequest) (*models.Page[*models.CheckInConflict], error)
+	GetByEventID(ctx context.Context, eventID uuid.UUID, filter *models.ScanFilter) (*models.Page[*models.TicketScan], error)
+}
+
+type HoldRepositoryInterface interface {
+	Create(ctx context.Context, hold *models.SeatHold) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.SeatHold, error)
+	GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*models.SeatHold, error)
+	Update(ctx context.Context, hold *models.SeatHold) error
+	Delete(ctx context.Context, hold *models.SeatHold) (bool, error)
+}
+
+type IdempotencyRepositoryInterface interface {
+	Acquire(ctx context.Context, scope, key, requestHash string, staleBefore, expiredBefore time.Time) (bool, error)
+	Get(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, scope, key string, statusCode int, responseBody []byte) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+type UnitOfWorkInterface interface {
+	WithinTransaction(ctx context.Context, fn func(repos *Repositories) error) error
+}
+
+type OutboxRepositoryInterface interface {
+	TryLockRelay(ctx context.Context) (bool, error)
+	GetUnpublished(ctx context.Context, limit int) ([]*models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []int64) error
+}
+
+type WebhookRepositoryInterface interface {
+	Create(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.WebhookSubscription, error)
+	GetMatching(ctx context.Context, eventType string, eventID uuid.UUID) ([]*models.WebhookSubscription, error)
+	Update(ctx context.Context, subscription *models.WebhookSubscription) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, subscriptionID uuid.UUID, filter *models.WebhookDeliveryFilter) (*models.Page[*models.WebhookDelivery], error)
+	ReplayDelivery(ctx context.Context, subscriptionID, id uuid.UUID) (*models.WebhookDelivery, error)
 }
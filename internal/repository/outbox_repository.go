@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// outboxRelayLock is the Postgres advisory lock key held by the relay that is
+// publishing the outbox
+const outboxRelayLock = 7_311_020_019
+
+// OutboxRepository reads the outbox for the relay. Events are written by the
+// statements that make the changes they describe, such as those of
+// BookingRepository.
+type OutboxRepository struct {
+	db DBTX
+}
+
+func NewOutboxRepository(db DBTX) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// TryLockRelay takes the relay lock until the end of the transaction, so that
+// of several instances only one publishes at a time and events stay in order.
+// It reports false if another relay holds the lock.
+func (r *OutboxRepository) TryLockRelay(ctx context.Context) (bool, error) {
+	var locked bool
+	err := r.db.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxRelayLock).Scan(&locked)
+	return locked, err
+}
+
+// GetUnpublished returns up to limit unpublished events in the order they were
+// recorded
+func (r *OutboxRepository) GetUnpublished(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	query := `
+		SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at
+		FROM outbox_events
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.OutboxEvent
+	for rows.Next() {
+		event := &models.OutboxEvent{}
+		err := rows.Scan(&event.ID, &event.AggregateType, &event.AggregateID, &event.Type, &event.Payload, &event.CreatedAt, &event.PublishedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// MarkPublished records that events have been published
+func (r *OutboxRepository) MarkPublished(ctx context.Context, ids []int64) error {
+	query := `
+		UPDATE outbox_events
+		SET published_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($1)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, pq.Array(ids))
+	return err
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"ticket-booking-system/internal/models"
+)
+
+// queryArgs collects the positional arguments of a query built at runtime
+type queryArgs []interface{}
+
+// add appends value and returns its placeholder
+func (a *queryArgs) add(value interface{}) string {
+	*a = append(*a, value)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+// whereClause joins conditions with AND, or returns "" if there are none
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// keysetCondition selects the rows after cursor when ordering by sortColumn
+// and then idColumn, both ascending or both descending.
+func keysetCondition(args *queryArgs, sortColumn, idColumn, cursor string, descending bool) (string, error) {
+	after, err := models.DecodeCursor(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	op := ">"
+	if descending {
+		op = "<"
+	}
+
+	return fmt.Sprintf("(%s, %s) %s (%s, %s)", sortColumn, idColumn, op, args.add(after.SortKey), args.add(after.ID)), nil
+}
+
+// newPage trims a result fetched with limit+1 rows to limit rows and sets the
+// next cursor if the extra row shows there is another page.
+func newPage[T any](items []T, limit int, cursorOf func(T) models.Cursor) *models.Page[T] {
+	page := &models.Page[T]{Data: items}
+	if page.Data == nil {
+		page.Data = []T{}
+	}
+
+	if len(items) > limit {
+		page.Data = items[:limit]
+		next := cursorOf(items[limit-1]).Encode()
+		page.NextCursor = &next
+	}
+
+	return page
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	cursor := models.Cursor{SortKey: time.Date(2024, 12, 31, 20, 0, 0, 123456000, time.UTC), ID: uuid.New()}
+
+	decoded, err := models.DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, cursor.SortKey.Equal(decoded.SortKey))
+	assert.Equal(t, cursor.ID, decoded.ID)
+
+	_, err = models.DecodeCursor("not-a-cursor")
+	assert.ErrorIs(t, err, models.ErrInvalidCursor)
+}
+
+func TestKeysetCondition(t *testing.T) {
+	cursor := models.Cursor{SortKey: time.Now(), ID: uuid.New()}
+
+	var args queryArgs
+	args.add("first")
+
+	ascending, err := keysetCondition(&args, "date_time", "id", cursor.Encode(), false)
+	require.NoError(t, err)
+	assert.Equal(t, "(date_time, id) > ($2, $3)", ascending)
+
+	descending, err := keysetCondition(&args, "created_at", "id", cursor.Encode(), true)
+	require.NoError(t, err)
+	assert.Equal(t, "(created_at, id) < ($4, $5)", descending)
+	assert.Len(t, args, 5)
+}
+
+func TestNewPage(t *testing.T) {
+	events := make([]*models.Event, 3)
+	for i := range events {
+		events[i] = &models.Event{ID: uuid.New(), DateTime: time.Now().Add(time.Duration(i) * time.Hour)}
+	}
+	cursorOf := func(event *models.Event) models.Cursor {
+		return models.Cursor{SortKey: event.DateTime, ID: event.ID}
+	}
+
+	// An extra row means there is another page, starting after the last row kept
+	page := newPage(events, 2, cursorOf)
+	assert.Len(t, page.Data, 2)
+	require.NotNil(t, page.NextCursor)
+	next, err := models.DecodeCursor(*page.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, events[1].ID, next.ID)
+
+	// Last page
+	page = newPage(events, 3, cursorOf)
+	assert.Len(t, page.Data, 3)
+	assert.Nil(t, page.NextCursor)
+
+	// Empty pages serialise as [] rather than null
+	empty := newPage[*models.Event](nil, 2, cursorOf)
+	assert.NotNil(t, empty.Data)
+	assert.Nil(t, empty.NextCursor)
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `100\% off\_sale \\o/`, escapeLike(`100% off_sale \o/`))
+}
@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrPromoCodeTaken is returned when creating a promo code that already exists
+	ErrPromoCodeTaken = errors.New("promo code already exists")
+
+	// ErrPromoCodeReferenced is returned when deleting a promo code that
+	// bookings still reference
+	ErrPromoCodeReferenced = errors.New("promo code is referenced by bookings")
+)
+
+// A booking counts as a redemption while it holds tickets
+const promoCodeColumns = `p.id, p.code, COALESCE(p.description, ''), p.discount_type, p.percent_off, p.amount_off, p.currency,
+	p.max_redemptions, p.max_per_user,
+	(SELECT COUNT(*) FROM bookings b WHERE b.promo_code_id = p.id AND b.status IN ('PENDING', 'CONFIRMED')) AS redemptions,
+	p.min_quantity, p.valid_from, p.valid_until, p.event_id, p.ticket_type_id, p.active, p.created_at, p.updated_at`
+
+type PromoCodeRepository struct {
+	db DBTX
+}
+
+func NewPromoCodeRepository(db DBTX) *PromoCodeRepository {
+	return &PromoCodeRepository{db: db}
+}
+
+func scanPromoCode(row rowScanner) (*models.PromoCode, error) {
+	promoCode := &models.PromoCode{}
+	var amountOff sql.NullInt64
+	var currency sql.NullString
+	err := row.Scan(
+		&promoCode.ID,
+		&promoCode.Code,
+		&promoCode.Description,
+		&promoCode.DiscountType,
+		&promoCode.PercentOff,
+		&amountOff,
+		&currency,
+		&promoCode.MaxRedemptions,
+		&promoCode.MaxPerUser,
+		&promoCode.Redemptions,
+		&promoCode.MinQuantity,
+		&promoCode.ValidFrom,
+		&promoCode.ValidUntil,
+		&promoCode.EventID,
+		&promoCode.TicketTypeID,
+		&promoCode.Active,
+		&promoCode.CreatedAt,
+		&promoCode.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if amountOff.Valid {
+		amount := models.NewMoney(amountOff.Int64, currency.String)
+		promoCode.AmountOff = &amount
+	}
+
+	return promoCode, nil
+}
+
+// amountOffColumns returns the amount_off and currency column values of a
+// promo code
+func amountOffColumns(promoCode *models.PromoCode) (sql.NullInt64, sql.NullString) {
+	if promoCode.AmountOff == nil {
+		return sql.NullInt64{}, sql.NullString{}
+	}
+	return sql.NullInt64{Int64: promoCode.AmountOff.Amount, Valid: true},
+		sql.NullString{String: promoCode.AmountOff.Currency, Valid: true}
+}
+
+func (r *PromoCodeRepository) Create(ctx context.Context, promoCode *models.PromoCode) error {
+	query := `
+		INSERT INTO promo_codes (code, description, discount_type, percent_off, amount_off, currency,
+			max_redemptions, max_per_user, min_quantity, valid_from, valid_until, event_id, ticket_type_id, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at, updated_at
+	`
+
+	amountOff, currency := amountOffColumns(promoCode)
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		promoCode.Code,
+		promoCode.Description,
+		promoCode.DiscountType,
+		promoCode.PercentOff,
+		amountOff,
+		currency,
+		promoCode.MaxRedemptions,
+		promoCode.MaxPerUser,
+		promoCode.MinQuantity,
+		promoCode.ValidFrom,
+		promoCode.ValidUntil,
+		promoCode.EventID,
+		promoCode.TicketTypeID,
+		promoCode.Active,
+	).Scan(&promoCode.ID, &promoCode.CreatedAt, &promoCode.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return ErrPromoCodeTaken
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *PromoCodeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PromoCode, error) {
+	query := `
+		SELECT ` + promoCodeColumns + `
+		FROM promo_codes p
+		WHERE p.id = $1
+	`
+
+	promoCode, err := scanPromoCode(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("promo code not found")
+		}
+		return nil, err
+	}
+
+	return promoCode, nil
+}
+
+// GetByCodeForUpdate reads a promo code and locks its row until the
+// surrounding transaction ends, so that redemptions of the code are counted
+// one booking at a time
+func (r *PromoCodeRepository) GetByCodeForUpdate(ctx context.Context, code string) (*models.PromoCode, error) {
+	if _, ok := r.db.(*sql.Tx); !ok {
+		return nil, fmt.Errorf("locking a promo code must run inside a transaction")
+	}
+
+	query := `
+		SELECT ` + promoCodeColumns + `
+		FROM promo_codes p
+		WHERE p.code = $1
+		FOR UPDATE
+	`
+
+	promoCode, err := scanPromoCode(r.db.QueryRowContext(ctx, query, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("promo code not found")
+		}
+		return nil, err
+	}
+
+	return promoCode, nil
+}
+
+// CountRedemptions returns how many pending and confirmed bookings use the
+// promo code, overall and for one user. Call it after GetByCodeForUpdate so
+// that the counts include bookings committed while waiting for the lock.
+func (r *PromoCodeRepository) CountRedemptions(ctx context.Context, id, userID uuid.UUID) (int, int, error) {
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE user_id = $2)
+		FROM bookings
+		WHERE promo_code_id = $1 AND status IN ('PENDING', 'CONFIRMED')
+	`
+
+	var total, byUser int
+	err := r.db.QueryRowContext(ctx, query, id, userID).Scan(&total, &byUser)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return total, byUser, nil
+}
+
+// GetAll returns one page of promo codes, newest first
+func (r *PromoCodeRepository) GetAll(ctx context.Context, page *models.PageRequest) (*models.Page[*models.PromoCode], error) {
+	var args queryArgs
+	var conditions []string
+
+	if page.Cursor != "" {
+		condition, err := keysetCondition(&args, "p.created_at", "p.id", page.Cursor, true)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	limit := page.PageLimit()
+	query := `
+		SELECT ` + promoCodeColumns + `
+		FROM promo_codes p
+		` + whereClause(conditions) + `
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT ` + args.add(limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promoCodes []*models.PromoCode
+	for rows.Next() {
+		promoCode, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		promoCodes = append(promoCodes, promoCode)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newPage(promoCodes, limit, func(promoCode *models.PromoCode) models.Cursor {
+		return models.Cursor{SortKey: promoCode.CreatedAt, ID: promoCode.ID}
+	}), nil
+}
+
+func (r *PromoCodeRepository) Update(ctx context.Context, promoCode *models.PromoCode) error {
+	query := `
+		UPDATE promo_codes
+		SET description = $2, percent_off = $3, amount_off = $4, max_redemptions = $5, max_per_user = $6,
+			min_quantity = $7, valid_from = $8, valid_until = $9, active = $10, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	amountOff, _ := amountOffColumns(promoCode)
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		promoCode.ID,
+		promoCode.Description,
+		promoCode.PercentOff,
+		amountOff,
+		promoCode.MaxRedemptions,
+		promoCode.MaxPerUser,
+		promoCode.MinQuantity,
+		promoCode.ValidFrom,
+		promoCode.ValidUntil,
+		promoCode.Active,
+	).Scan(&promoCode.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("promo code not found")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *PromoCodeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM promo_codes WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return ErrPromoCodeReferenced
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("promo code not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const refundColumns = `id, booking_id, amount, currency, status, refund_reference, created_at, updated_at`
+
+type RefundRepository struct {
+	db DBTX
+}
+
+func NewRefundRepository(db DBTX) *RefundRepository {
+	return &RefundRepository{db: db}
+}
+
+func scanRefund(row rowScanner) (*models.Refund, error) {
+	refund := &models.Refund{}
+	err := row.Scan(
+		&refund.ID,
+		&refund.BookingID,
+		&refund.Amount.Amount,
+		&refund.Amount.Currency,
+		&refund.Status,
+		&refund.RefundReference,
+		&refund.CreatedAt,
+		&refund.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+func (r *RefundRepository) Create(ctx context.Context, refund *models.Refund) error {
+	query := `
+		INSERT INTO refunds (booking_id, amount, currency, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		refund.BookingID,
+		refund.Amount.Amount,
+		refund.Amount.Currency,
+		refund.Status,
+	).Scan(&refund.ID, &refund.CreatedAt, &refund.UpdatedAt)
+
+	return err
+}
+
+func (r *RefundRepository) GetByBookingID(ctx context.Context, bookingID uuid.UUID) (*models.Refund, error) {
+	query := `
+		SELECT ` + refundColumns + `
+		FROM refunds
+		WHERE booking_id = $1
+	`
+
+	refund, err := scanRefund(r.db.QueryRowContext(ctx, query, bookingID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("refund not found")
+		}
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// UpdateStatus records the outcome of the gateway call for a refund
+func (r *RefundRepository) UpdateStatus(ctx context.Context, refund *models.Refund) error {
+	query := `
+		UPDATE refunds
+		SET status = $2, refund_reference = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, refund.ID, refund.Status, refund.RefundReference).Scan(&refund.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("refund not found")
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const scanColumns = `s.id, s.event_id, s.device_id, s.gate, s.ticket_id, s.result,
+	s.client_scan_id, s.device_result, s.scanned_at, s.synced_at`
+
+// ScanRepository keeps the scan log of events' gates
+type ScanRepository struct {
+	db DBTX
+}
+
+func NewScanRepository(db DBTX) *ScanRepository {
+	return &ScanRepository{db: db}
+}
+
+// scanTicketScan reads the scanColumns of a row, followed by dest
+func scanTicketScan(row rowScanner, dest ...any) (*models.TicketScan, error) {
+	scan := &models.TicketScan{}
+	err := row.Scan(append([]any{
+		&scan.ID,
+		&scan.EventID,
+		&scan.DeviceID,
+		&scan.Gate,
+		&scan.TicketID,
+		&scan.Result,
+		&scan.ClientScanID,
+		&scan.DeviceResult,
+		&scan.ScannedAt,
+		&scan.SyncedAt,
+	}, dest...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scan, nil
+}
+
+// Create logs a scan. A ticket ID that does not belong to any ticket, which an
+// offline device may upload, is logged as no ticket.
+func (r *ScanRepository) Create(ctx context.Context, scan *models.TicketScan) error {
+	query := `
+		INSERT INTO ticket_scans (event_id, device_id, gate, ticket_id, result, client_scan_id, device_result, scanned_at, synced_at)
+		VALUES ($1, $2, $3, (SELECT id FROM tickets WHERE id = $4), $5, $6, $7, $8, $9)
+		RETURNING id, ticket_id
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		scan.EventID,
+		scan.DeviceID,
+		scan.Gate,
+		scan.TicketID,
+		scan.Result,
+		scan.ClientScanID,
+		scan.DeviceResult,
+		scan.ScannedAt,
+		scan.SyncedAt,
+	).Scan(&scan.ID, &scan.TicketID)
+}
+
+// GetByClientScanIDs returns the scans a device has already uploaded out of
+// the given client scan IDs
+func (r *ScanRepository) GetByClientScanIDs(ctx context.Context, deviceID uuid.UUID, clientScanIDs []uuid.UUID) ([]*models.TicketScan, error) {
+	ids := make([]string, len(clientScanIDs))
+	for i, id := range clientScanIDs {
+		ids[i] = id.String()
+	}
+
+	query := `
+		SELECT ` + scanColumns + `
+		FROM ticket_scans s
+		WHERE s.device_id = $1 AND s.client_scan_id = ANY($2::uuid[])
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, deviceID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scans []*models.TicketScan
+	for rows.Next() {
+		scan, err := scanTicketScan(rows)
+		if err != nil {
+			return nil, err
+		}
+		scans = append(scans, scan)
+	}
+
+	return scans, rows.Err()
+}
+
+// GetByEventID returns one page of an event's scans matching filter, newest
+// first
+func (r *ScanRepository) GetByEventID(ctx context.Context, eventID uuid.UUID, filter *models.ScanFilter) (*models.Page[*models.TicketScan], error) {
+	var args queryArgs
+	conditions := []string{"s.event_id = " + args.add(eventID)}
+
+	if filter.Cursor != "" {
+		condition, err := keysetCondition(&args, "s.scanned_at", "s.id", filter.Cursor, true)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	if filter.Gate != "" {
+		conditions = append(conditions, "s.gate = "+args.add(filter.Gate))
+	}
+	if filter.Result != "" {
+		conditions = append(conditions, "s.result = "+args.add(filter.Result))
+	}
+
+	limit := filter.PageLimit()
+	query := `
+		SELECT ` + scanColumns + `
+		FROM ticket_scans s
+		` + whereClause(conditions) + `
+		ORDER BY s.scanned_at DESC, s.id DESC
+		LIMIT ` + args.add(limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scans []*models.TicketScan
+	for rows.Next() {
+		scan, err := scanTicketScan(rows)
+		if err != nil {
+			return nil, err
+		}
+		scans = append(scans, scan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newPage(scans, limit, func(scan *models.TicketScan) models.Cursor {
+		return models.Cursor{SortKey: scan.ScannedAt, ID: scan.ID}
+	}), nil
+}
+
+// GetConflicts returns one page of an event's conflicting offline scans, newest
+// first, with the check-in that was accepted for each ticket
+func (r *ScanRepository) GetConflicts(ctx context.Context, eventID uuid.UUID, page *models.PageRequest) (*models.Page[*models.CheckInConflict], error) {
+	var args queryArgs
+	conditions := []string{
+		"s.event_id = " + args.add(eventID),
+		"s.device_result = 'ADMITTED'",
+		"s.result <> 'ADMITTED'",
+	}
+
+	if page.Cursor != "" {
+		condition, err := keysetCondition(&args, "s.scanned_at", "s.id", page.Cursor, true)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	limit := page.PageLimit()
+	query := `
+		SELECT ` + scanColumns + `, t.checked_in_at, t.checked_in_device_id, d.gate
+		FROM ticket_scans s
+		LEFT JOIN tickets t ON t.id = s.ticket_id
+		LEFT JOIN scanner_devices d ON d.id = t.checked_in_device_id
+		` + whereClause(conditions) + `
+		ORDER BY s.scanned_at DESC, s.id DESC
+		LIMIT ` + args.add(limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []*models.CheckInConflict
+	for rows.Next() {
+		conflict := &models.CheckInConflict{}
+		conflict.Scan, err = scanTicketScan(rows, &conflict.CheckedInAt, &conflict.CheckedInDeviceID, &conflict.CheckedInGate)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newPage(conflicts, limit, func(conflict *models.CheckInConflict) models.Cursor {
+		return models.Cursor{SortKey: conflict.Scan.ScannedAt, ID: conflict.Scan.ID}
+	}), nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const scannerColumns = `id, event_id, name, gate, created_at, revoked_at`
+
+type ScannerRepository struct {
+	db DBTX
+}
+
+func NewScannerRepository(db DBTX) *ScannerRepository {
+	return &ScannerRepository{db: db}
+}
+
+func scanScanner(row rowScanner) (*models.ScannerDevice, error) {
+	device := &models.ScannerDevice{}
+	err := row.Scan(
+		&device.ID,
+		&device.EventID,
+		&device.Name,
+		&device.Gate,
+		&device.CreatedAt,
+		&device.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// Create registers a device that authenticates with the token hashing to
+// tokenHash
+func (r *ScannerRepository) Create(ctx context.Context, device *models.ScannerDevice, tokenHash string) error {
+	query := `
+		INSERT INTO scanner_devices (event_id, name, gate, token_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query, device.EventID, device.Name, device.Gate, tokenHash).
+		Scan(&device.ID, &device.CreatedAt)
+}
+
+// GetByTokenHash returns the active device with the token hash
+func (r *ScannerRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.ScannerDevice, error) {
+	query := `
+		SELECT ` + scannerColumns + `
+		FROM scanner_devices
+		WHERE token_hash = $1 AND revoked_at IS NULL
+	`
+
+	device, err := scanScanner(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("scanner not found")
+		}
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// GetByEventID returns an event's devices, including revoked ones, by gate
+func (r *ScannerRepository) GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*models.ScannerDevice, error) {
+	query := `
+		SELECT ` + scannerColumns + `
+		FROM scanner_devices
+		WHERE event_id = $1
+		ORDER BY gate, name, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []*models.ScannerDevice{}
+	for rows.Next() {
+		device, err := scanScanner(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, rows.Err()
+}
+
+// Revoke stops an event's device from authenticating
+func (r *ScannerRepository) Revoke(ctx context.Context, id, eventID uuid.UUID) error {
+	query := `
+		UPDATE scanner_devices
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND event_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, eventID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("scanner not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const ticketColumns = `id, booking_id, event_id, number, ticket_type_id, seat_id, status,
+	checked_in_at, checked_in_device_id, created_at, revoked_at`
+
+// ErrTicketNotFound is returned for tickets that were never issued
+var ErrTicketNotFound = errors.New("ticket not found")
+
+// TicketRepository reads and checks in tickets. They are issued and revoked by
+// BookingRepository as their booking's status changes.
+type TicketRepository struct {
+	db DBTX
+}
+
+func NewTicketRepository(db DBTX) *TicketRepository {
+	return &TicketRepository{db: db}
+}
+
+func scanTicket(row rowScanner) (*models.Ticket, error) {
+	ticket := &models.Ticket{}
+	err := row.Scan(
+		&ticket.ID,
+		&ticket.BookingID,
+		&ticket.EventID,
+		&ticket.Number,
+		&ticket.TicketTypeID,
+		&ticket.SeatID,
+		&ticket.Status,
+		&ticket.CheckedInAt,
+		&ticket.CheckedInBy,
+		&ticket.CreatedAt,
+		&ticket.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ticket, nil
+}
+
+func (r *TicketRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
+	query := `
+		SELECT ` + ticketColumns + `
+		FROM tickets
+		WHERE id = $1
+	`
+
+	ticket, err := scanTicket(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTicketNotFound
+		}
+		return nil, err
+	}
+
+	return ticket, nil
+}
+
+// GetByBookingID returns a booking's tickets in number order
+func (r *TicketRepository) GetByBookingID(ctx context.Context, bookingID uuid.UUID) ([]*models.Ticket, error) {
+	query := `
+		SELECT ` + ticketColumns + `
+		FROM tickets
+		WHERE booking_id = $1
+		ORDER BY number
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := []*models.Ticket{}
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, rows.Err()
+}
+
+// CheckIn marks a valid ticket for the event as checked in by the device at
+// the given time. The check is part of the update, so of two concurrent scans
+// of a ticket only one succeeds; it returns false if the ticket is unknown, for
+// another event, revoked or already checked in.
+func (r *TicketRepository) CheckIn(ctx context.Context, id, eventID, deviceID uuid.UUID, at time.Time) (bool, error) {
+	query := `
+		UPDATE tickets
+		SET checked_in_at = $4, checked_in_device_id = $3
+		WHERE id = $1 AND event_id = $2 AND status = 'VALID' AND checked_in_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, eventID, deviceID, at)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// GetValidIDs returns the IDs of an event's valid tickets in ID order, split
+// into those waiting to be checked in and those already checked in
+func (r *TicketRepository) GetValidIDs(ctx context.Context, eventID uuid.UUID) (pending, checkedIn []uuid.UUID, err error) {
+	query := `
+		SELECT id, checked_in_at IS NOT NULL
+		FROM tickets
+		WHERE event_id = $1 AND status = 'VALID'
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var isCheckedIn bool
+		if err := rows.Scan(&id, &isCheckedIn); err != nil {
+			return nil, nil, err
+		}
+
+		if isCheckedIn {
+			checkedIn = append(checkedIn, id)
+		} else {
+			pending = append(pending, id)
+		}
+	}
+
+	return pending, checkedIn, rows.Err()
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrTicketTypeReferenced is returned when deleting a ticket type that
+// bookings still reference
+var ErrTicketTypeReferenced = errors.New("ticket type is referenced by bookings")
+
+// Availability counts tickets in pending and confirmed bookings as taken.
+// Prices are in the event's currency.
+const ticketTypeColumns = `tt.id, tt.event_id, tt.name, COALESCE(tt.description, ''),
+	tt.price, (SELECT e.currency FROM events e WHERE e.id = tt.event_id) AS currency, tt.allocation, tt.zone,
+	tt.allocation - COALESCE((
+		SELECT SUM(bi.quantity)
+		FROM booking_items bi
+		JOIN bookings b ON b.id = bi.booking_id
+		WHERE bi.ticket_type_id = tt.id AND b.status IN ('PENDING', 'CONFIRMED')
+	), 0) AS available,
+	tt.created_at, tt.updated_at`
+
+type TicketTypeRepository struct {
+	db DBTX
+}
+
+func NewTicketTypeRepository(db DBTX) *TicketTypeRepository {
+	return &TicketTypeRepository{db: db}
+}
+
+func scanTicketType(row rowScanner) (*models.TicketType, error) {
+	ticketType := &models.TicketType{}
+	err := row.Scan(
+		&ticketType.ID,
+		&ticketType.EventID,
+		&ticketType.Name,
+		&ticketType.Description,
+		&ticketType.Price.Amount,
+		&ticketType.Price.Currency,
+		&ticketType.Allocation,
+		&ticketType.Zone,
+		&ticketType.Available,
+		&ticketType.CreatedAt,
+		&ticketType.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ticketType, nil
+}
+
+func (r *TicketTypeRepository) Create(ctx context.Context, ticketType *models.TicketType) error {
+	query := `
+		INSERT INTO ticket_types (event_id, name, description, price, allocation, zone)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		ticketType.EventID,
+		ticketType.Name,
+		ticketType.Description,
+		ticketType.Price.Amount,
+		ticketType.Allocation,
+		ticketType.Zone,
+	).Scan(&ticketType.ID, &ticketType.CreatedAt, &ticketType.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	ticketType.Available = ticketType.Allocation
+
+	return r.syncEventPrice(ctx, ticketType.EventID)
+}
+
+// GetByEventID returns an event's ticket types, cheapest first
+func (r *TicketTypeRepository) GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*models.TicketType, error) {
+	query := `
+		SELECT ` + ticketTypeColumns + `
+		FROM ticket_types tt
+		WHERE tt.event_id = $1
+		ORDER BY tt.price, tt.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ticketTypes []*models.TicketType
+	for rows.Next() {
+		ticketType, err := scanTicketType(rows)
+		if err != nil {
+			return nil, err
+		}
+		ticketTypes = append(ticketTypes, ticketType)
+	}
+
+	return ticketTypes, rows.Err()
+}
+
+func (r *TicketTypeRepository) Update(ctx context.Context, ticketType *models.TicketType) error {
+	query := `
+		UPDATE ticket_types
+		SET name = $2, description = $3, price = $4, allocation = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		ticketType.ID,
+		ticketType.Name,
+		ticketType.Description,
+		ticketType.Price.Amount,
+		ticketType.Allocation,
+	).Scan(&ticketType.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("ticket type not found")
+		}
+		return err
+	}
+
+	return r.syncEventPrice(ctx, ticketType.EventID)
+}
+
+func (r *TicketTypeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM ticket_types WHERE id = $1 RETURNING event_id`
+
+	var eventID uuid.UUID
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&eventID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return ErrTicketTypeReferenced
+		}
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("ticket type not found")
+		}
+		return err
+	}
+
+	return r.syncEventPrice(ctx, eventID)
+}
+
+// syncEventPrice keeps the event's ticket_price at its cheapest ticket type so
+// that price filters on events keep working
+func (r *TicketTypeRepository) syncEventPrice(ctx context.Context, eventID uuid.UUID) error {
+	query := `
+		UPDATE events
+		SET ticket_price = COALESCE((SELECT MIN(price) FROM ticket_types WHERE event_id = $1), ticket_price)
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, eventID)
+	return err
+}
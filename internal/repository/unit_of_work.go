@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"ticket-booking-system/internal/logging"
+)
+
+// DBTX is the subset of *sql.DB and *sql.Tx used by the repositories, so the
+// same repository code can run on its own or inside a unit of work.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Repositories groups the repositories bound to a single transaction.
+type Repositories struct {
+	Events      EventRepositoryInterface
+	Users       UserRepositoryInterface
+	Bookings    BookingRepositoryInterface
+	Refunds     RefundRepositoryInterface
+	Venues      VenueRepositoryInterface
+	TicketTypes TicketTypeRepositoryInterface
+	PromoCodes  PromoCodeRepositoryInterface
+	Waitlist    WaitlistRepositoryInterface
+	Tickets     TicketRepositoryInterface
+	Scans       ScanRepositoryInterface
+	Outbox      OutboxRepositoryInterface
+
+	// Holds live in Redis, outside the transaction
+	Holds HoldRepositoryInterface
+}
+
+type UnitOfWork struct {
+	db     *sql.DB
+	holds  HoldRepositoryInterface
+	logger *slog.Logger
+}
+
+func NewUnitOfWork(db *sql.DB, holds HoldRepositoryInterface, logger *slog.Logger) *UnitOfWork {
+	return &UnitOfWork{db: db, holds: holds, logger: logger}
+}
+
+// WithinTransaction runs fn with repositories bound to one transaction. The
+// transaction is committed if fn returns nil and rolled back otherwise, or
+// when ctx is cancelled before it commits.
+func (u *UnitOfWork) WithinTransaction(ctx context.Context, fn func(repos *Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// A committed transaction, or one the driver already rolled back when
+		// ctx was cancelled, is done; anything else left it open
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			u.logger.ErrorContext(ctx, "Failed to roll back transaction", logging.Err(err))
+		}
+	}()
+
+	repos := &Repositories{
+		Events:      NewEventRepository(tx, u.holds),
+		Users:       NewUserRepository(tx),
+		Bookings:    NewBookingRepository(tx),
+		Refunds:     NewRefundRepository(tx),
+		Venues:      NewVenueRepository(tx),
+		TicketTypes: NewTicketTypeRepository(tx),
+		PromoCodes:  NewPromoCodeRepository(tx),
+		Waitlist:    NewWaitlistRepository(tx),
+		Tickets:     NewTicketRepository(tx),
+		Scans:       NewScanRepository(tx),
+		Outbox:      NewOutboxRepository(tx),
+		Holds:       u.holds,
+	}
+
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -9,46 +10,61 @@ import (
 	"github.com/google/uuid"
 )
 
+const userColumns = `id, name, email, role, password_hash, created_at, updated_at`
+
 type UserRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
+func NewUserRepository(db DBTX) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) Create(user *models.User) error {
+func scanUser(row rowScanner) (*models.User, error) {
+	user := &models.User{}
+	err := row.Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Role,
+		&user.PasswordHash,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (name, email)
-		VALUES ($1, $2)
+		INSERT INTO users (name, email, role, password_hash)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(
+		ctx,
 		query,
 		user.Name,
 		user.Email,
+		user.Role,
+		user.PasswordHash,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 
 	return err
 }
 
-func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	query := `
-		SELECT id, name, email, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = $1
 	`
 
-	user := &models.User{}
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.Name,
-		&user.Email,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("user not found")
@@ -59,22 +75,14 @@ func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	return user, nil
 }
 
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, name, email, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE email = $1
 	`
 
-	user := &models.User{}
-	err := r.db.QueryRow(query, email).Scan(
-		&user.ID,
-		&user.Name,
-		&user.Email,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("user not found")
@@ -85,14 +93,28 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	return user, nil
 }
 
-func (r *UserRepository) GetAll() ([]*models.User, error) {
+// GetAll returns one page of users, newest first
+func (r *UserRepository) GetAll(ctx context.Context, page *models.PageRequest) (*models.Page[*models.User], error) {
+	var args queryArgs
+	var conditions []string
+
+	if page.Cursor != "" {
+		condition, err := keysetCondition(&args, "created_at", "id", page.Cursor, true)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	limit := page.PageLimit()
 	query := `
-		SELECT id, name, email, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
-		ORDER BY created_at DESC
-	`
+		` + whereClause(conditions) + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + args.add(limit+1)
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -100,24 +122,22 @@ func (r *UserRepository) GetAll() ([]*models.User, error) {
 
 	var users []*models.User
 	for rows.Next() {
-		user := &models.User{}
-		err := rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Email,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
 		users = append(users, user)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return users, nil
+	return newPage(users, limit, func(user *models.User) models.Cursor {
+		return models.Cursor{SortKey: user.CreatedAt, ID: user.ID}
+	}), nil
 }
 
-func (r *UserRepository) Update(user *models.User) error {
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users
 		SET name = $2, email = $3, updated_at = CURRENT_TIMESTAMP
@@ -125,7 +145,8 @@ func (r *UserRepository) Update(user *models.User) error {
 		RETURNING updated_at
 	`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(
+		ctx,
 		query,
 		user.ID,
 		user.Name,
@@ -135,9 +156,33 @@ func (r *UserRepository) Update(user *models.User) error {
 	return err
 }
 
-func (r *UserRepository) Delete(id uuid.UUID) error {
+func (r *UserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.Role) error {
+	query := `
+		UPDATE users
+		SET role = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, role)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM users WHERE id = $1`
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
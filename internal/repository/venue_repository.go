@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrVenueReferenced is returned when deleting a venue that events still reference
+var ErrVenueReferenced = errors.New("venue is referenced by events")
+
+// foreignKeyViolation is the Postgres error code for a foreign key violation
+const foreignKeyViolation = "23503"
+
+const venueColumns = `id, name, COALESCE(address, ''), created_at, updated_at`
+
+type VenueRepository struct {
+	db DBTX
+}
+
+func NewVenueRepository(db DBTX) *VenueRepository {
+	return &VenueRepository{db: db}
+}
+
+func scanVenue(row rowScanner) (*models.Venue, error) {
+	venue := &models.Venue{}
+	err := row.Scan(
+		&venue.ID,
+		&venue.Name,
+		&venue.Address,
+		&venue.CreatedAt,
+		&venue.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return venue, nil
+}
+
+// Create inserts a venue with its sections, rows and seats and sets their IDs.
+// It must run inside a unit of work so that a venue is never stored with part
+// of its seat map.
+func (r *VenueRepository) Create(ctx context.Context, venue *models.Venue) error {
+	if _, ok := r.db.(*sql.Tx); !ok {
+		return fmt.Errorf("creating a venue must run inside a transaction")
+	}
+
+	query := `
+		INSERT INTO venues (name, address)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, venue.Name, venue.Address).Scan(&venue.ID, &venue.CreatedAt, &venue.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	sectionQuery := `
+		INSERT INTO venue_sections (venue_id, name, position)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	// One statement per row inserts all of its seats
+	seatQuery := `
+		INSERT INTO seats (section_id, row_label, row_position, seat_number, zone)
+		SELECT $1, $2, $3, seat_number, $5
+		FROM unnest($4::int[]) AS seat_number
+		RETURNING id, seat_number
+	`
+
+	for sectionPosition, section := range venue.Sections {
+		err := r.db.QueryRowContext(ctx, sectionQuery, venue.ID, section.Name, sectionPosition).Scan(&section.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create section %q: %w", section.Name, err)
+		}
+
+		for rowPosition, row := range section.Rows {
+			if err := r.createRow(ctx, seatQuery, section.ID, rowPosition, row); err != nil {
+				return fmt.Errorf("failed to create row %q in section %q: %w", row.Label, section.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// createRow inserts a row's seats. All seats of a row share a price zone.
+func (r *VenueRepository) createRow(ctx context.Context, query string, sectionID uuid.UUID, position int, row *models.SeatRow) error {
+	seatsByNumber := make(map[int]*models.Seat, len(row.Seats))
+	numbers := make([]int64, 0, len(row.Seats))
+	zone := ""
+	for _, seat := range row.Seats {
+		zone = seat.Zone
+		seat.SectionID = sectionID
+		seat.Row = row.Label
+		seatsByNumber[seat.Number] = seat
+		numbers = append(numbers, int64(seat.Number))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, sectionID, row.Label, position, pq.Array(numbers), zone)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var number int
+		if err := rows.Scan(&id, &number); err != nil {
+			return err
+		}
+		seatsByNumber[number].ID = id
+	}
+
+	return rows.Err()
+}
+
+// GetByID returns a venue with its seat map
+func (r *VenueRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Venue, error) {
+	query := `
+		SELECT ` + venueColumns + `
+		FROM venues
+		WHERE id = $1
+	`
+
+	venue, err := scanVenue(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("venue not found")
+		}
+		return nil, err
+	}
+
+	venue.Sections, err = r.getSections(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return venue, nil
+}
+
+// GetAll returns one page of venues, newest first, without their seat maps
+func (r *VenueRepository) GetAll(ctx context.Context, page *models.PageRequest) (*models.Page[*models.Venue], error) {
+	var args queryArgs
+	var conditions []string
+
+	if page.Cursor != "" {
+		condition, err := keysetCondition(&args, "created_at", "id", page.Cursor, true)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	limit := page.PageLimit()
+	query := `
+		SELECT ` + venueColumns + `
+		FROM venues
+		` + whereClause(conditions) + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + args.add(limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var venues []*models.Venue
+	for rows.Next() {
+		venue, err := scanVenue(rows)
+		if err != nil {
+			return nil, err
+		}
+		venues = append(venues, venue)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newPage(venues, limit, func(venue *models.Venue) models.Cursor {
+		return models.Cursor{SortKey: venue.CreatedAt, ID: venue.ID}
+	}), nil
+}
+
+func (r *VenueRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM venues WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return ErrVenueReferenced
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("venue not found")
+	}
+
+	return nil
+}
+
+func (r *VenueRepository) CountSeats(ctx context.Context, id uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(s.id)
+		FROM venue_sections sec
+		JOIN seats s ON s.section_id = sec.id
+		WHERE sec.venue_id = $1
+	`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetSeatZones returns the price zone of each of the seats that belongs to the
+// venue
+func (r *VenueRepository) GetSeatZones(ctx context.Context, venueID uuid.UUID, seatIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	query := `
+		SELECT s.id, s.zone
+		FROM seats s
+		JOIN venue_sections sec ON sec.id = s.section_id
+		WHERE sec.venue_id = $1 AND s.id = ANY($2::uuid[])
+	`
+
+	ids := make([]string, len(seatIDs))
+	for i, seatID := range seatIDs {
+		ids[i] = seatID.String()
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, venueID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zones := make(map[uuid.UUID]string, len(seatIDs))
+	for rows.Next() {
+		var id uuid.UUID
+		var zone string
+		if err := rows.Scan(&id, &zone); err != nil {
+			return nil, err
+		}
+		zones[id] = zone
+	}
+
+	return zones, rows.Err()
+}
+
+// GetSeatMap returns the venue's seat map with each seat marked available,
+// held by a pending booking or sold for the event
+func (r *VenueRepository) GetSeatMap(ctx context.Context, venueID, eventID uuid.UUID) ([]*models.Section, error) {
+	return r.getSections(ctx, venueID, &eventID)
+}
+
+// getSections loads a venue's sections, rows and seats in display order.
+// Seat statuses are only filled in when eventID is given.
+func (r *VenueRepository) getSections(ctx context.Context, venueID uuid.UUID, eventID *uuid.UUID) ([]*models.Section, error) {
+	query := `
+		SELECT sec.id, sec.name, s.id, s.row_label, s.seat_number, s.zone,
+			CASE
+				WHEN b.status = 'CONFIRMED' THEN 'sold'
+				WHEN b.status = 'PENDING' THEN 'held'
+				ELSE 'available'
+			END as seat_status
+		FROM venue_sections sec
+		JOIN seats s ON s.section_id = sec.id
+		LEFT JOIN booking_seats bs ON bs.seat_id = s.id AND bs.event_id = $2 AND NOT bs.released
+		LEFT JOIN bookings b ON b.id = bs.booking_id
+		WHERE sec.venue_id = $1
+		ORDER BY sec.position, s.row_position, s.seat_number
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, venueID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sections []*models.Section
+	var section *models.Section
+	var row *models.SeatRow
+	for rows.Next() {
+		var sectionID uuid.UUID
+		var sectionName string
+		seat := &models.Seat{}
+		err := rows.Scan(&sectionID, &sectionName, &seat.ID, &seat.Row, &seat.Number, &seat.Zone, &seat.Status)
+		if err != nil {
+			return nil, err
+		}
+
+		if eventID == nil {
+			seat.Status = ""
+		}
+		seat.SectionID = sectionID
+
+		// Rows arrive ordered, so a new section or row label starts a new group
+		if section == nil || section.ID != sectionID {
+			section = &models.Section{ID: sectionID, Name: sectionName}
+			sections = append(sections, section)
+			row = nil
+		}
+		if row == nil || row.Label != seat.Row {
+			row = &models.SeatRow{Label: seat.Row}
+			section.Rows = append(section.Rows, row)
+		}
+		row.Seats = append(row.Seats, seat)
+	}
+
+	return sections, rows.Err()
+}
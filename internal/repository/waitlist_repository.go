@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrAlreadyWaiting is returned when a user joins an event's waitlist while
+// already waiting for it
+var ErrAlreadyWaiting = errors.New("user is already on the waitlist")
+
+// waitlistStatus reports an offer as accepted or expired once its booking has
+// been paid for or has lapsed
+const waitlistStatus = `CASE
+		WHEN w.status <> 'OFFERED' OR b.status = 'PENDING' THEN w.status
+		WHEN b.status IN ('CONFIRMED', 'REFUNDED') THEN 'ACCEPTED'
+		ELSE 'EXPIRED'
+	END`
+
+// The position counts the waiting entries for the same ticket type up to and
+// including this one
+const waitlistColumns = `w.id, w.event_id, w.user_id, w.ticket_type_id, w.quantity, ` + waitlistStatus + ` AS status,
+	CASE WHEN w.status = 'WAITING' THEN (
+		SELECT COUNT(*) FROM waitlist_entries q
+		WHERE q.event_id = w.event_id AND q.ticket_type_id = w.ticket_type_id AND q.status = 'WAITING'
+			AND (q.created_at, q.id) <= (w.created_at, w.id)
+	) ELSE 0 END AS position,
+	w.booking_id, CASE WHEN b.status = 'PENDING' THEN b.payment_deadline END AS offer_expires_at,
+	w.created_at, w.updated_at`
+
+const waitlistFrom = `FROM waitlist_entries w LEFT JOIN bookings b ON b.id = w.booking_id`
+
+type WaitlistRepository struct {
+	db DBTX
+}
+
+func NewWaitlistRepository(db DBTX) *WaitlistRepository {
+	return &WaitlistRepository{db: db}
+}
+
+func scanWaitlistEntry(row rowScanner) (*models.WaitlistEntry, error) {
+	entry := &models.WaitlistEntry{}
+	err := row.Scan(
+		&entry.ID,
+		&entry.EventID,
+		&entry.UserID,
+		&entry.TicketTypeID,
+		&entry.Quantity,
+		&entry.Status,
+		&entry.Position,
+		&entry.BookingID,
+		&entry.OfferExpiresAt,
+		&entry.CreatedAt,
+		&entry.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (r *WaitlistRepository) queryEntries(ctx context.Context, query string, args ...interface{}) ([]*models.WaitlistEntry, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.WaitlistEntry
+	for rows.Next() {
+		entry, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *WaitlistRepository) Create(ctx context.Context, entry *models.WaitlistEntry) error {
+	query := `
+		INSERT INTO waitlist_entries (event_id, user_id, ticket_type_id, quantity, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		entry.EventID,
+		entry.UserID,
+		entry.TicketTypeID,
+		entry.Quantity,
+		entry.Status,
+	).Scan(&entry.ID, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return ErrAlreadyWaiting
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByUser returns the user's latest waitlist entry for an event
+func (r *WaitlistRepository) GetByUser(ctx context.Context, eventID, userID uuid.UUID) (*models.WaitlistEntry, error) {
+	query := `
+		SELECT ` + waitlistColumns + `
+		` + waitlistFrom + `
+		WHERE w.event_id = $1 AND w.user_id = $2
+		ORDER BY w.created_at DESC, w.id DESC
+		LIMIT 1
+	`
+
+	entry, err := scanWaitlistEntry(r.db.QueryRowContext(ctx, query, eventID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("waitlist entry not found")
+		}
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// GetByEventID returns one page of an event's waitlist matching filter, in
+// the order the entries joined
+func (r *WaitlistRepository) GetByEventID(ctx context.Context, eventID uuid.UUID, filter *models.WaitlistFilter) (*models.Page[*models.WaitlistEntry], error) {
+	var args queryArgs
+	conditions := []string{"w.event_id = " + args.add(eventID)}
+
+	if filter.Cursor != "" {
+		condition, err := keysetCondition(&args, "w.created_at", "w.id", filter.Cursor, false)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, waitlistStatus+" = "+args.add(filter.Status))
+	}
+
+	limit := filter.PageLimit()
+	query := `
+		SELECT ` + waitlistColumns + `
+		` + waitlistFrom + `
+		` + whereClause(conditions) + `
+		ORDER BY w.created_at ASC, w.id ASC
+		LIMIT ` + args.add(limit+1)
+
+	entries, err := r.queryEntries(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPage(entries, limit, func(entry *models.WaitlistEntry) models.Cursor {
+		return models.Cursor{SortKey: entry.CreatedAt, ID: entry.ID}
+	}), nil
+}
+
+// GetWaitingForUpdate returns an event's waiting entries in the order they
+// joined and locks them until the surrounding transaction ends
+func (r *WaitlistRepository) GetWaitingForUpdate(ctx context.Context, eventID uuid.UUID) ([]*models.WaitlistEntry, error) {
+	if _, ok := r.db.(*sql.Tx); !ok {
+		return nil, fmt.Errorf("locking waitlist entries must run inside a transaction")
+	}
+
+	query := `
+		SELECT ` + waitlistColumns + `
+		` + waitlistFrom + `
+		WHERE w.event_id = $1 AND w.status = 'WAITING'
+		ORDER BY w.created_at ASC, w.id ASC
+		FOR UPDATE OF w
+	`
+
+	return r.queryEntries(ctx, query, eventID)
+}
+
+// GetEventIDsWithWaiting returns the upcoming events that have a waitlist
+func (r *WaitlistRepository) GetEventIDsWithWaiting(ctx context.Context) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT w.event_id
+		FROM waitlist_entries w
+		JOIN events e ON e.id = w.event_id
+		WHERE w.status = 'WAITING' AND e.date_time > $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var eventIDs []uuid.UUID
+	for rows.Next() {
+		var eventID uuid.UUID
+		if err := rows.Scan(&eventID); err != nil {
+			return nil, err
+		}
+		eventIDs = append(eventIDs, eventID)
+	}
+
+	return eventIDs, rows.Err()
+}
+
+// Offer records the pending booking offered to a waiting entry
+func (r *WaitlistRepository) Offer(ctx context.Context, id, bookingID uuid.UUID) error {
+	query := `
+		UPDATE waitlist_entries
+		SET status = 'OFFERED', booking_id = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'WAITING'
+	`
+
+	return r.expectOne(r.db.ExecContext(ctx, query, id, bookingID))
+}
+
+// Leave takes a waiting or offered entry off the waitlist
+func (r *WaitlistRepository) Leave(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE waitlist_entries
+		SET status = 'LEFT', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status IN ('WAITING', 'OFFERED')
+	`
+
+	return r.expectOne(r.db.ExecContext(ctx, query, id))
+}
+
+func (r *WaitlistRepository) expectOne(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("waitlist entry not found")
+	}
+
+	return nil
+}
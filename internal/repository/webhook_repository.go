@@ -0,0 +1,346 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const webhookColumns = `id, user_id, url, secret, event_types, event_id, active, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, subscription_id, outbox_event_id, event_type, payload, status, attempts,
+	next_attempt_at, last_attempt_at, response_status, last_error, created_at, updated_at`
+
+var (
+	// ErrWebhookNotFound is returned for unknown webhook subscriptions
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	// ErrWebhookDeliveryNotFound is returned for deliveries that do not exist
+	// or belong to another subscription
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookRepository stores webhook subscriptions and their deliveries
+type WebhookRepository struct {
+	db DBTX
+}
+
+func NewWebhookRepository(db DBTX) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func scanWebhook(row rowScanner) (*models.WebhookSubscription, error) {
+	subscription := &models.WebhookSubscription{}
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.UserID,
+		&subscription.URL,
+		&subscription.Secret,
+		pq.Array(&subscription.EventTypes),
+		&subscription.EventID,
+		&subscription.Active,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.OutboxEventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastAttemptAt,
+		&delivery.ResponseStatus,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (r *WebhookRepository) queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []*models.WebhookSubscription{}
+	for rows.Next() {
+		subscription, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (r *WebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, subscription *models.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (user_id, url, secret, event_types, event_id, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		subscription.UserID,
+		subscription.URL,
+		subscription.Secret,
+		pq.Array(subscription.EventTypes),
+		subscription.EventID,
+		subscription.Active,
+	).Scan(&subscription.ID, &subscription.CreatedAt, &subscription.UpdatedAt)
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`
+
+	subscription, err := scanWebhook(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// GetByUserID returns a user's subscriptions, oldest first
+func (r *WebhookRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhook_subscriptions
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`
+
+	return r.queryWebhooks(ctx, query, userID)
+}
+
+// GetMatching returns the active subscriptions to eventType for bookings of
+// the event eventID. Only subscriptions of admins and of the event's organizer
+// match, whatever event they were made for.
+func (r *WebhookRepository) GetMatching(ctx context.Context, eventType string, eventID uuid.UUID) ([]*models.WebhookSubscription, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhook_subscriptions w
+		WHERE active AND $1 = ANY(event_types) AND (event_id IS NULL OR event_id = $2)
+			AND (
+				EXISTS (SELECT 1 FROM users u WHERE u.id = w.user_id AND u.role = 'admin')
+				OR EXISTS (SELECT 1 FROM events e WHERE e.id = $2 AND e.organizer_id = w.user_id)
+			)
+		ORDER BY id
+	`
+
+	return r.queryWebhooks(ctx, query, eventType, eventID)
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, subscription *models.WebhookSubscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $2, secret = $3, event_types = $4, active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		subscription.ID,
+		subscription.URL,
+		subscription.Secret,
+		pq.Array(subscription.EventTypes),
+		subscription.Active,
+	).Scan(&subscription.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrWebhookNotFound
+	}
+
+	return err
+}
+
+// Delete removes a subscription together with its deliveries
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery queues a delivery. A delivery of the same outbox event to the
+// same subscription is only queued once; it reports whether this one was.
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, outbox_event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (subscription_id, outbox_event_id) DO NOTHING
+		RETURNING ` + webhookDeliveryColumns
+
+	row := r.db.QueryRowContext(ctx, query, delivery.SubscriptionID, delivery.OutboxEventID, delivery.EventType, delivery.Payload)
+	created, err := scanWebhookDelivery(row)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	*delivery = *created
+	return true, nil
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries whose next attempt
+// is due and postpones that attempt to leaseUntil, so that other dispatchers
+// leave them alone while they are being sent
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'PENDING' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	return r.queryDeliveries(ctx, query, limit, leaseUntil)
+}
+
+// UpdateDelivery records the outcome of an attempt
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = COALESCE($4, next_attempt_at), last_attempt_at = $5,
+			response_status = $6, last_error = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		delivery.ID,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastAttemptAt,
+		delivery.ResponseStatus,
+		delivery.LastError,
+	).Scan(&delivery.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrWebhookDeliveryNotFound
+	}
+
+	return err
+}
+
+// GetDeliveries returns one page of a subscription's deliveries matching
+// filter, newest first
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, subscriptionID uuid.UUID, filter *models.WebhookDeliveryFilter) (*models.Page[*models.WebhookDelivery], error) {
+	var args queryArgs
+	conditions := []string{"subscription_id = " + args.add(subscriptionID)}
+
+	if filter.Cursor != "" {
+		condition, err := keysetCondition(&args, "created_at", "id", filter.Cursor, true)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+args.add(filter.Status))
+	}
+
+	limit := filter.PageLimit()
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		` + whereClause(conditions) + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + args.add(limit+1)
+
+	deliveries, err := r.queryDeliveries(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPage(deliveries, limit, func(delivery *models.WebhookDelivery) models.Cursor {
+		return models.Cursor{SortKey: delivery.CreatedAt, ID: delivery.ID}
+	}), nil
+}
+
+// ReplayDelivery queues a subscription's delivery to be sent again now, with
+// a fresh set of attempts, whatever its status
+func (r *WebhookRepository) ReplayDelivery(ctx context.Context, subscriptionID, id uuid.UUID) (*models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'PENDING', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND subscription_id = $2
+		RETURNING ` + webhookDeliveryColumns
+
+	delivery, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, query, id, subscriptionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrEmailTaken         = errors.New("email already registered")
+)
+
+// TokenClaims are the claims carried by access and refresh tokens
+type TokenClaims struct {
+	Role      models.Role `json:"role"`
+	TokenType string      `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+type AuthService struct {
+	userRepo   repository.UserRepositoryInterface
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewAuthService(
+	userRepo repository.UserRepositoryInterface,
+	secret string,
+	accessTTL time.Duration,
+	refreshTTL time.Duration,
+) *AuthService {
+	return &AuthService{
+		userRepo:   userRepo,
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Signup registers a new customer and signs them in
+func (s *AuthService) Signup(ctx context.Context, req *models.SignupRequest) (*models.AuthResponse, error) {
+	if _, err := s.userRepo.GetByEmail(ctx, req.Email); err == nil {
+		return nil, ErrEmailTaken
+	}
+
+	passwordHash, err := HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Name:         req.Name,
+		Email:        req.Email,
+		Role:         models.RoleCustomer,
+		PasswordHash: &passwordHash,
+	}
+
+	err = s.userRepo.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user)
+}
+
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error) {
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.PasswordHash == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(req.Password))
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.issueTokens(user)
+}
+
+// Refresh exchanges a refresh token for a new token pair. The user is reloaded
+// so that role changes take effect.
+func (s *AuthService) Refresh(ctx context.Context, req *models.RefreshTokenRequest) (*models.AuthResponse, error) {
+	claims, err := s.parseToken(req.RefreshToken, tokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return s.issueTokens(user)
+}
+
+// Authenticate validates an access token and returns the caller it identifies
+func (s *AuthService) Authenticate(accessToken string) (*models.Caller, error) {
+	claims, err := s.parseToken(accessToken, tokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &models.Caller{UserID: userID, Role: claims.Role}, nil
+}
+
+func (s *AuthService) issueTokens(user *models.User) (*models.AuthResponse, error) {
+	accessToken, err := s.signToken(user, tokenTypeAccess, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.signToken(user, tokenTypeRefresh, s.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+		User:         user,
+	}, nil
+}
+
+func (s *AuthService) signToken(user *models.User, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := TokenClaims{
+		Role:      user.Role,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return signed, nil
+}
+
+func (s *AuthService) parseToken(tokenString, tokenType string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	_, err := jwt.ParseWithClaims(
+		strings.TrimSpace(tokenString),
+		claims,
+		func(token *jwt.Token) (interface{}, error) { return s.secret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+	)
+	if err != nil || claims.TokenType != tokenType {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return string(hash), nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	args := m.Called(id)
+	user, _ := args.Get(0).(*models.User)
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(email)
+	user, _ := args.Get(0).(*models.User)
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) GetAll(ctx context.Context, page *models.PageRequest) (*models.Page[*models.User], error) {
+	args := m.Called(page)
+	return args.Get(0).(*models.Page[*models.User]), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.Role) error {
+	args := m.Called(id, role)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func newTestAuthService(userRepo *MockUserRepository) *AuthService {
+	return NewAuthService(userRepo, "test-secret", 15*time.Minute, time.Hour)
+}
+
+func TestAuthService_Signup_Success(t *testing.T) {
+	// Setup
+	mockUserRepo := &MockUserRepository{}
+	service := newTestAuthService(mockUserRepo)
+
+	// Mock expectations
+	mockUserRepo.On("GetByEmail", "jane@example.com").Return(nil, fmt.Errorf("user not found"))
+	mockUserRepo.On("Create", mock.AnythingOfType("*models.User")).
+		Run(func(args mock.Arguments) { args.Get(0).(*models.User).ID = uuid.New() }).
+		Return(nil)
+
+	// Test
+	resp, err := service.Signup(context.Background(), &models.SignupRequest{Name: "Jane", Email: "jane@example.com", Password: "s3cret-pass"})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleCustomer, resp.User.Role)
+	assert.NotEqual(t, "s3cret-pass", *resp.User.PasswordHash)
+
+	caller, err := service.Authenticate(resp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, resp.User.ID, caller.UserID)
+	assert.Equal(t, models.RoleCustomer, caller.Role)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Signup_EmailTaken(t *testing.T) {
+	// Setup
+	mockUserRepo := &MockUserRepository{}
+	service := newTestAuthService(mockUserRepo)
+
+	// Mock expectations
+	mockUserRepo.On("GetByEmail", "jane@example.com").Return(&models.User{ID: uuid.New()}, nil)
+
+	// Test
+	_, err := service.Signup(context.Background(), &models.SignupRequest{Name: "Jane", Email: "jane@example.com", Password: "s3cret-pass"})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrEmailTaken)
+	mockUserRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestAuthService_Login(t *testing.T) {
+	// Setup
+	mockUserRepo := &MockUserRepository{}
+	service := newTestAuthService(mockUserRepo)
+
+	passwordHash, err := HashPassword("s3cret-pass")
+	require.NoError(t, err)
+	user := &models.User{ID: uuid.New(), Email: "jane@example.com", Role: models.RoleOrganizer, PasswordHash: &passwordHash}
+
+	// Mock expectations
+	mockUserRepo.On("GetByEmail", "jane@example.com").Return(user, nil)
+
+	// Test: a correct password yields tokens, a wrong one is rejected
+	resp, err := service.Login(context.Background(), &models.LoginRequest{Email: "jane@example.com", Password: "s3cret-pass"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+	assert.NotEmpty(t, resp.RefreshToken)
+
+	_, err = service.Login(context.Background(), &models.LoginRequest{Email: "jane@example.com", Password: "wrong-pass"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	// Setup
+	mockUserRepo := &MockUserRepository{}
+	service := newTestAuthService(mockUserRepo)
+
+	user := &models.User{ID: uuid.New(), Role: models.RoleCustomer}
+	tokens, err := service.issueTokens(user)
+	require.NoError(t, err)
+
+	// The role was changed since the tokens were issued
+	promoted := &models.User{ID: user.ID, Role: models.RoleAdmin}
+	mockUserRepo.On("GetByID", user.ID).Return(promoted, nil)
+
+	// Test
+	resp, err := service.Refresh(context.Background(), &models.RefreshTokenRequest{RefreshToken: tokens.RefreshToken})
+
+	// Assertions
+	require.NoError(t, err)
+	caller, err := service.Authenticate(resp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, caller.Role)
+
+	// Tokens cannot be used in place of each other
+	_, err = service.Authenticate(tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = service.Refresh(context.Background(), &models.RefreshTokenRequest{RefreshToken: tokens.AccessToken})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAuthService_Authenticate_WrongSecret(t *testing.T) {
+	other := NewAuthService(&MockUserRepository{}, "other-secret", time.Minute, time.Hour)
+	tokens, err := other.issueTokens(&models.User{ID: uuid.New(), Role: models.RoleAdmin})
+	require.NoError(t, err)
+
+	_, err = newTestAuthService(&MockUserRepository{}).Authenticate(tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/database"
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestDB connects to DATABASE_URL and applies the migrations. The test is
+// skipped when no database is configured or reachable.
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("DATABASE_URL not set, skipping integration test")
+	}
+
+	db, err := database.NewConnection(databaseURL)
+	if err != nil {
+		t.Skipf("database not available: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := migrate.New("file://../../migrations", databaseURL)
+	require.NoError(t, err)
+	defer m.Close()
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// createTestUser creates a user with role, deleted when the test ends
+func createTestUser(t *testing.T, userRepo *repository.UserRepository, role models.Role) *models.User {
+	t.Helper()
+
+	user := &models.User{
+		Name:  "Test " + string(role),
+		Email: fmt.Sprintf("%s-%s@example.com", role, uuid.New()),
+		Role:  role,
+	}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+	t.Cleanup(func() { userRepo.Delete(context.Background(), user.ID) })
+
+	return user
+}
+
+func TestBookingService_CreateBooking_ConcurrentNoOversell(t *testing.T) {
+	db := setupTestDB(t)
+
+	eventRepo := repository.NewEventRepository(db, nil)
+	userRepo := repository.NewUserRepository(db)
+	bookingRepo := repository.NewBookingRepository(db)
+	uow := repository.NewUnitOfWork(db, nil, logging.Discard())
+	service := NewBookingService(bookingRepo, eventRepo, uow, 15, logging.Discard())
+
+	const totalTickets = 50
+	const attempts = 300
+
+	organizer := createTestUser(t, userRepo, models.RoleOrganizer)
+	caller := &models.Caller{UserID: organizer.ID, Role: organizer.Role}
+
+	price := usd(1000)
+	event, err := NewEventService(eventRepo, repository.NewVenueRepository(db), nil, uow).CreateEvent(context.Background(), caller, &models.CreateEventRequest{
+		Name:         "Concurrency Test Event",
+		DateTime:     time.Now().Add(24 * time.Hour),
+		TotalTickets: totalTickets,
+		TicketPrice:  &price,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { eventRepo.Delete(context.Background(), event.ID) })
+
+	user := &models.User{
+		Name:  "Concurrency Tester",
+		Email: fmt.Sprintf("concurrency-%s@example.com", uuid.New()),
+		Role:  models.RoleCustomer,
+	}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+	t.Cleanup(func() { userRepo.Delete(context.Background(), user.ID) })
+
+	var (
+		wg         sync.WaitGroup
+		succeeded  int64
+		mu         sync.Mutex
+		unexpected []error
+	)
+	start := make(chan struct{})
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, err := service.CreateBooking(context.Background(), user.ID, &models.CreateBookingRequest{
+				EventID:  event.ID.String(),
+				Quantity: 1,
+			})
+			if err == nil {
+				atomic.AddInt64(&succeeded, 1)
+				return
+			}
+			if !strings.Contains(err.Error(), "insufficient tickets available") {
+				mu.Lock()
+				unexpected = append(unexpected, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Release all goroutines at once to maximise contention
+	close(start)
+	wg.Wait()
+
+	assert.Empty(t, unexpected)
+	assert.EqualValues(t, totalTickets, succeeded)
+
+	available, err := eventRepo.GetAvailableTickets(context.Background(), event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, available)
+
+	bookings, err := bookingRepo.GetByUserID(context.Background(), user.ID, &models.BookingFilter{
+		PageRequest: models.PageRequest{Limit: models.MaxPageLimit},
+	})
+	require.NoError(t, err)
+	assert.Len(t, bookings.Data, totalTickets)
+}
+
+func TestBookingService_CreateBooking_ConcurrentSameSeat(t *testing.T) {
+	db := setupTestDB(t)
+
+	eventRepo := repository.NewEventRepository(db, nil)
+	userRepo := repository.NewUserRepository(db)
+	bookingRepo := repository.NewBookingRepository(db)
+	venueRepo := repository.NewVenueRepository(db)
+	uow := repository.NewUnitOfWork(db, nil, logging.Discard())
+	service := NewBookingService(bookingRepo, eventRepo, uow, 15, logging.Discard())
+
+	const attempts = 50
+
+	venue, err := NewVenueService(venueRepo, uow).CreateVenue(context.Background(), &models.CreateVenueRequest{
+		Name: "Concurrency Test Venue",
+		Sections: []models.CreateSectionRequest{
+			{Name: "Floor", Rows: []models.CreateRowRequest{{Label: "A", Seats: 2}}},
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { venueRepo.Delete(context.Background(), venue.ID) })
+	seat := venue.Sections[0].Rows[0].Seats[0]
+	venueID := venue.ID.String()
+
+	organizer := createTestUser(t, userRepo, models.RoleOrganizer)
+	caller := &models.Caller{UserID: organizer.ID, Role: organizer.Role}
+
+	price := usd(1000)
+	event, err := NewEventService(eventRepo, venueRepo, nil, uow).CreateEvent(context.Background(), caller, &models.CreateEventRequest{
+		Name:        "Concurrency Test Seated Event",
+		DateTime:    time.Now().Add(24 * time.Hour),
+		TicketPrice: &price,
+		VenueID:     &venueID,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { eventRepo.Delete(context.Background(), event.ID) })
+	assert.Equal(t, 2, event.TotalTickets)
+
+	user := &models.User{
+		Name:  "Seat Tester",
+		Email: fmt.Sprintf("seats-%s@example.com", uuid.New()),
+		Role:  models.RoleCustomer,
+	}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+	t.Cleanup(func() { userRepo.Delete(context.Background(), user.ID) })
+
+	var (
+		wg         sync.WaitGroup
+		succeeded  int64
+		mu         sync.Mutex
+		unexpected []error
+	)
+	start := make(chan struct{})
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, err := service.CreateBooking(context.Background(), user.ID, &models.CreateBookingRequest{
+				EventID: event.ID.String(),
+				SeatIDs: []string{seat.ID.String()},
+			})
+			if err == nil {
+				atomic.AddInt64(&succeeded, 1)
+				return
+			}
+			if !errors.Is(err, ErrSeatUnavailable) {
+				mu.Lock()
+				unexpected = append(unexpected, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	assert.Empty(t, unexpected)
+	assert.EqualValues(t, 1, succeeded)
+
+	seatMap, err := venueRepo.GetSeatMap(context.Background(), venue.ID, event.ID)
+	require.NoError(t, err)
+	seats := seatMap[0].Rows[0].Seats
+	assert.Equal(t, models.SeatStatusHeld, seats[0].Status)
+	assert.Equal(t, models.SeatStatusAvailable, seats[1].Status)
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newLimitTest returns a booking service with limits for an upcoming general
+// admission event
+func newLimitTest(limits BookingLimits) (*BookingService, *MockBookingRepository, *MockEventRepository, *models.Event) {
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+	service.SetLimits(limits)
+
+	event := &models.Event{
+		ID:           uuid.New(),
+		Name:         "Test Event",
+		DateTime:     time.Now().Add(24 * time.Hour),
+		TotalTickets: 100,
+		Currency:     "USD",
+		TicketPrice:  usd(5000),
+		TicketTypes:  generalAdmission(usd(5000), 100),
+	}
+
+	return service, mockBookingRepo, mockEventRepo, event
+}
+
+func TestBookingService_CreateBooking_WithinLimits(t *testing.T) {
+	// Setup
+	service, mockBookingRepo, mockEventRepo, event := newLimitTest(BookingLimits{MaxPendingBookings: 3, MaxTicketsPerEvent: 10})
+	userID := uuid.New()
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, singleItem(event, 4)).Return(nil)
+	mockBookingRepo.On("CountUserBookings", userID, event.ID).Return(&models.UserBookingCounts{PendingBookings: 2, EventTickets: 6}, nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Return(nil)
+
+	// Test
+	booking, err := service.CreateBooking(context.Background(), userID, &models.CreateBookingRequest{
+		EventID:  event.ID.String(),
+		Quantity: 4,
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, 4, booking.Quantity)
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestBookingService_CreateBooking_TooManyPendingBookings(t *testing.T) {
+	// Setup
+	service, mockBookingRepo, mockEventRepo, event := newLimitTest(BookingLimits{MaxPendingBookings: 2})
+	userID := uuid.New()
+	nextDeadline := time.Now().Add(7 * time.Minute)
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, singleItem(event, 1)).Return(nil)
+	mockBookingRepo.On("CountUserBookings", userID, event.ID).Return(&models.UserBookingCounts{
+		PendingBookings:     2,
+		NextPaymentDeadline: &nextDeadline,
+	}, nil)
+
+	// Test
+	booking, err := service.CreateBooking(context.Background(), userID, &models.CreateBookingRequest{
+		EventID:  event.ID.String(),
+		Quantity: 1,
+	})
+
+	// Assertions
+	assert.Nil(t, booking)
+	assert.ErrorIs(t, err, ErrTooManyPendingBookings)
+
+	var retryAfter *RetryAfterError
+	require.True(t, errors.As(err, &retryAfter))
+	assert.InDelta(t, 7*time.Minute, retryAfter.RetryAfter, float64(time.Second), "until the oldest unpaid booking's deadline")
+	mockBookingRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBookingService_CreateBooking_TicketLimitExceeded(t *testing.T) {
+	// Setup
+	service, mockBookingRepo, mockEventRepo, event := newLimitTest(BookingLimits{MaxTicketsPerEvent: 10})
+	userID := uuid.New()
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, singleItem(event, 3)).Return(nil)
+	mockBookingRepo.On("CountUserBookings", userID, event.ID).Return(&models.UserBookingCounts{EventTickets: 8}, nil)
+
+	// Test
+	booking, err := service.CreateBooking(context.Background(), userID, &models.CreateBookingRequest{
+		EventID:  event.ID.String(),
+		Quantity: 3,
+	})
+
+	// Assertions
+	assert.Nil(t, booking)
+	assert.ErrorIs(t, err, ErrTicketLimitExceeded)
+	mockBookingRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBookingService_CreateBooking_NoLimits(t *testing.T) {
+	// Setup
+	service, mockBookingRepo, mockEventRepo, event := newLimitTest(BookingLimits{})
+	userID := uuid.New()
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, singleItem(event, 50)).Return(nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Return(nil)
+
+	// Test
+	_, err := service.CreateBooking(context.Background(), userID, &models.CreateBookingRequest{
+		EventID:  event.ID.String(),
+		Quantity: 50,
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	mockBookingRepo.AssertNotCalled(t, "CountUserBookings", mock.Anything, mock.Anything)
+}
+
+func TestBookingService_CheckoutHold_TicketLimitRestoresHold(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, mockBookingRepo, mockEventRepo := newCheckoutTestService()
+	service.SetLimits(BookingLimits{MaxTicketsPerEvent: 4})
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	hold := liveHold(event, userID, 3)
+
+	// Mock expectations: the hold's tickets count towards the limit
+	mockHoldRepo.On("GetByID", hold.ID).Return(hold, nil)
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+	mockHoldRepo.On("Delete", hold).Return(true, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, hold.Items).Return(nil)
+	mockBookingRepo.On("CountUserBookings", userID, event.ID).Return(&models.UserBookingCounts{EventTickets: 2}, nil)
+	mockHoldRepo.On("GetByEventID", event.ID).Return([]*models.SeatHold{}, nil)
+	mockHoldRepo.On("Create", hold).Return(nil)
+
+	// Test
+	booking, err := service.CheckoutHold(context.Background(), hold.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer}, &models.CheckoutHoldRequest{})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrTicketLimitExceeded)
+	assert.Nil(t, booking)
+	mockBookingRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockHoldRepo.AssertExpectations(t)
+}
+
+func TestHoldService_CreateHold_TicketLimitCountsHolds(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, mockEventRepo := newHoldTestService()
+	service.SetLimits(BookingLimits{MaxTicketsPerEvent: 10})
+	mockBookingRepo := service.uow.(*MockUnitOfWork).repos.Bookings.(*MockBookingRepository)
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	held := liveHold(event, userID, 4)
+	others := liveHold(event, uuid.New(), 6)
+
+	// Mock expectations: 4 booked and 4 held, so 3 more is over the limit;
+	// another user's hold does not count
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, mock.Anything).Return(nil)
+	mockBookingRepo.On("CountUserBookings", userID, event.ID).Return(&models.UserBookingCounts{EventTickets: 4}, nil)
+	mockHoldRepo.On("GetByEventID", event.ID).Return([]*models.SeatHold{held, others}, nil)
+
+	// Test
+	hold, err := service.CreateHold(context.Background(), userID, &models.CreateHoldRequest{
+		EventID: event.ID.String(),
+		Items:   []models.BookingItemRequest{{TicketTypeID: event.TicketTypes[0].ID.String(), Quantity: 3}},
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrTicketLimitExceeded)
+	assert.Nil(t, hold)
+	mockHoldRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestHoldService_CreateHold_TooManyPendingBookings(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, mockEventRepo := newHoldTestService()
+	service.SetLimits(BookingLimits{MaxPendingBookings: 2})
+	mockBookingRepo := service.uow.(*MockUnitOfWork).repos.Bookings.(*MockBookingRepository)
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	nextDeadline := time.Now().Add(5 * time.Minute)
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, mock.Anything).Return(nil)
+	mockBookingRepo.On("CountUserBookings", userID, event.ID).Return(&models.UserBookingCounts{
+		PendingBookings:     2,
+		NextPaymentDeadline: &nextDeadline,
+	}, nil)
+
+	// Test
+	hold, err := service.CreateHold(context.Background(), userID, &models.CreateHoldRequest{
+		EventID: event.ID.String(),
+		Items:   []models.BookingItemRequest{{TicketTypeID: event.TicketTypes[0].ID.String(), Quantity: 1}},
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrTooManyPendingBookings)
+	assert.Nil(t, hold)
+	mockHoldRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBookingRepository_CountUserBookings_IgnoresExpired(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	eventRepo := repository.NewEventRepository(db, nil)
+	userRepo := repository.NewUserRepository(db)
+	bookingRepo := repository.NewBookingRepository(db)
+	uow := repository.NewUnitOfWork(db, nil, logging.Discard())
+
+	organizer := createTestUser(t, userRepo, models.RoleOrganizer)
+	customer := createTestUser(t, userRepo, models.RoleCustomer)
+
+	price := usd(3000)
+	event, err := NewEventService(eventRepo, repository.NewVenueRepository(db), nil, uow).CreateEvent(ctx,
+		&models.Caller{UserID: organizer.ID, Role: organizer.Role},
+		&models.CreateEventRequest{
+			Name:         "Limits Event " + uuid.NewString(),
+			DateTime:     time.Now().Add(24 * time.Hour),
+			TotalTickets: 20,
+			TicketPrice:  &price,
+		})
+	require.NoError(t, err)
+	t.Cleanup(func() { eventRepo.Delete(ctx, event.ID) })
+
+	book := func(status models.BookingStatus, quantity int, deadline time.Time) {
+		t.Helper()
+		require.NoError(t, bookingRepo.Create(ctx, &models.Booking{
+			UserID:   customer.ID,
+			EventID:  event.ID,
+			Quantity: quantity,
+			Items: []*models.BookingItem{{
+				TicketTypeID: event.TicketTypes[0].ID,
+				Quantity:     quantity,
+				UnitPrice:    price,
+			}},
+			Status:          status,
+			TotalAmount:     price.Mul(quantity),
+			DiscountAmount:  models.Zero("USD"),
+			PaymentDeadline: &deadline,
+		}))
+	}
+
+	// Setup: a live and an expired unpaid booking, and a paid one whose
+	// deadline has long passed
+	book(models.BookingStatusPending, 2, time.Now().Add(10*time.Minute))
+	book(models.BookingStatusPending, 4, time.Now().Add(-time.Minute))
+	book(models.BookingStatusConfirmed, 3, time.Now().Add(-time.Hour))
+
+	// Test
+	var counts *models.UserBookingCounts
+	err = uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		counts, err = repos.Bookings.CountUserBookings(ctx, customer.ID, event.ID)
+		return err
+	})
+
+	// Assertions: the expired booking's tickets no longer count
+	require.NoError(t, err)
+	assert.Equal(t, 1, counts.PendingBookings)
+	assert.Equal(t, 5, counts.EventTickets)
+}
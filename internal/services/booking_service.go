@@ -1,47 +1,130 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/metrics"
 	"ticket-booking-system/internal/models"
 	"ticket-booking-system/internal/repository"
 
 	"github.com/google/uuid"
 )
 
+// BookingLimits caps what one customer can book, so that inventory cannot be
+// tied up in unpaid bookings. A zero limit is no limit.
+type BookingLimits struct {
+	MaxPendingBookings int // unpaid bookings at once, across all events
+	MaxTicketsPerEvent int // tickets in pending and confirmed bookings for one event
+}
+
 type BookingService struct {
 	bookingRepo     repository.BookingRepositoryInterface
 	eventRepo       repository.EventRepositoryInterface
+	uow             repository.UnitOfWorkInterface
 	paymentDeadline int // in minutes
+	releases        TicketReleaseListener
+	metrics         *metrics.Metrics
+	logger          *slog.Logger
+	limits          BookingLimits
 }
 
 func NewBookingService(
 	bookingRepo repository.BookingRepositoryInterface,
 	eventRepo repository.EventRepositoryInterface,
+	uow repository.UnitOfWorkInterface,
 	paymentDeadline int,
+	logger *slog.Logger,
 ) *BookingService {
 	return &BookingService{
 		bookingRepo:     bookingRepo,
 		eventRepo:       eventRepo,
+		uow:             uow,
 		paymentDeadline: paymentDeadline,
+		logger:          logger,
 	}
 }
 
-func (s *BookingService) CreateBooking(req *models.CreateBookingRequest) (*models.Booking, error) {
-	// Parse UUIDs
-	userID, err := uuid.Parse(req.UserID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid user ID: %w", err)
+// SetReleaseListener sets the listener notified when a cancellation or refund
+// releases tickets
+func (s *BookingService) SetReleaseListener(listener TicketReleaseListener) {
+	s.releases = listener
+}
+
+// SetMetrics sets the metrics that failed ticket reservations are counted in
+func (s *BookingService) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
+
+// SetLimits sets the caps on what one customer can book
+func (s *BookingService) SetLimits(limits BookingLimits) {
+	s.limits = limits
+}
+
+// ticketsReleased notifies the release listener, if any
+func (s *BookingService) ticketsReleased(ctx context.Context, eventID uuid.UUID) {
+	if s.releases != nil {
+		s.releases.TicketsReleased(ctx, eventID)
 	}
+}
+
+var (
+	// ErrForbidden is returned when the caller may not act on a resource
+	ErrForbidden = errors.New("you do not have access to this resource")
+
+	// ErrInvalidStatus is returned when a booking is not in the status an
+	// action needs, including when it changed while the action was running
+	ErrInvalidStatus = errors.New("invalid booking status")
+
+	// ErrBookingNotRefundable is returned when refunding a booking that was
+	// never paid for
+	ErrBookingNotRefundable = errors.New("only confirmed bookings can be refunded")
 
+	// ErrRefundWindowClosed is returned once the event's cancellation policy
+	// no longer allows a refund
+	ErrRefundWindowClosed = errors.New("the cancellation policy no longer allows a refund for this event")
+
+	// ErrSeatUnavailable is returned when a requested seat is already held or
+	// sold for the event
+	ErrSeatUnavailable = errors.New("one or more seats are no longer available")
+
+	// ErrTooManyPendingBookings is returned when the customer already has the
+	// maximum number of unpaid bookings
+	ErrTooManyPendingBookings = errors.New("you have too many unpaid bookings, pay for or cancel one first")
+
+	// ErrTicketLimitExceeded is returned when a booking would take the
+	// customer over the maximum number of tickets for the event
+	ErrTicketLimitExceeded = errors.New("this booking would exceed the maximum number of tickets per customer for this event")
+)
+
+// RetryAfterError is returned when a request is refused by a limit that frees
+// up after RetryAfter
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+func (s *BookingService) CreateBooking(ctx context.Context, userID uuid.UUID, req *models.CreateBookingRequest) (*models.Booking, error) {
+	// Parse UUIDs
 	eventID, err := uuid.Parse(req.EventID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid event ID: %w", err)
 	}
 
 	// Get event details
-	event, err := s.eventRepo.GetByID(eventID)
+	event, err := s.eventRepo.GetByID(ctx, eventID)
 	if err != nil {
 		return nil, fmt.Errorf("event not found: %w", err)
 	}
@@ -51,71 +134,485 @@ func (s *BookingService) CreateBooking(req *models.CreateBookingRequest) (*model
 		return nil, fmt.Errorf("cannot book tickets for past events")
 	}
 
-	// Reserve tickets with row locking to prevent race conditions
-	err = s.eventRepo.ReserveTickets(eventID, req.Quantity)
+	// Reserved seating events book specific seats, general admission a quantity
+	quantity := req.Quantity
+	var seatIDs []uuid.UUID
+	if event.VenueID != nil {
+		seatIDs, err = parseSeatIDs(req.SeatIDs)
+		if err != nil {
+			return nil, err
+		}
+		quantity = len(seatIDs)
+	} else if len(req.SeatIDs) > 0 {
+		return nil, fmt.Errorf("seat_ids can only be used for reserved seating events")
+	}
+
+	items, err := bookingItems(event, req.Items, quantity)
 	if err != nil {
-		return nil, fmt.Errorf("failed to reserve tickets: %w", err)
+		return nil, err
 	}
 
-	// Calculate total amount
-	totalAmount := float64(req.Quantity) * event.TicketPrice
+	booking := s.newBooking(userID, event, items)
+
+	// Reserve tickets and insert the booking in one transaction so the event
+	// row lock is held until the booking is visible to other reservations
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if err := reserveTickets(ctx, repos, s.metrics, eventID, items); err != nil {
+			return err
+		}
+
+		if err := s.limits.check(ctx, repos, userID, eventID, booking.Quantity); err != nil {
+			return err
+		}
+
+		if len(seatIDs) > 0 {
+			if err := checkSeatsNotHeld(ctx, repos.Holds, eventID, seatIDs); err != nil {
+				return err
+			}
+		}
+
+		return placeBooking(ctx, repos, event, booking, seatIDs, req.PromoCode)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Booking created", logging.BookingID(booking.ID), logging.EventID(booking.EventID))
+	return booking, nil
+}
+
+// CheckoutHold turns a live hold owned by the caller into a pending booking
+// for the held tickets and seats, at the prices they were held at. The hold
+// is deleted before the booking is created so that it can only be checked out
+// once; it is put back if the booking cannot be created.
+func (s *BookingService) CheckoutHold(ctx context.Context, id uuid.UUID, caller *models.Caller, req *models.CheckoutHoldRequest) (*models.Booking, error) {
+	var booking *models.Booking
+	var claimed *models.SeatHold
+	var holdRepo repository.HoldRepositoryInterface
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		holdRepo = repos.Holds
+
+		hold, err := repos.Holds.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrHoldNotFound) {
+			return ErrHoldNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if hold.UserID != caller.UserID {
+			return ErrForbidden
+		}
+
+		// Lock the event first so no other hold or booking can take the held
+		// tickets between deleting the hold and inserting the booking
+		event, err := repos.Events.GetByIDForUpdate(ctx, hold.EventID)
+		if err != nil {
+			return err
+		}
+
+		if event.DateTime.Before(time.Now()) {
+			return fmt.Errorf("cannot book tickets for past events")
+		}
+
+		live, err := repos.Holds.Delete(ctx, hold)
+		if err != nil {
+			return err
+		}
+		if !live {
+			return ErrHoldNotFound
+		}
+		claimed = hold
+
+		if err := reserveTickets(ctx, repos, s.metrics, event.ID, hold.Items); err != nil {
+			return err
+		}
+
+		booking = s.newBooking(caller.UserID, event, hold.Items)
+		if err := s.limits.check(ctx, repos, caller.UserID, event.ID, booking.Quantity); err != nil {
+			return err
+		}
+		return placeBooking(ctx, repos, event, booking, hold.SeatIDs, req.PromoCode)
+	})
+	if err != nil {
+		if claimed != nil && claimed.ExpiresAt.After(time.Now()) {
+			if restoreErr := holdRepo.Create(context.WithoutCancel(ctx), claimed); restoreErr != nil {
+				s.logger.ErrorContext(ctx, "Failed to restore hold after a failed checkout", slog.String("hold_id", claimed.ID.String()), logging.Err(restoreErr))
+			}
+		}
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Booking created from hold", logging.BookingID(booking.ID), logging.EventID(booking.EventID), slog.String("hold_id", id.String()))
+	return booking, nil
+}
+
+// check checks that the user may book or hold quantity more tickets for the
+// event. It must run inside the transaction that creates the booking or hold:
+// CountUserBookings locks the user, so concurrent requests from one user are
+// counted one at a time. Tickets in the user's live holds for the event count
+// towards the per-event cap.
+func (l BookingLimits) check(ctx context.Context, repos *repository.Repositories, userID, eventID uuid.UUID, quantity int) error {
+	if l.MaxPendingBookings <= 0 && l.MaxTicketsPerEvent <= 0 {
+		return nil
+	}
+
+	counts, err := repos.Bookings.CountUserBookings(ctx, userID, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to count bookings: %w", err)
+	}
+
+	if limit := l.MaxPendingBookings; limit > 0 && counts.PendingBookings >= limit {
+		// A slot frees up once the oldest unpaid booking is paid or expires
+		var retryAfter time.Duration
+		if counts.NextPaymentDeadline != nil {
+			retryAfter = time.Until(*counts.NextPaymentDeadline)
+		}
+		return &RetryAfterError{Err: ErrTooManyPendingBookings, RetryAfter: retryAfter}
+	}
+
+	if limit := l.MaxTicketsPerEvent; limit > 0 {
+		held, err := heldTickets(ctx, repos.Holds, userID, eventID)
+		if err != nil {
+			return err
+		}
+		if counts.EventTickets+held+quantity > limit {
+			return ErrTicketLimitExceeded
+		}
+	}
+
+	return nil
+}
+
+// reserveTickets reserves items of an event, counting the reservation in m if
+// it fails
+func reserveTickets(ctx context.Context, repos *repository.Repositories, m *metrics.Metrics, eventID uuid.UUID, items []*models.BookingItem) error {
+	err := repos.Events.ReserveTickets(ctx, eventID, items)
+	if err == nil {
+		return nil
+	}
+
+	reason := metrics.ReservationError
+	if errors.Is(err, repository.ErrInsufficientTickets) {
+		reason = metrics.ReservationSoldOut
+	}
+	m.ReservationFailed(eventID, reason)
+
+	return fmt.Errorf("failed to reserve tickets: %w", err)
+}
+
+// newBooking returns a pending booking of items that must be paid for before
+// the payment deadline
+func (s *BookingService) newBooking(userID uuid.UUID, event *models.Event, items []*models.BookingItem) *models.Booking {
+	quantity := 0
+	totalAmount := models.Zero(event.Currency)
+	for _, item := range items {
+		quantity += item.Quantity
+		totalAmount = totalAmount.Add(item.UnitPrice.Mul(item.Quantity))
+	}
 
 	// Set payment deadline from config
 	paymentDeadline := time.Now().Add(time.Duration(s.paymentDeadline) * time.Minute)
 
-	// Create booking
-	booking := &models.Booking{
+	return &models.Booking{
 		UserID:          userID,
-		EventID:         eventID,
-		Quantity:        req.Quantity,
+		EventID:         event.ID,
+		Quantity:        quantity,
+		Items:           items,
 		Status:          models.BookingStatusPending,
 		TotalAmount:     totalAmount,
+		DiscountAmount:  models.Zero(event.Currency),
 		PaymentDeadline: &paymentDeadline,
 	}
+}
 
-	// Create booking in database
-	err = s.bookingRepo.CreateWithTransaction(booking)
+// placeBooking applies the promo code, if any, inserts the booking and claims
+// its seats. The tickets must already be reserved in the same unit of work.
+func placeBooking(ctx context.Context, repos *repository.Repositories, event *models.Event, booking *models.Booking, seatIDs []uuid.UUID, promoCode string) error {
+	if len(seatIDs) > 0 {
+		if err := checkSeatZones(ctx, repos, event, booking.Items, seatIDs); err != nil {
+			return err
+		}
+	}
+
+	if promoCode != "" {
+		if err := applyPromoCode(ctx, repos, promoCode, booking, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if err := repos.Bookings.Create(ctx, booking); err != nil {
+		return fmt.Errorf("failed to create booking: %w", err)
+	}
+
+	if len(seatIDs) > 0 {
+		err := repos.Bookings.ClaimSeats(ctx, booking.ID, event.ID, *event.VenueID, seatIDs)
+		if errors.Is(err, repository.ErrSeatTaken) {
+			return ErrSeatUnavailable
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim seats: %w", err)
+		}
+		booking.SeatIDs = seatIDs
+	}
+
+	return nil
+}
+
+// checkSeatZones checks that the items pay for the seats' price zones: seats
+// in a zone that one of the event's ticket types is sold for must be booked
+// with that zone's ticket types, one ticket per seat, and all other seats with
+// ticket types that have no zone.
+func checkSeatZones(ctx context.Context, repos *repository.Repositories, event *models.Event, items []*models.BookingItem, seatIDs []uuid.UUID) error {
+	typeZones := make(map[uuid.UUID]string, len(event.TicketTypes))
+	zoned := make(map[string]bool)
+	for _, ticketType := range event.TicketTypes {
+		typeZones[ticketType.ID] = ticketType.Zone
+		if ticketType.Zone != "" {
+			zoned[ticketType.Zone] = true
+		}
+	}
+	if len(zoned) == 0 {
+		return nil
+	}
+
+	seatZones, err := repos.Venues.GetSeatZones(ctx, *event.VenueID, seatIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create booking: %w", err)
+		return fmt.Errorf("failed to get seat zones: %w", err)
 	}
 
-	return booking, nil
+	// Tickets per zone less seats per zone, with "" for seats in no ticket
+	// type's zone, must come out even
+	tickets := make(map[string]int)
+	for _, item := range items {
+		tickets[typeZones[item.TicketTypeID]] += item.Quantity
+	}
+	for _, seatID := range seatIDs {
+		zone, ok := seatZones[seatID]
+		if !ok {
+			return fmt.Errorf("seat %s is not in the event's venue", seatID)
+		}
+		if !zoned[zone] {
+			zone = ""
+		}
+		tickets[zone]--
+	}
+
+	for _, count := range tickets {
+		if count != 0 {
+			return fmt.Errorf("the ticket types do not match the seats' zones")
+		}
+	}
+
+	return nil
+}
+
+// bookingItems prices the requested line items at the event's ticket type
+// prices. Without items, quantity tickets of the event's only ticket type are
+// booked. A non-zero quantity must match the items' total.
+func bookingItems(event *models.Event, reqItems []models.BookingItemRequest, quantity int) ([]*models.BookingItem, error) {
+	if len(reqItems) == 0 {
+		if quantity < 1 {
+			return nil, fmt.Errorf("quantity or items is required")
+		}
+		if len(event.TicketTypes) != 1 {
+			return nil, fmt.Errorf("items are required for events with several ticket types")
+		}
+
+		return []*models.BookingItem{{
+			TicketTypeID: event.TicketTypes[0].ID,
+			Quantity:     quantity,
+			UnitPrice:    event.TicketTypes[0].Price,
+		}}, nil
+	}
+
+	prices := make(map[uuid.UUID]models.Money, len(event.TicketTypes))
+	for _, ticketType := range event.TicketTypes {
+		prices[ticketType.ID] = ticketType.Price
+	}
+
+	items := make([]*models.BookingItem, 0, len(reqItems))
+	seen := make(map[uuid.UUID]bool, len(reqItems))
+	total := 0
+	for _, reqItem := range reqItems {
+		ticketTypeID, err := uuid.Parse(reqItem.TicketTypeID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket type ID: %w", err)
+		}
+
+		price, ok := prices[ticketTypeID]
+		if !ok {
+			return nil, fmt.Errorf("ticket type %s is not sold for this event", ticketTypeID)
+		}
+		if seen[ticketTypeID] {
+			return nil, fmt.Errorf("ticket type %s is listed more than once", ticketTypeID)
+		}
+		seen[ticketTypeID] = true
+
+		items = append(items, &models.BookingItem{
+			TicketTypeID: ticketTypeID,
+			Quantity:     reqItem.Quantity,
+			UnitPrice:    price,
+		})
+		total += reqItem.Quantity
+	}
+
+	if quantity != 0 && total != quantity {
+		return nil, fmt.Errorf("items add up to %d tickets but %d were requested", total, quantity)
+	}
+
+	return items, nil
 }
 
-func (s *BookingService) GetBooking(id uuid.UUID) (*models.Booking, error) {
-	return s.bookingRepo.GetByID(id)
+// parseSeatIDs parses the seats requested for a reserved seating event
+func parseSeatIDs(ids []string) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("seat_ids is required for reserved seating events")
+	}
+
+	seatIDs := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, idStr := range ids {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seat ID: %w", err)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("seat %s is requested more than once", id)
+		}
+		seen[id] = true
+		seatIDs = append(seatIDs, id)
+	}
+
+	return seatIDs, nil
 }
 
-func (s *BookingService) GetUserBookings(userID uuid.UUID) ([]*models.Booking, error) {
-	return s.bookingRepo.GetByUserID(userID)
+// GetBooking returns a booking owned by the caller. Admins may read any booking.
+func (s *BookingService) GetBooking(ctx context.Context, id uuid.UUID, caller *models.Caller) (*models.Booking, error) {
+	booking, err := s.bookingRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if booking.UserID != caller.UserID && !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	return booking, nil
+}
+
+func (s *BookingService) GetUserBookings(ctx context.Context, userID uuid.UUID, filter *models.BookingFilter) (*models.Page[*models.Booking], error) {
+	return s.bookingRepo.GetByUserID(ctx, userID, filter)
 }
 
-func (s *BookingService) CancelBooking(id uuid.UUID) error {
-	booking, err := s.bookingRepo.GetByID(id)
+func (s *BookingService) CancelBooking(ctx context.Context, id uuid.UUID, caller *models.Caller) error {
+	booking, err := s.GetBooking(ctx, id, caller)
 	if err != nil {
 		return err
 	}
 
 	// Only allow cancellation of pending bookings
 	if booking.Status != models.BookingStatusPending {
-		return fmt.Errorf("only pending bookings can be cancelled")
+		return fmt.Errorf("%w: only pending bookings can be cancelled", ErrInvalidStatus)
+	}
+
+	// Cancel only if still pending, as the payment may have been confirmed
+	// since the booking was read
+	err = s.bookingRepo.UpdatePendingStatus(ctx, id, models.BookingStatusCancelled)
+	if errors.Is(err, repository.ErrBookingNotPending) {
+		return fmt.Errorf("%w: only pending bookings can be cancelled", ErrInvalidStatus)
+	}
+	if err != nil {
+		return err
 	}
 
-	// Update booking status to cancelled
-	return s.bookingRepo.UpdateStatus(id, models.BookingStatusCancelled)
+	s.logger.InfoContext(ctx, "Booking cancelled", logging.BookingID(id), logging.EventID(booking.EventID))
+	s.ticketsReleased(ctx, booking.EventID)
+	return nil
 }
 
-func (s *BookingService) ConfirmBooking(id uuid.UUID) error {
-	booking, err := s.bookingRepo.GetByID(id)
+// RefundBooking cancels a confirmed booking, releasing its tickets, and records
+// a refund of the amount allowed by the event's cancellation policy. The money
+// is returned separately by PaymentService.ProcessRefund. Refunding a booking
+// again returns its existing refund so that a failed refund can be retried.
+func (s *BookingService) RefundBooking(ctx context.Context, id uuid.UUID, caller *models.Caller) (*models.Refund, error) {
+	var refund *models.Refund
+	var released *uuid.UUID
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		// Lock the booking so concurrent refunds cannot both see it confirmed
+		booking, err := repos.Bookings.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if booking.UserID != caller.UserID && !caller.IsAdmin() {
+			return ErrForbidden
+		}
+
+		if booking.Status == models.BookingStatusRefunded {
+			refund, err = repos.Refunds.GetByBookingID(ctx, id)
+			return err
+		}
+
+		if booking.Status != models.BookingStatusConfirmed || booking.PaymentReference == nil {
+			return ErrBookingNotRefundable
+		}
+
+		event, err := repos.Events.GetByID(ctx, booking.EventID)
+		if err != nil {
+			return err
+		}
+
+		amount, ok := event.CancellationPolicy.RefundAmount(booking.TotalAmount, event.DateTime, time.Now())
+		if !ok {
+			return ErrRefundWindowClosed
+		}
+
+		// Refunded bookings no longer count against the event's tickets
+		if err := repos.Bookings.UpdateStatus(ctx, id, models.BookingStatusRefunded); err != nil {
+			return err
+		}
+
+		refund = &models.Refund{
+			BookingID: id,
+			Amount:    amount,
+			Status:    models.RefundStatusPending,
+		}
+		if err := repos.Refunds.Create(ctx, refund); err != nil {
+			return fmt.Errorf("failed to create refund: %w", err)
+		}
+
+		released = &booking.EventID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if released != nil {
+		s.ticketsReleased(ctx, *released)
+	}
+
+	return refund, nil
+}
+
+func (s *BookingService) ConfirmBooking(ctx context.Context, id uuid.UUID) error {
+	booking, err := s.bookingRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
 	// Only allow confirmation of pending bookings
 	if booking.Status != models.BookingStatusPending {
-		return fmt.Errorf("only pending bookings can be confirmed")
+		return fmt.Errorf("%w: only pending bookings can be confirmed", ErrInvalidStatus)
 	}
 
-	// Update booking status to confirmed
-	return s.bookingRepo.UpdateStatus(id, models.BookingStatusConfirmed)
+	// Confirm only if still pending, as it may have expired since it was read
+	err = s.bookingRepo.UpdatePendingStatus(ctx, id, models.BookingStatusConfirmed)
+	if errors.Is(err, repository.ErrBookingNotPending) {
+		return fmt.Errorf("%w: only pending bookings can be confirmed", ErrInvalidStatus)
+	}
+	return err
 }
@@ -1,10 +1,13 @@
 package services
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"ticket-booking-system/internal/logging"
 	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -16,90 +19,179 @@ type MockBookingRepository struct {
 	mock.Mock
 }
 
-func (m *MockBookingRepository) Create(booking *models.Booking) error {
+func (m *MockBookingRepository) Create(ctx context.Context, booking *models.Booking) error {
 	args := m.Called(booking)
 	return args.Error(0)
 }
 
-func (m *MockBookingRepository) GetByID(id uuid.UUID) (*models.Booking, error) {
+func (m *MockBookingRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Booking, error) {
 	args := m.Called(id)
 	return args.Get(0).(*models.Booking), args.Error(1)
 }
 
-func (m *MockBookingRepository) GetByUserID(userID uuid.UUID) ([]*models.Booking, error) {
-	args := m.Called(userID)
-	return args.Get(0).([]*models.Booking), args.Error(1)
+func (m *MockBookingRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Booking, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.Booking), args.Error(1)
+}
+
+func (m *MockBookingRepository) GetByUserID(ctx context.Context, userID uuid.UUID, filter *models.BookingFilter) (*models.Page[*models.Booking], error) {
+	args := m.Called(userID, filter)
+	return args.Get(0).(*models.Page[*models.Booking]), args.Error(1)
 }
 
-func (m *MockBookingRepository) UpdateStatus(id uuid.UUID, status models.BookingStatus) error {
+func (m *MockBookingRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.BookingStatus) error {
 	args := m.Called(id, status)
 	return args.Error(0)
 }
 
-func (m *MockBookingRepository) GetPendingBookings() ([]*models.Booking, error) {
+func (m *MockBookingRepository) UpdatePendingStatus(ctx context.Context, id uuid.UUID, status models.BookingStatus) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
+func (m *MockBookingRepository) Expire(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockBookingRepository) ConfirmPayment(ctx context.Context, id uuid.UUID, paymentReference string) error {
+	args := m.Called(id, paymentReference)
+	return args.Error(0)
+}
+
+func (m *MockBookingRepository) ClaimSeats(ctx context.Context, bookingID, eventID, venueID uuid.UUID, seatIDs []uuid.UUID) error {
+	args := m.Called(bookingID, eventID, venueID, seatIDs)
+	return args.Error(0)
+}
+
+func (m *MockBookingRepository) GetPendingBookings(ctx context.Context) ([]*models.Booking, error) {
 	args := m.Called()
 	return args.Get(0).([]*models.Booking), args.Error(1)
 }
 
-func (m *MockBookingRepository) GetExpiredBookings() ([]*models.Booking, error) {
+func (m *MockBookingRepository) GetExpiredBookings(ctx context.Context) ([]*models.Booking, error) {
 	args := m.Called()
 	return args.Get(0).([]*models.Booking), args.Error(1)
 }
 
-func (m *MockBookingRepository) CreateWithTransaction(booking *models.Booking) error {
-	args := m.Called(booking)
-	return args.Error(0)
+func (m *MockBookingRepository) CountUserBookings(ctx context.Context, userID, eventID uuid.UUID) (*models.UserBookingCounts, error) {
+	args := m.Called(userID, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserBookingCounts), args.Error(1)
 }
 
 type MockEventRepository struct {
 	mock.Mock
 }
 
-func (m *MockEventRepository) GetByID(id uuid.UUID) (*models.Event, error) {
+func (m *MockEventRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Event, error) {
 	args := m.Called(id)
 	return args.Get(0).(*models.Event), args.Error(1)
 }
 
-func (m *MockEventRepository) ReserveTickets(eventID uuid.UUID, quantity int) error {
-	args := m.Called(eventID, quantity)
+func (m *MockEventRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Event, error) {
+	args := m.Called(id)
+	event, _ := args.Get(0).(*models.Event)
+	return event, args.Error(1)
+}
+
+func (m *MockEventRepository) ReserveTickets(ctx context.Context, eventID uuid.UUID, items []*models.BookingItem) error {
+	args := m.Called(eventID, items)
 	return args.Error(0)
 }
 
-func (m *MockEventRepository) Create(event *models.Event) error {
+func (m *MockEventRepository) Create(ctx context.Context, event *models.Event) error {
 	args := m.Called(event)
 	return args.Error(0)
 }
 
-func (m *MockEventRepository) GetAll() ([]*models.Event, error) {
-	args := m.Called()
-	return args.Get(0).([]*models.Event), args.Error(1)
+func (m *MockEventRepository) GetAll(ctx context.Context, filter *models.EventFilter) (*models.Page[*models.Event], error) {
+	args := m.Called(filter)
+	return args.Get(0).(*models.Page[*models.Event]), args.Error(1)
 }
 
-func (m *MockEventRepository) Update(event *models.Event) error {
+func (m *MockEventRepository) Update(ctx context.Context, event *models.Event) error {
 	args := m.Called(event)
 	return args.Error(0)
 }
 
-func (m *MockEventRepository) Delete(id uuid.UUID) error {
+func (m *MockEventRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockEventRepository) GetStatistics(eventID uuid.UUID) (*models.EventStatistics, error) {
+func (m *MockEventRepository) GetStatistics(ctx context.Context, eventID uuid.UUID) (*models.EventStatistics, error) {
 	args := m.Called(eventID)
 	return args.Get(0).(*models.EventStatistics), args.Error(1)
 }
 
-func (m *MockEventRepository) GetAvailableTickets(eventID uuid.UUID) (int, error) {
+func (m *MockEventRepository) GetAvailableTickets(ctx context.Context, eventID uuid.UUID) (int, error) {
 	args := m.Called(eventID)
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockEventRepository) GetSellThrough(ctx context.Context) ([]*models.EventSellThrough, error) {
+	args := m.Called()
+	events, _ := args.Get(0).([]*models.EventSellThrough)
+	return events, args.Error(1)
+}
+
+// MockUnitOfWork runs the callback directly against the mock repositories. Like
+// a real transaction it does not start once ctx is done.
+type MockUnitOfWork struct {
+	repos *repository.Repositories
+}
+
+func (m *MockUnitOfWork) WithinTransaction(ctx context.Context, fn func(repos *repository.Repositories) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fn(m.repos)
+}
+
+func newMockUnitOfWork(bookingRepo *MockBookingRepository, eventRepo *MockEventRepository) *MockUnitOfWork {
+	return &MockUnitOfWork{
+		repos: &repository.Repositories{
+			Events:   eventRepo,
+			Bookings: bookingRepo,
+		},
+	}
+}
+
+// usd returns an amount of US dollars in cents
+func usd(cents int64) models.Money {
+	return models.NewMoney(cents, "USD")
+}
+
+// generalAdmission returns the single ticket type of an event created without
+// ticket types
+func generalAdmission(price models.Money, allocation int) []*models.TicketType {
+	return []*models.TicketType{{
+		ID:         uuid.New(),
+		Name:       "General Admission",
+		Price:      price,
+		Allocation: allocation,
+		Available:  allocation,
+	}}
+}
+
+// singleItem is the line item of a booking for quantity tickets of the event's
+// first ticket type
+func singleItem(event *models.Event, quantity int) []*models.BookingItem {
+	return []*models.BookingItem{{
+		TicketTypeID: event.TicketTypes[0].ID,
+		Quantity:     quantity,
+		UnitPrice:    event.TicketTypes[0].Price,
+	}}
+}
+
 func TestBookingService_CreateBooking_Success(t *testing.T) {
 	// Setup
 	mockBookingRepo := &MockBookingRepository{}
 	mockEventRepo := &MockEventRepository{}
-	service := NewBookingService(mockBookingRepo, mockEventRepo, 15)
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
 
 	userID := uuid.New()
 	eventID := uuid.New()
@@ -111,22 +203,23 @@ func TestBookingService_CreateBooking_Success(t *testing.T) {
 		Name:         "Test Event",
 		DateTime:     time.Now().Add(24 * time.Hour),
 		TotalTickets: 100,
-		TicketPrice:  50.0,
+		Currency:     "USD",
+		TicketPrice:  usd(5000),
+		TicketTypes:  generalAdmission(usd(5000), 100),
 	}
 
 	// Mock expectations
 	mockEventRepo.On("GetByID", eventID).Return(event, nil)
-	mockEventRepo.On("ReserveTickets", eventID, quantity).Return(nil)
-	mockBookingRepo.On("CreateWithTransaction", mock.AnythingOfType("*models.Booking")).Return(nil)
+	mockEventRepo.On("ReserveTickets", eventID, singleItem(event, quantity)).Return(nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Return(nil)
 
 	// Test
 	req := &models.CreateBookingRequest{
-		UserID:   userID.String(),
 		EventID:  eventID.String(),
 		Quantity: quantity,
 	}
 
-	booking, err := service.CreateBooking(req)
+	booking, err := service.CreateBooking(context.Background(), userID, req)
 
 	// Assertions
 	assert.NoError(t, err)
@@ -135,7 +228,8 @@ func TestBookingService_CreateBooking_Success(t *testing.T) {
 	assert.Equal(t, eventID, booking.EventID)
 	assert.Equal(t, quantity, booking.Quantity)
 	assert.Equal(t, models.BookingStatusPending, booking.Status)
-	assert.Equal(t, 100.0, booking.TotalAmount) // 2 * 50.0
+	assert.Equal(t, usd(10000), booking.TotalAmount) // 2 * 50.00
+	assert.Equal(t, usd(0), booking.DiscountAmount)
 	assert.NotNil(t, booking.PaymentDeadline)
 
 	// Verify all expectations were met
@@ -147,7 +241,7 @@ func TestBookingService_CreateBooking_PastEvent(t *testing.T) {
 	// Setup
 	mockBookingRepo := &MockBookingRepository{}
 	mockEventRepo := &MockEventRepository{}
-	service := NewBookingService(mockBookingRepo, mockEventRepo, 15)
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
 
 	userID := uuid.New()
 	eventID := uuid.New()
@@ -158,7 +252,9 @@ func TestBookingService_CreateBooking_PastEvent(t *testing.T) {
 		Name:         "Past Event",
 		DateTime:     time.Now().Add(-24 * time.Hour), // Past event
 		TotalTickets: 100,
-		TicketPrice:  50.0,
+		Currency:     "USD",
+		TicketPrice:  usd(5000),
+		TicketTypes:  generalAdmission(usd(5000), 100),
 	}
 
 	// Mock expectations
@@ -166,12 +262,11 @@ func TestBookingService_CreateBooking_PastEvent(t *testing.T) {
 
 	// Test
 	req := &models.CreateBookingRequest{
-		UserID:   userID.String(),
 		EventID:  eventID.String(),
 		Quantity: 2,
 	}
 
-	booking, err := service.CreateBooking(req)
+	booking, err := service.CreateBooking(context.Background(), userID, req)
 
 	// Assertions
 	assert.Error(t, err)
@@ -183,11 +278,47 @@ func TestBookingService_CreateBooking_PastEvent(t *testing.T) {
 	mockBookingRepo.AssertExpectations(t)
 }
 
+func TestBookingService_CreateBooking_ContextCancelled(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+
+	eventID := uuid.New()
+	event := &models.Event{
+		ID:           eventID,
+		Name:         "Test Event",
+		DateTime:     time.Now().Add(24 * time.Hour),
+		TotalTickets: 100,
+		Currency:     "USD",
+		TicketPrice:  usd(5000),
+		TicketTypes:  generalAdmission(usd(5000), 100),
+	}
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", eventID).Return(event, nil)
+
+	// Test
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	booking, err := service.CreateBooking(ctx, uuid.New(), &models.CreateBookingRequest{
+		EventID:  eventID.String(),
+		Quantity: 2,
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, booking)
+	mockEventRepo.AssertNotCalled(t, "ReserveTickets", mock.Anything, mock.Anything)
+	mockBookingRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
 func TestBookingService_CreateBooking_InsufficientTickets(t *testing.T) {
 	// Setup
 	mockBookingRepo := &MockBookingRepository{}
 	mockEventRepo := &MockEventRepository{}
-	service := NewBookingService(mockBookingRepo, mockEventRepo, 15)
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
 
 	userID := uuid.New()
 	eventID := uuid.New()
@@ -198,21 +329,22 @@ func TestBookingService_CreateBooking_InsufficientTickets(t *testing.T) {
 		Name:         "Test Event",
 		DateTime:     time.Now().Add(24 * time.Hour),
 		TotalTickets: 100,
-		TicketPrice:  50.0,
+		Currency:     "USD",
+		TicketPrice:  usd(5000),
+		TicketTypes:  generalAdmission(usd(5000), 100),
 	}
 
 	// Mock expectations
 	mockEventRepo.On("GetByID", eventID).Return(event, nil)
-	mockEventRepo.On("ReserveTickets", eventID, 150).Return(assert.AnError) // Simulate insufficient tickets
+	mockEventRepo.On("ReserveTickets", eventID, singleItem(event, 150)).Return(assert.AnError) // Simulate insufficient tickets
 
 	// Test
 	req := &models.CreateBookingRequest{
-		UserID:   userID.String(),
 		EventID:  eventID.String(),
 		Quantity: 150, // More than available
 	}
 
-	booking, err := service.CreateBooking(req)
+	booking, err := service.CreateBooking(context.Background(), userID, req)
 
 	// Assertions
 	assert.Error(t, err)
@@ -228,7 +360,7 @@ func TestBookingService_CancelBooking_Success(t *testing.T) {
 	// Setup
 	mockBookingRepo := &MockBookingRepository{}
 	mockEventRepo := &MockEventRepository{}
-	service := NewBookingService(mockBookingRepo, mockEventRepo, 15)
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
 
 	bookingID := uuid.New()
 	userID := uuid.New()
@@ -244,10 +376,10 @@ func TestBookingService_CancelBooking_Success(t *testing.T) {
 
 	// Mock expectations
 	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
-	mockBookingRepo.On("UpdateStatus", bookingID, models.BookingStatusCancelled).Return(nil)
+	mockBookingRepo.On("UpdatePendingStatus", bookingID, models.BookingStatusCancelled).Return(nil)
 
 	// Test
-	err := service.CancelBooking(bookingID)
+	err := service.CancelBooking(context.Background(), bookingID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
 
 	// Assertions
 	assert.NoError(t, err)
@@ -260,7 +392,7 @@ func TestBookingService_CancelBooking_NotPending(t *testing.T) {
 	// Setup
 	mockBookingRepo := &MockBookingRepository{}
 	mockEventRepo := &MockEventRepository{}
-	service := NewBookingService(mockBookingRepo, mockEventRepo, 15)
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
 
 	bookingID := uuid.New()
 	userID := uuid.New()
@@ -278,12 +410,89 @@ func TestBookingService_CancelBooking_NotPending(t *testing.T) {
 	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
 
 	// Test
-	err := service.CancelBooking(bookingID)
+	err := service.CancelBooking(context.Background(), bookingID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
 
 	// Assertions
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidStatus)
 	assert.Contains(t, err.Error(), "only pending bookings can be cancelled")
 
 	// Verify expectations
 	mockBookingRepo.AssertExpectations(t)
 }
+
+func TestBookingService_CancelBooking_PaidMeanwhile(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	listener := &MockReleaseListener{}
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+	service.SetReleaseListener(listener)
+
+	userID := uuid.New()
+	booking := &models.Booking{ID: uuid.New(), UserID: userID, EventID: uuid.New(), Status: models.BookingStatusPending}
+
+	// Mock expectations: the payment is confirmed between the read and the update
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockBookingRepo.On("UpdatePendingStatus", booking.ID, models.BookingStatusCancelled).Return(repository.ErrBookingNotPending)
+
+	// Test
+	err := service.CancelBooking(context.Background(), booking.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+	mockBookingRepo.AssertExpectations(t)
+	listener.AssertNotCalled(t, "TicketsReleased", mock.Anything)
+}
+
+func TestBookingService_GetBooking_OtherUser(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+
+	bookingID := uuid.New()
+	booking := &models.Booking{
+		ID:     bookingID,
+		UserID: uuid.New(),
+		Status: models.BookingStatusPending,
+	}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
+
+	// Test: another customer is refused, an admin is allowed
+	_, err := service.GetBooking(context.Background(), bookingID, &models.Caller{UserID: uuid.New(), Role: models.RoleCustomer})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	got, err := service.GetBooking(context.Background(), bookingID, &models.Caller{UserID: uuid.New(), Role: models.RoleAdmin})
+	assert.NoError(t, err)
+	assert.Equal(t, booking, got)
+
+	// Verify expectations
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestBookingService_CancelBooking_OtherUser(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+
+	bookingID := uuid.New()
+	booking := &models.Booking{
+		ID:     bookingID,
+		UserID: uuid.New(),
+		Status: models.BookingStatusPending,
+	}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
+
+	// Test
+	err := service.CancelBooking(context.Background(), bookingID, &models.Caller{UserID: uuid.New(), Role: models.RoleCustomer})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrForbidden)
+	mockBookingRepo.AssertExpectations(t)
+	mockBookingRepo.AssertNotCalled(t, "UpdatePendingStatus", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// checkInBundleVersion prefixes signed check-in bundles
+const checkInBundleVersion = "QTB1"
+
+// ErrInvalidCheckInBundle is returned for check-in bundles that are malformed
+// or were not signed by this system
+var ErrInvalidCheckInBundle = errors.New("invalid check-in bundle")
+
+// OfflineBundle is what a gate device needs to check tickets in without a
+// connection: the event's valid tickets as of GeneratedAt. Both lists are
+// sorted by ID, so a device can binary search them.
+type OfflineBundle struct {
+	EventID     uuid.UUID
+	GeneratedAt time.Time
+	Pending     []uuid.UUID // valid and not yet checked in
+	CheckedIn   []uuid.UUID
+}
+
+// bundleClaims is the signed form of an OfflineBundle. Ticket IDs are packed
+// as their 16 raw bytes each, in base64url, which is less than half the size
+// of a JSON array of UUID strings.
+type bundleClaims struct {
+	EventID     uuid.UUID `json:"eid"`
+	GeneratedAt int64     `json:"iat"` // unix seconds
+	Pending     string    `json:"pending"`
+	CheckedIn   string    `json:"checked_in"`
+}
+
+// SignBundle returns a signed check-in bundle, in the form
+// QTB1.<base64url claims>.<base64url signature>
+func (s *TicketSigner) SignBundle(bundle *OfflineBundle) (string, error) {
+	return s.sign(checkInBundleVersion, bundleClaims{
+		EventID:     bundle.EventID,
+		GeneratedAt: bundle.GeneratedAt.Unix(),
+		Pending:     packTicketIDs(bundle.Pending),
+		CheckedIn:   packTicketIDs(bundle.CheckedIn),
+	})
+}
+
+// VerifyCheckInBundle checks a signed check-in bundle against publicKey and
+// returns its contents
+func VerifyCheckInBundle(publicKey ed25519.PublicKey, token string) (*OfflineBundle, error) {
+	claims := &bundleClaims{}
+	if !verify(publicKey, checkInBundleVersion, token, claims) {
+		return nil, ErrInvalidCheckInBundle
+	}
+
+	pending, err := unpackTicketIDs(claims.Pending)
+	if err != nil {
+		return nil, err
+	}
+	checkedIn, err := unpackTicketIDs(claims.CheckedIn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OfflineBundle{
+		EventID:     claims.EventID,
+		GeneratedAt: time.Unix(claims.GeneratedAt, 0),
+		Pending:     pending,
+		CheckedIn:   checkedIn,
+	}, nil
+}
+
+func packTicketIDs(ids []uuid.UUID) string {
+	packed := make([]byte, 0, len(ids)*16)
+	for _, id := range ids {
+		packed = append(packed, id[:]...)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(packed)
+}
+
+func unpackTicketIDs(packed string) ([]uuid.UUID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(packed)
+	if err != nil || len(data)%16 != 0 {
+		return nil, ErrInvalidCheckInBundle
+	}
+
+	ids := make([]uuid.UUID, len(data)/16)
+	for i := range ids {
+		copy(ids[i][:], data[i*16:])
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// scannerTokenPrefix makes scanner tokens recognisable, e.g. in leaked logs
+const scannerTokenPrefix = "scn_"
+
+// ErrInvalidScannerToken is returned for scanner tokens that were never issued
+// or whose device has been revoked
+var ErrInvalidScannerToken = errors.New("invalid or revoked scanner token")
+
+// scanMessages are shown to door staff for each scan result
+var scanMessages = map[models.ScanResult]string{
+	models.ScanResultAdmitted:   "Ticket checked in",
+	models.ScanResultDuplicate:  "Ticket has already been checked in",
+	models.ScanResultRevoked:    "Ticket has been revoked",
+	models.ScanResultWrongEvent: "Ticket is for another event",
+	models.ScanResultInvalid:    "Ticket is not valid",
+}
+
+type CheckInService struct {
+	ticketRepo  repository.TicketRepositoryInterface
+	scannerRepo repository.ScannerRepositoryInterface
+	scanRepo    repository.ScanRepositoryInterface
+	eventRepo   repository.EventRepositoryInterface
+	uow         repository.UnitOfWorkInterface
+	signer      *TicketSigner
+}
+
+func NewCheckInService(
+	ticketRepo repository.TicketRepositoryInterface,
+	scannerRepo repository.ScannerRepositoryInterface,
+	scanRepo repository.ScanRepositoryInterface,
+	eventRepo repository.EventRepositoryInterface,
+	uow repository.UnitOfWorkInterface,
+	signer *TicketSigner,
+) *CheckInService {
+	return &CheckInService{
+		ticketRepo:  ticketRepo,
+		scannerRepo: scannerRepo,
+		scanRepo:    scanRepo,
+		eventRepo:   eventRepo,
+		uow:         uow,
+		signer:      signer,
+	}
+}
+
+// checkOrganizer checks that caller may manage the check-in of eventID
+func (s *CheckInService) checkOrganizer(ctx context.Context, eventID uuid.UUID, caller *models.Caller) error {
+	event, err := s.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return err
+	}
+
+	return checkEventOrganizer(caller, event)
+}
+
+// RegisterScanner registers a scanner device for one of the gates of an event
+// organized by caller. The returned device carries its token, which cannot be
+// read again.
+func (s *CheckInService) RegisterScanner(ctx context.Context, eventID uuid.UUID, caller *models.Caller, req *models.RegisterScannerRequest) (*models.ScannerDevice, error) {
+	if err := s.checkOrganizer(ctx, eventID, caller); err != nil {
+		return nil, err
+	}
+
+	token, err := newScannerToken()
+	if err != nil {
+		return nil, err
+	}
+
+	device := &models.ScannerDevice{
+		EventID: eventID,
+		Name:    req.Name,
+		Gate:    req.Gate,
+	}
+	if err := s.scannerRepo.Create(ctx, device, hashScannerToken(token)); err != nil {
+		return nil, err
+	}
+
+	device.Token = token
+	return device, nil
+}
+
+// newScannerToken returns a random scanner token
+func newScannerToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate scanner token: %w", err)
+	}
+
+	return scannerTokenPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashScannerToken returns the hash a scanner token is stored and looked up by
+func hashScannerToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func (s *CheckInService) GetScanners(ctx context.Context, eventID uuid.UUID, caller *models.Caller) ([]*models.ScannerDevice, error) {
+	if err := s.checkOrganizer(ctx, eventID, caller); err != nil {
+		return nil, err
+	}
+
+	return s.scannerRepo.GetByEventID(ctx, eventID)
+}
+
+func (s *CheckInService) RevokeScanner(ctx context.Context, eventID, id uuid.UUID, caller *models.Caller) error {
+	if err := s.checkOrganizer(ctx, eventID, caller); err != nil {
+		return err
+	}
+
+	return s.scannerRepo.Revoke(ctx, id, eventID)
+}
+
+// AuthenticateScanner returns the active device a scanner token belongs to
+func (s *CheckInService) AuthenticateScanner(ctx context.Context, token string) (*models.ScannerDevice, error) {
+	device, err := s.scannerRepo.GetByTokenHash(ctx, hashScannerToken(token))
+	if err != nil {
+		return nil, ErrInvalidScannerToken
+	}
+
+	return device, nil
+}
+
+// GetScans returns one page of the event's scan log, newest first
+func (s *CheckInService) GetScans(ctx context.Context, eventID uuid.UUID, caller *models.Caller, filter *models.ScanFilter) (*models.Page[*models.TicketScan], error) {
+	if err := s.checkOrganizer(ctx, eventID, caller); err != nil {
+		return nil, err
+	}
+
+	return s.scanRepo.GetByEventID(ctx, eventID, filter)
+}
+
+// CheckIn checks in the ticket whose signed payload the device scanned and
+// logs the scan. A ticket is admitted once: the check-in is a conditional
+// update, so when the same ticket is scanned twice at once, at the same or
+// different gates, one scan is admitted and the other is a duplicate.
+// Rejected scans are results rather than errors.
+func (s *CheckInService) CheckIn(ctx context.Context, eventID uuid.UUID, device *models.ScannerDevice, req *models.CheckInRequest) (*models.CheckInResult, error) {
+	if device.EventID != eventID {
+		return nil, ErrForbidden
+	}
+
+	result := &models.CheckInResult{
+		Scan: &models.TicketScan{
+			EventID:   eventID,
+			DeviceID:  device.ID,
+			Gate:      device.Gate,
+			ScannedAt: time.Now(),
+		},
+	}
+
+	claims, verifyErr := s.signer.Verify(req.Payload)
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		switch {
+		case verifyErr != nil:
+			result.Scan.Result = models.ScanResultInvalid
+		case claims.EventID != eventID:
+			result.Scan.Result = models.ScanResultWrongEvent
+		default:
+			ticket, err := checkInTicket(ctx, repos, result.Scan, claims.TicketID)
+			if err != nil {
+				return err
+			}
+			result.Ticket = ticket
+		}
+
+		return repos.Scans.Create(ctx, result.Scan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Result = result.Scan.Result
+	result.Message = scanMessages[result.Result]
+	return result, nil
+}
+
+// checkInTicket checks in a ticket at the time and device of scan and sets the
+// scan's result. It returns the ticket, or nil if it does not exist.
+func checkInTicket(ctx context.Context, repos *repository.Repositories, scan *models.TicketScan, ticketID uuid.UUID) (*models.Ticket, error) {
+	admitted, err := repos.Tickets.CheckIn(ctx, ticketID, scan.EventID, scan.DeviceID, scan.ScannedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := repos.Tickets.GetByID(ctx, ticketID)
+	if errors.Is(err, repository.ErrTicketNotFound) {
+		scan.TicketID = nil
+		scan.Result = models.ScanResultInvalid
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scan.TicketID = &ticket.ID
+
+	switch {
+	case admitted:
+		scan.Result = models.ScanResultAdmitted
+	case ticket.EventID != scan.EventID:
+		scan.Result = models.ScanResultWrongEvent
+	case ticket.Status != models.TicketStatusValid:
+		scan.Result = models.ScanResultRevoked
+	default:
+		scan.Result = models.ScanResultDuplicate
+	}
+
+	return ticket, nil
+}
+
+// GetBundle returns a signed bundle of the event's valid tickets, for the
+// device to check tickets in with while offline
+func (s *CheckInService) GetBundle(ctx context.Context, eventID uuid.UUID, device *models.ScannerDevice) (*models.CheckInBundle, error) {
+	if device.EventID != eventID {
+		return nil, ErrForbidden
+	}
+
+	pending, checkedIn, err := s.ticketRepo.GetValidIDs(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &OfflineBundle{
+		EventID:     eventID,
+		GeneratedAt: time.Now().Truncate(time.Second),
+		Pending:     pending,
+		CheckedIn:   checkedIn,
+	}
+
+	signed, err := s.signer.SignBundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CheckInBundle{
+		EventID:     eventID,
+		GeneratedAt: bundle.GeneratedAt,
+		Tickets:     len(pending) + len(checkedIn),
+		PublicKey:   base64.RawURLEncoding.EncodeToString(s.signer.PublicKey()),
+		Bundle:      signed,
+	}, nil
+}
+
+// SyncScans reconciles the scans a device made offline with the server's
+// check-ins, in the order they were scanned. A scan the device admitted checks
+// its ticket in as of the time of the scan; if the server does not accept it,
+// for instance because another gate already checked the ticket in, the scan is
+// logged as a conflict. Scans that were already uploaded are returned as they
+// were logged, so an upload can be retried.
+func (s *CheckInService) SyncScans(ctx context.Context, eventID uuid.UUID, device *models.ScannerDevice, req *models.SyncScansRequest) (*models.SyncScansResult, error) {
+	if device.EventID != eventID {
+		return nil, ErrForbidden
+	}
+
+	offline := make([]models.OfflineScan, len(req.Scans))
+	copy(offline, req.Scans)
+	sort.SliceStable(offline, func(i, j int) bool {
+		return offline[i].ScannedAt.Before(offline[j].ScannedAt)
+	})
+
+	clientScanIDs := make([]uuid.UUID, len(offline))
+	for i, scan := range offline {
+		clientScanIDs[i] = uuid.MustParse(scan.ID)
+	}
+
+	result := &models.SyncScansResult{Scans: make([]*models.TicketScan, 0, len(offline))}
+	syncedAt := time.Now()
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		synced, err := repos.Scans.GetByClientScanIDs(ctx, device.ID, clientScanIDs)
+		if err != nil {
+			return err
+		}
+
+		alreadySynced := make(map[uuid.UUID]*models.TicketScan, len(synced))
+		for _, scan := range synced {
+			alreadySynced[*scan.ClientScanID] = scan
+		}
+
+		for i, offlineScan := range offline {
+			scan, ok := alreadySynced[clientScanIDs[i]]
+			if !ok {
+				scan, err = syncScan(ctx, repos, device, offlineScan, clientScanIDs[i], syncedAt)
+				if err != nil {
+					return err
+				}
+				alreadySynced[clientScanIDs[i]] = scan
+			}
+
+			result.Scans = append(result.Scans, scan)
+			if scan.Conflicting() {
+				result.Conflicts++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// syncScan logs one offline scan that has not been uploaded before. A scan the
+// device rejected is logged as it is.
+func syncScan(ctx context.Context, repos *repository.Repositories, device *models.ScannerDevice, offline models.OfflineScan, clientScanID uuid.UUID, syncedAt time.Time) (*models.TicketScan, error) {
+	deviceResult := offline.Result
+	scan := &models.TicketScan{
+		EventID:      device.EventID,
+		DeviceID:     device.ID,
+		Gate:         device.Gate,
+		Result:       deviceResult,
+		ClientScanID: &clientScanID,
+		DeviceResult: &deviceResult,
+		ScannedAt:    offline.ScannedAt,
+		SyncedAt:     &syncedAt,
+	}
+
+	if offline.TicketID != "" {
+		ticketID := uuid.MustParse(offline.TicketID)
+		scan.TicketID = &ticketID
+	}
+
+	if deviceResult == models.ScanResultAdmitted {
+		if scan.TicketID == nil {
+			scan.Result = models.ScanResultInvalid
+		} else if _, err := checkInTicket(ctx, repos, scan, *scan.TicketID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := repos.Scans.Create(ctx, scan); err != nil {
+		return nil, err
+	}
+
+	return scan, nil
+}
+
+// GetConflicts returns one page of the event's conflicting offline scans,
+// newest first
+func (s *CheckInService) GetConflicts(ctx context.Context, eventID uuid.UUID, caller *models.Caller, page *models.PageRequest) (*models.Page[*models.CheckInConflict], error) {
+	if err := s.checkOrganizer(ctx, eventID, caller); err != nil {
+		return nil, err
+	}
+
+	return s.scanRepo.GetConflicts(ctx, eventID, page)
+}
@@ -0,0 +1,449 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockScannerRepository struct {
+	mock.Mock
+}
+
+func (m *MockScannerRepository) Create(ctx context.Context, device *models.ScannerDevice, tokenHash string) error {
+	args := m.Called(device, tokenHash)
+	return args.Error(0)
+}
+
+func (m *MockScannerRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.ScannerDevice, error) {
+	args := m.Called(tokenHash)
+	device, _ := args.Get(0).(*models.ScannerDevice)
+	return device, args.Error(1)
+}
+
+func (m *MockScannerRepository) GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*models.ScannerDevice, error) {
+	args := m.Called(eventID)
+	return args.Get(0).([]*models.ScannerDevice), args.Error(1)
+}
+
+func (m *MockScannerRepository) Revoke(ctx context.Context, id, eventID uuid.UUID) error {
+	args := m.Called(id, eventID)
+	return args.Error(0)
+}
+
+type MockScanRepository struct {
+	mock.Mock
+}
+
+func (m *MockScanRepository) Create(ctx context.Context, scan *models.TicketScan) error {
+	args := m.Called(scan)
+	return args.Error(0)
+}
+
+func (m *MockScanRepository) GetByClientScanIDs(ctx context.Context, deviceID uuid.UUID, clientScanIDs []uuid.UUID) ([]*models.TicketScan, error) {
+	args := m.Called(deviceID, clientScanIDs)
+	return args.Get(0).([]*models.TicketScan), args.Error(1)
+}
+
+func (m *MockScanRepository) GetConflicts(ctx context.Context, eventID uuid.UUID, page *models.PageRequest) (*models.Page[*models.CheckInConflict], error) {
+	args := m.Called(eventID, page)
+	conflicts, _ := args.Get(0).(*models.Page[*models.CheckInConflict])
+	return conflicts, args.Error(1)
+}
+
+func (m *MockScanRepository) GetByEventID(ctx context.Context, eventID uuid.UUID, filter *models.ScanFilter) (*models.Page[*models.TicketScan], error) {
+	args := m.Called(eventID, filter)
+	page, _ := args.Get(0).(*models.Page[*models.TicketScan])
+	return page, args.Error(1)
+}
+
+type checkInTestEnv struct {
+	service     *CheckInService
+	scannerRepo *MockScannerRepository
+	scanRepo    *MockScanRepository
+	ticketRepo  *MockTicketRepository
+	eventRepo   *MockEventRepository
+	device      *models.ScannerDevice
+}
+
+func newCheckInTestEnv() *checkInTestEnv {
+	env := &checkInTestEnv{
+		scannerRepo: &MockScannerRepository{},
+		scanRepo:    &MockScanRepository{},
+		ticketRepo:  &MockTicketRepository{},
+		eventRepo:   &MockEventRepository{},
+		device:      &models.ScannerDevice{ID: uuid.New(), EventID: uuid.New(), Name: "Handheld 1", Gate: "North"},
+	}
+
+	uow := newMockUnitOfWork(&MockBookingRepository{}, env.eventRepo)
+	uow.repos.Tickets = env.ticketRepo
+	uow.repos.Scans = env.scanRepo
+
+	env.service = NewCheckInService(env.ticketRepo, env.scannerRepo, env.scanRepo, env.eventRepo, uow, NewTicketSigner("test-secret"))
+	return env
+}
+
+// scan signs ticket and checks it in at the test device
+func (env *checkInTestEnv) scan(t *testing.T, ticket *models.Ticket) (*models.CheckInResult, error) {
+	payload, err := env.service.signer.Sign(ticket)
+	require.NoError(t, err)
+
+	return env.service.CheckIn(context.Background(), env.device.EventID, env.device, &models.CheckInRequest{Payload: payload})
+}
+
+// expectScan expects a scan with the given result to be logged at the test
+// device
+func (env *checkInTestEnv) expectScan(result models.ScanResult) {
+	env.scanRepo.On("Create", mock.MatchedBy(func(scan *models.TicketScan) bool {
+		return scan.Result == result && scan.DeviceID == env.device.ID && scan.Gate == env.device.Gate
+	})).Return(nil).Once()
+}
+
+func TestCheckInService_CheckIn_Admitted(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+	ticket := newTicket(&models.Booking{ID: uuid.New(), EventID: env.device.EventID}, 1)
+
+	// Mock expectations
+	env.ticketRepo.On("CheckIn", ticket.ID, env.device.EventID, env.device.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+	env.ticketRepo.On("GetByID", ticket.ID).Return(ticket, nil)
+	env.expectScan(models.ScanResultAdmitted)
+
+	// Test
+	result, err := env.scan(t, ticket)
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, models.ScanResultAdmitted, result.Result)
+	assert.Equal(t, models.ScanResultAdmitted, result.Scan.Result)
+	assert.Equal(t, &ticket.ID, result.Scan.TicketID)
+	assert.Equal(t, ticket, result.Ticket)
+	assert.NotEmpty(t, result.Message)
+	env.scanRepo.AssertExpectations(t)
+}
+
+func TestCheckInService_CheckIn_Rejected(t *testing.T) {
+	eventID := uuid.New()
+
+	revoked := newTicket(&models.Booking{ID: uuid.New(), EventID: eventID}, 1)
+	revoked.Status = models.TicketStatusRevoked
+
+	tests := []struct {
+		name     string
+		ticket   *models.Ticket
+		err      error
+		expected models.ScanResult
+	}{
+		{"already checked in", newTicket(&models.Booking{ID: uuid.New(), EventID: eventID}, 1), nil, models.ScanResultDuplicate},
+		{"revoked ticket", revoked, nil, models.ScanResultRevoked},
+		{"unknown ticket", newTicket(&models.Booking{ID: uuid.New(), EventID: eventID}, 1), repository.ErrTicketNotFound, models.ScanResultInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			env := newCheckInTestEnv()
+			env.device.EventID = eventID
+			stored := tt.ticket
+			if tt.err != nil {
+				stored = nil
+			}
+
+			// Mock expectations
+			env.ticketRepo.On("CheckIn", tt.ticket.ID, eventID, env.device.ID, mock.AnythingOfType("time.Time")).Return(false, nil)
+			env.ticketRepo.On("GetByID", tt.ticket.ID).Return(stored, tt.err)
+			env.expectScan(tt.expected)
+
+			// Test
+			result, err := env.scan(t, tt.ticket)
+
+			// Assertions
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.Scan.Result)
+			env.scanRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCheckInService_CheckIn_WrongEventIsNotCheckedIn(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+	ticket := newTicket(&models.Booking{ID: uuid.New(), EventID: uuid.New()}, 1)
+
+	// Mock expectations
+	env.expectScan(models.ScanResultWrongEvent)
+
+	// Test
+	result, err := env.scan(t, ticket)
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, models.ScanResultWrongEvent, result.Scan.Result)
+	env.ticketRepo.AssertNotCalled(t, "CheckIn", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	env.scanRepo.AssertExpectations(t)
+}
+
+func TestCheckInService_CheckIn_InvalidSignatureIsLogged(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+	forged, err := NewTicketSigner("other-secret").Sign(newTicket(&models.Booking{ID: uuid.New(), EventID: env.device.EventID}, 1))
+	require.NoError(t, err)
+
+	// Mock expectations
+	env.expectScan(models.ScanResultInvalid)
+
+	// Test
+	result, err := env.service.CheckIn(context.Background(), env.device.EventID, env.device, &models.CheckInRequest{Payload: forged})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, models.ScanResultInvalid, result.Scan.Result)
+	assert.Nil(t, result.Scan.TicketID)
+	env.ticketRepo.AssertNotCalled(t, "CheckIn", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	env.scanRepo.AssertExpectations(t)
+}
+
+func TestCheckInService_CheckIn_DeviceForAnotherEvent(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+
+	// Test
+	result, err := env.service.CheckIn(context.Background(), uuid.New(), env.device, &models.CheckInRequest{Payload: "QTX1.a.b"})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, result)
+	env.scanRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCheckInService_RegisterScanner(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+	eventID := uuid.New()
+	var storedHash string
+
+	// Mock expectations
+	env.eventRepo.On("GetByID", eventID).Return(&models.Event{ID: eventID, OrganizerID: &testOrganizer.UserID}, nil)
+	env.scannerRepo.On("Create", mock.AnythingOfType("*models.ScannerDevice"), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { storedHash = args.String(1) }).
+		Return(nil)
+
+	// Test
+	device, err := env.service.RegisterScanner(context.Background(), eventID, testOrganizer, &models.RegisterScannerRequest{Name: "Handheld 2", Gate: "South"})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(device.Token, scannerTokenPrefix))
+	assert.Equal(t, hashScannerToken(device.Token), storedHash)
+	assert.NotContains(t, storedHash, device.Token)
+	assert.Equal(t, "South", device.Gate)
+}
+
+func TestCheckInService_RegisterScanner_OtherOrganizer(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+	eventID := uuid.New()
+	other := &models.Caller{UserID: uuid.New(), Role: models.RoleOrganizer}
+
+	// Mock expectations
+	env.eventRepo.On("GetByID", eventID).Return(&models.Event{ID: eventID, OrganizerID: &testOrganizer.UserID}, nil)
+
+	// Test
+	_, err := env.service.RegisterScanner(context.Background(), eventID, other, &models.RegisterScannerRequest{Name: "Handheld 2", Gate: "South"})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrForbidden)
+	env.scannerRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCheckInService_AuthenticateScanner(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+
+	// Mock expectations
+	env.scannerRepo.On("GetByTokenHash", hashScannerToken("scn_good")).Return(env.device, nil)
+	env.scannerRepo.On("GetByTokenHash", hashScannerToken("scn_revoked")).Return(nil, errors.New("scanner not found"))
+
+	// Test
+	device, err := env.service.AuthenticateScanner(context.Background(), "scn_good")
+	_, revokedErr := env.service.AuthenticateScanner(context.Background(), "scn_revoked")
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, env.device, device)
+	assert.ErrorIs(t, revokedErr, ErrInvalidScannerToken)
+}
+
+func TestTicketSigner_SignBundle(t *testing.T) {
+	// Setup
+	signer := NewTicketSigner("test-secret")
+	bundle := &OfflineBundle{
+		EventID:     uuid.New(),
+		GeneratedAt: time.Now().Truncate(time.Second),
+		Pending:     []uuid.UUID{uuid.New(), uuid.New()},
+		CheckedIn:   []uuid.UUID{uuid.New()},
+	}
+
+	// Test
+	token, err := signer.SignBundle(bundle)
+	require.NoError(t, err)
+	verified, err := VerifyCheckInBundle(signer.PublicKey(), token)
+
+	// Assertions
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, "QTB1."))
+	assert.Equal(t, bundle.EventID, verified.EventID)
+	assert.True(t, bundle.GeneratedAt.Equal(verified.GeneratedAt))
+	assert.Equal(t, bundle.Pending, verified.Pending)
+	assert.Equal(t, bundle.CheckedIn, verified.CheckedIn)
+
+	_, err = VerifyCheckInBundle(NewTicketSigner("other-secret").PublicKey(), token)
+	assert.ErrorIs(t, err, ErrInvalidCheckInBundle)
+
+	ticketPayload, err := signer.Sign(newTicket(&models.Booking{ID: uuid.New(), EventID: bundle.EventID}, 1))
+	require.NoError(t, err)
+	_, err = VerifyCheckInBundle(signer.PublicKey(), ticketPayload)
+	assert.ErrorIs(t, err, ErrInvalidCheckInBundle)
+}
+
+func TestCheckInService_GetBundle(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+	pending := []uuid.UUID{uuid.New(), uuid.New()}
+	checkedIn := []uuid.UUID{uuid.New()}
+
+	// Mock expectations
+	env.ticketRepo.On("GetValidIDs", env.device.EventID).Return(pending, checkedIn, nil)
+
+	// Test
+	bundle, err := env.service.GetBundle(context.Background(), env.device.EventID, env.device)
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, 3, bundle.Tickets)
+
+	publicKey, err := base64.RawURLEncoding.DecodeString(bundle.PublicKey)
+	require.NoError(t, err)
+	contents, err := VerifyCheckInBundle(publicKey, bundle.Bundle)
+	require.NoError(t, err)
+	assert.Equal(t, env.device.EventID, contents.EventID)
+	assert.Equal(t, pending, contents.Pending)
+	assert.Equal(t, checkedIn, contents.CheckedIn)
+
+	_, err = env.service.GetBundle(context.Background(), uuid.New(), env.device)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+// offlineScan returns a scan the test device made offline at scannedAt
+func offlineScan(ticketID uuid.UUID, result models.ScanResult, scannedAt time.Time) models.OfflineScan {
+	return models.OfflineScan{ID: uuid.NewString(), TicketID: ticketID.String(), Result: result, ScannedAt: scannedAt}
+}
+
+func TestCheckInService_SyncScans(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+	now := time.Now()
+
+	admitted := newTicket(&models.Booking{ID: uuid.New(), EventID: env.device.EventID}, 1)
+	conflicting := newTicket(&models.Booking{ID: uuid.New(), EventID: env.device.EventID}, 1)
+	rejected := newTicket(&models.Booking{ID: uuid.New(), EventID: env.device.EventID}, 1)
+
+	// Uploaded out of order; the earliest scan is reconciled first
+	req := &models.SyncScansRequest{Scans: []models.OfflineScan{
+		offlineScan(conflicting.ID, models.ScanResultAdmitted, now.Add(-time.Minute)),
+		offlineScan(admitted.ID, models.ScanResultAdmitted, now.Add(-2*time.Minute)),
+		offlineScan(rejected.ID, models.ScanResultDuplicate, now.Add(-30*time.Second)),
+	}}
+
+	// Mock expectations
+	env.scanRepo.On("GetByClientScanIDs", env.device.ID, mock.Anything).Return([]*models.TicketScan{}, nil)
+	env.ticketRepo.On("CheckIn", admitted.ID, env.device.EventID, env.device.ID, req.Scans[1].ScannedAt).Return(true, nil)
+	env.ticketRepo.On("GetByID", admitted.ID).Return(admitted, nil)
+	env.ticketRepo.On("CheckIn", conflicting.ID, env.device.EventID, env.device.ID, req.Scans[0].ScannedAt).Return(false, nil)
+	env.ticketRepo.On("GetByID", conflicting.ID).Return(conflicting, nil)
+	env.scanRepo.On("Create", mock.AnythingOfType("*models.TicketScan")).Return(nil)
+
+	// Test
+	result, err := env.service.SyncScans(context.Background(), env.device.EventID, env.device, req)
+
+	// Assertions
+	require.NoError(t, err)
+	require.Len(t, result.Scans, 3)
+	assert.Equal(t, 1, result.Conflicts)
+
+	assert.Equal(t, &admitted.ID, result.Scans[0].TicketID)
+	assert.Equal(t, models.ScanResultAdmitted, result.Scans[0].Result)
+	assert.False(t, result.Scans[0].Conflicting())
+
+	assert.Equal(t, &conflicting.ID, result.Scans[1].TicketID)
+	assert.Equal(t, models.ScanResultDuplicate, result.Scans[1].Result)
+	assert.True(t, result.Scans[1].Conflicting())
+
+	assert.Equal(t, models.ScanResultDuplicate, result.Scans[2].Result)
+	assert.False(t, result.Scans[2].Conflicting())
+	env.ticketRepo.AssertNotCalled(t, "CheckIn", rejected.ID, mock.Anything, mock.Anything, mock.Anything)
+
+	for _, scan := range result.Scans {
+		assert.Equal(t, env.device.Gate, scan.Gate)
+		assert.NotNil(t, scan.SyncedAt)
+	}
+}
+
+func TestCheckInService_SyncScans_RetriedUpload(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+	ticket := newTicket(&models.Booking{ID: uuid.New(), EventID: env.device.EventID}, 1)
+	offline := offlineScan(ticket.ID, models.ScanResultAdmitted, time.Now())
+
+	clientScanID := uuid.MustParse(offline.ID)
+	deviceResult := models.ScanResultAdmitted
+	synced := &models.TicketScan{
+		ID:           uuid.New(),
+		EventID:      env.device.EventID,
+		DeviceID:     env.device.ID,
+		TicketID:     &ticket.ID,
+		Result:       models.ScanResultAdmitted,
+		ClientScanID: &clientScanID,
+		DeviceResult: &deviceResult,
+	}
+
+	// Mock expectations
+	env.scanRepo.On("GetByClientScanIDs", env.device.ID, []uuid.UUID{clientScanID}).Return([]*models.TicketScan{synced}, nil)
+
+	// Test
+	result, err := env.service.SyncScans(context.Background(), env.device.EventID, env.device, &models.SyncScansRequest{
+		Scans: []models.OfflineScan{offline},
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, []*models.TicketScan{synced}, result.Scans)
+	assert.Zero(t, result.Conflicts)
+	env.ticketRepo.AssertNotCalled(t, "CheckIn", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	env.scanRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCheckInService_SyncScans_DeviceForAnotherEvent(t *testing.T) {
+	// Setup
+	env := newCheckInTestEnv()
+
+	// Test
+	result, err := env.service.SyncScans(context.Background(), uuid.New(), env.device, &models.SyncScansRequest{
+		Scans: []models.OfflineScan{offlineScan(uuid.New(), models.ScanResultAdmitted, time.Now())},
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, result)
+}
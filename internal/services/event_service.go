@@ -1,6 +1,10 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"ticket-booking-system/internal/models"
 	"ticket-booking-system/internal/repository"
 
@@ -9,74 +13,329 @@ import (
 
 type EventService struct {
 	eventRepo repository.EventRepositoryInterface
+	venueRepo repository.VenueRepositoryInterface
+	holdRepo  repository.HoldRepositoryInterface
+	uow       repository.UnitOfWorkInterface
 }
 
-func NewEventService(eventRepo repository.EventRepositoryInterface) *EventService {
+func NewEventService(
+	eventRepo repository.EventRepositoryInterface,
+	venueRepo repository.VenueRepositoryInterface,
+	holdRepo repository.HoldRepositoryInterface,
+	uow repository.UnitOfWorkInterface,
+) *EventService {
 	return &EventService{
 		eventRepo: eventRepo,
+		venueRepo: venueRepo,
+		holdRepo:  holdRepo,
+		uow:       uow,
 	}
 }
 
-func (s *EventService) CreateEvent(req *models.CreateEventRequest) (*models.Event, error) {
+var (
+	// ErrInvalidEvent is returned when an event's tickets are inconsistent,
+	// for example when its ticket types allocate more than its total tickets
+	ErrInvalidEvent = errors.New("invalid event")
+
+	// ErrNotSeated is returned when a seat map is requested for a general
+	// admission event
+	ErrNotSeated = errors.New("event does not have reserved seating")
+)
+
+// defaultTicketTypeName names the single ticket type of events created
+// without ticket types
+const defaultTicketTypeName = "General Admission"
+
+// CreateEvent creates an event organized by caller
+func (s *EventService) CreateEvent(ctx context.Context, caller *models.Caller, req *models.CreateEventRequest) (*models.Event, error) {
 	event := &models.Event{
 		Name:         req.Name,
 		Description:  req.Description,
 		DateTime:     req.DateTime,
 		TotalTickets: req.TotalTickets,
-		TicketPrice:  req.TicketPrice,
+		OrganizerID:  &caller.UserID,
 	}
 
-	err := s.eventRepo.Create(event)
+	// Reserved seating events sell exactly the venue's seats
+	if req.VenueID != nil {
+		venueID, err := uuid.Parse(*req.VenueID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid venue ID: %w", err)
+		}
+
+		seats, err := s.venueRepo.CountSeats(ctx, venueID)
+		if err != nil {
+			return nil, err
+		}
+		if seats == 0 {
+			return nil, fmt.Errorf("%w: venue not found", ErrInvalidEvent)
+		}
+
+		event.VenueID = &venueID
+		event.TotalTickets = seats
+	}
+
+	ticketTypes, err := newTicketTypes(event, req)
 	if err != nil {
 		return nil, err
 	}
 
+	event.CancellationPolicy = models.DefaultCancellationPolicy
+	if req.CancellationPolicy != nil {
+		event.CancellationPolicy = *req.CancellationPolicy
+	}
+
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if err := repos.Events.Create(ctx, event); err != nil {
+			return err
+		}
+
+		for _, ticketType := range ticketTypes {
+			ticketType.EventID = event.ID
+			if err := repos.TicketTypes.Create(ctx, ticketType); err != nil {
+				return fmt.Errorf("failed to create ticket type %q: %w", ticketType.Name, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	event.TicketTypes = ticketTypes
 	return event, nil
 }
 
-func (s *EventService) GetEvent(id uuid.UUID) (*models.Event, error) {
-	return s.eventRepo.GetByID(id)
+// newTicketTypes builds the ticket types of a new event. Without ticket types
+// in the request the event sells a single tier at its ticket price. The
+// event's total tickets default to the sum of the allocations. All prices must
+// share one currency, which becomes the event's, and the event's ticket price
+// is the cheapest tier's.
+func newTicketTypes(event *models.Event, req *models.CreateEventRequest) ([]*models.TicketType, error) {
+	if len(req.TicketTypes) == 0 {
+		if event.TotalTickets == 0 {
+			return nil, fmt.Errorf("%w: total_tickets is required for general admission events", ErrInvalidEvent)
+		}
+		if req.TicketPrice == nil {
+			return nil, fmt.Errorf("%w: ticket_price is required when ticket_types are not given", ErrInvalidEvent)
+		}
+		if err := checkPrice(*req.TicketPrice, req.TicketPrice.Currency); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+
+		event.Currency = req.TicketPrice.Currency
+		event.TicketPrice = *req.TicketPrice
+
+		return []*models.TicketType{{
+			Name:       defaultTicketTypeName,
+			Price:      *req.TicketPrice,
+			Allocation: event.TotalTickets,
+		}}, nil
+	}
+
+	ticketTypes := make([]*models.TicketType, 0, len(req.TicketTypes))
+	names := make(map[string]bool, len(req.TicketTypes))
+	allocated := 0
+	event.Currency = req.TicketTypes[0].Price.Currency
+	for _, ticketTypeReq := range req.TicketTypes {
+		if names[ticketTypeReq.Name] {
+			return nil, fmt.Errorf("%w: duplicate ticket type name %q", ErrInvalidEvent, ticketTypeReq.Name)
+		}
+		if err := checkPrice(ticketTypeReq.Price, event.Currency); err != nil {
+			return nil, fmt.Errorf("%w: ticket type %q: %v", ErrInvalidEvent, ticketTypeReq.Name, err)
+		}
+		names[ticketTypeReq.Name] = true
+		allocated += ticketTypeReq.Allocation
+
+		if ticketTypeReq.Zone != "" && event.VenueID == nil {
+			return nil, fmt.Errorf("%w: ticket type %q: zones can only be used for reserved seating events", ErrInvalidEvent, ticketTypeReq.Name)
+		}
+
+		ticketTypes = append(ticketTypes, &models.TicketType{
+			Name:        ticketTypeReq.Name,
+			Description: ticketTypeReq.Description,
+			Price:       ticketTypeReq.Price,
+			Allocation:  ticketTypeReq.Allocation,
+			Zone:        ticketTypeReq.Zone,
+		})
+	}
+
+	if event.TotalTickets == 0 {
+		event.TotalTickets = allocated
+	}
+	if allocated > event.TotalTickets {
+		return nil, fmt.Errorf("%w: ticket types allocate %d tickets but the event has %d", ErrInvalidEvent, allocated, event.TotalTickets)
+	}
+
+	event.TicketPrice = ticketTypes[0].Price
+	for _, ticketType := range ticketTypes {
+		event.TicketPrice = event.TicketPrice.Min(ticketType.Price)
+	}
+
+	return ticketTypes, nil
+}
+
+// checkPrice validates a ticket price for an event selling in currency
+func checkPrice(price models.Money, currency string) error {
+	if price.Currency == "" {
+		return fmt.Errorf("a price with an amount and currency is required")
+	}
+	if err := models.ValidateCurrency(price.Currency); err != nil {
+		return err
+	}
+	if price.Currency != currency {
+		return fmt.Errorf("prices must be in the event's currency %s, not %s", currency, price.Currency)
+	}
+	if price.IsNegative() {
+		return fmt.Errorf("prices cannot be negative")
+	}
+	return nil
+}
+
+func (s *EventService) GetEvent(ctx context.Context, id uuid.UUID) (*models.Event, error) {
+	return s.eventRepo.GetByID(ctx, id)
 }
 
-func (s *EventService) GetEvents() ([]*models.Event, error) {
-	return s.eventRepo.GetAll()
+func (s *EventService) GetEvents(ctx context.Context, filter *models.EventFilter) (*models.Page[*models.Event], error) {
+	return s.eventRepo.GetAll(ctx, filter)
 }
 
-func (s *EventService) UpdateEvent(id uuid.UUID, req *models.UpdateEventRequest) (*models.Event, error) {
-	event, err := s.eventRepo.GetByID(id)
+// UpdateEvent changes an event organized by caller while holding its row
+// lock, so that its total tickets cannot drop below its ticket types'
+// allocations concurrently
+func (s *EventService) UpdateEvent(ctx context.Context, id uuid.UUID, caller *models.Caller, req *models.UpdateEventRequest) (*models.Event, error) {
+	if req.TicketPrice != nil {
+		return nil, fmt.Errorf("%w: ticket prices are set on ticket types", ErrInvalidEvent)
+	}
+
+	var event *models.Event
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		var err error
+		event, err = repos.Events.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkEventOrganizer(caller, event); err != nil {
+			return err
+		}
+
+		// Update fields if provided
+		if req.Name != nil {
+			event.Name = *req.Name
+		}
+		if req.Description != nil {
+			event.Description = *req.Description
+		}
+		if req.DateTime != nil {
+			event.DateTime = *req.DateTime
+		}
+		if req.TotalTickets != nil {
+			if event.VenueID != nil {
+				return fmt.Errorf("%w: total_tickets of a reserved seating event is set by its venue", ErrInvalidEvent)
+			}
+			if allocated := allocatedTickets(event.TicketTypes); *req.TotalTickets < allocated {
+				return fmt.Errorf("%w: ticket types allocate %d tickets", ErrInvalidEvent, allocated)
+			}
+			event.TotalTickets = *req.TotalTickets
+		}
+		if req.CancellationPolicy != nil {
+			event.CancellationPolicy = *req.CancellationPolicy
+		}
+
+		return repos.Events.Update(ctx, event)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Update fields if provided
-	if req.Name != nil {
-		event.Name = *req.Name
+	return event, nil
+}
+
+// allocatedTickets sums the allocations of an event's ticket types
+func allocatedTickets(ticketTypes []*models.TicketType) int {
+	allocated := 0
+	for _, ticketType := range ticketTypes {
+		allocated += ticketType.Allocation
 	}
-	if req.Description != nil {
-		event.Description = *req.Description
+	return allocated
+}
+
+// checkEventOrganizer checks that caller may manage event: admins may manage
+// every event, organizers only their own
+func checkEventOrganizer(caller *models.Caller, event *models.Event) error {
+	if caller.IsAdmin() {
+		return nil
 	}
-	if req.DateTime != nil {
-		event.DateTime = *req.DateTime
+	if event.OrganizerID == nil || *event.OrganizerID != caller.UserID {
+		return ErrForbidden
 	}
-	if req.TotalTickets != nil {
-		event.TotalTickets = *req.TotalTickets
+
+	return nil
+}
+
+// DeleteEvent deletes an event organized by caller
+func (s *EventService) DeleteEvent(ctx context.Context, id uuid.UUID, caller *models.Caller) error {
+	event, err := s.eventRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
 	}
-	if req.TicketPrice != nil {
-		event.TicketPrice = *req.TicketPrice
+	if err := checkEventOrganizer(caller, event); err != nil {
+		return err
 	}
 
-	err = s.eventRepo.Update(event)
+	return s.eventRepo.Delete(ctx, id)
+}
+
+// GetEventStatistics returns the sales statistics of an event organized by
+// caller
+func (s *EventService) GetEventStatistics(ctx context.Context, id uuid.UUID, caller *models.Caller) (*models.EventStatistics, error) {
+	event, err := s.eventRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkEventOrganizer(caller, event); err != nil {
+		return nil, err
+	}
 
-	return event, nil
+	return s.eventRepo.GetStatistics(ctx, id)
 }
 
-func (s *EventService) DeleteEvent(id uuid.UUID) error {
-	return s.eventRepo.Delete(id)
-}
+// GetSeatMap returns the event venue's seat map with the status of each seat.
+// Seats in live holds are shown as held.
+func (s *EventService) GetSeatMap(ctx context.Context, id uuid.UUID) (*models.SeatMap, error) {
+	event, err := s.eventRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.VenueID == nil {
+		return nil, ErrNotSeated
+	}
+
+	sections, err := s.venueRepo.GetSeatMap(ctx, *event.VenueID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := heldSeats(ctx, s.holdRepo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, section := range sections {
+		for _, row := range section.Rows {
+			for _, seat := range row.Seats {
+				if held[seat.ID] && seat.Status == models.SeatStatusAvailable {
+					seat.Status = models.SeatStatusHeld
+				}
+			}
+		}
+	}
 
-func (s *EventService) GetEventStatistics(id uuid.UUID) (*models.EventStatistics, error) {
-	return s.eventRepo.GetStatistics(id)
+	return &models.SeatMap{
+		EventID:  id,
+		VenueID:  *event.VenueID,
+		Sections: sections,
+	}, nil
 }
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ticket-booking-system/internal/models"
+)
+
+// Heartbeat records when a background worker last went round its loop, so a
+// worker that has stalled or died can be told apart from one that is idle
+type Heartbeat struct {
+	last atomic.Int64 // Unix nanoseconds, 0 before the first beat
+}
+
+// Beat records that the worker is alive
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Last returns when the worker last beat, or the zero time if it never has
+func (h *Heartbeat) Last() time.Time {
+	last := h.last.Load()
+	if last == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, last)
+}
+
+// HealthCheckFunc checks a dependency and returns why it is unhealthy. It may
+// fill in details of check, such as the migration version; the status and
+// latency are set by the HealthService.
+type HealthCheckFunc func(ctx context.Context, check *models.HealthCheck) error
+
+type healthCheck struct {
+	name     string
+	critical bool
+	fn       HealthCheckFunc
+}
+
+// HealthService checks the service's dependencies and background workers for
+// the readiness probe
+type HealthService struct {
+	timeout time.Duration
+	checks  []healthCheck
+}
+
+// NewHealthService returns a service that gives each check timeout to
+// complete
+func NewHealthService(timeout time.Duration) *HealthService {
+	return &HealthService{timeout: timeout}
+}
+
+// AddCheck registers a check. The service is reported down while a critical
+// check fails, and degraded while any other check fails.
+func (s *HealthService) AddCheck(name string, critical bool, fn HealthCheckFunc) {
+	s.checks = append(s.checks, healthCheck{name: name, critical: critical, fn: fn})
+}
+
+// AddHeartbeat registers a non-critical check that fails once heartbeat has
+// not beaten for maxAge
+func (s *HealthService) AddHeartbeat(name string, heartbeat *Heartbeat, maxAge time.Duration) {
+	s.AddCheck(name, false, func(ctx context.Context, check *models.HealthCheck) error {
+		last := heartbeat.Last()
+		if last.IsZero() {
+			return fmt.Errorf("no heartbeat yet")
+		}
+
+		check.LastHeartbeat = &last
+		if age := time.Since(last); age > maxAge {
+			return fmt.Errorf("no heartbeat for %s", age.Round(time.Second))
+		}
+		return nil
+	})
+}
+
+// MigrationCheck reports the schema version returned by version, and fails if
+// the last migration left the schema dirty
+func MigrationCheck(version func(ctx context.Context) (uint, bool, error)) HealthCheckFunc {
+	return func(ctx context.Context, check *models.HealthCheck) error {
+		current, dirty, err := version(ctx)
+		if err != nil {
+			return err
+		}
+
+		check.MigrationVersion = &current
+		if dirty {
+			return fmt.Errorf("migration %d failed and left the schema dirty", current)
+		}
+		return nil
+	}
+}
+
+// Check runs every check at once, each bounded by the timeout, and reports
+// their outcomes
+func (s *HealthService) Check(ctx context.Context) *models.HealthReport {
+	report := &models.HealthReport{
+		Status: models.HealthStatusUp,
+		Checks: make(map[string]*models.HealthCheck, len(s.checks)),
+	}
+
+	results := make([]*models.HealthCheck, len(s.checks))
+	var wg sync.WaitGroup
+	for i, c := range s.checks {
+		wg.Add(1)
+		go func(i int, c healthCheck) {
+			defer wg.Done()
+			results[i] = s.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for i, c := range s.checks {
+		result := results[i]
+		report.Checks[c.name] = result
+
+		if result.Status == models.HealthStatusUp {
+			continue
+		}
+		if c.critical {
+			report.Status = models.HealthStatusDown
+		} else if report.Status == models.HealthStatusUp {
+			report.Status = models.HealthStatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (s *HealthService) run(ctx context.Context, c healthCheck) *models.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	result := &models.HealthCheck{
+		Status:   models.HealthStatusUp,
+		Critical: c.critical,
+	}
+
+	start := time.Now()
+	err := c.fn(ctx, result)
+	result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		result.Status = models.HealthStatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func healthy(context.Context, *models.HealthCheck) error { return nil }
+
+func TestHealthService_AllUp(t *testing.T) {
+	// Setup
+	service := NewHealthService(time.Second)
+	service.AddCheck("postgres", true, healthy)
+	service.AddCheck("redis", true, healthy)
+
+	// Test
+	report := service.Check(context.Background())
+
+	// Assertions
+	assert.Equal(t, models.HealthStatusUp, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, models.HealthStatusUp, report.Checks["postgres"].Status)
+	assert.True(t, report.Checks["postgres"].Critical)
+	assert.Empty(t, report.Checks["postgres"].Error)
+}
+
+func TestHealthService_CriticalDown(t *testing.T) {
+	// Setup
+	service := NewHealthService(time.Second)
+	service.AddCheck("postgres", true, func(context.Context, *models.HealthCheck) error {
+		return errors.New("connection refused")
+	})
+	service.AddCheck("redis", true, healthy)
+
+	// Test
+	report := service.Check(context.Background())
+
+	// Assertions
+	assert.Equal(t, models.HealthStatusDown, report.Status)
+	assert.Equal(t, models.HealthStatusDown, report.Checks["postgres"].Status)
+	assert.Equal(t, "connection refused", report.Checks["postgres"].Error)
+	assert.Equal(t, models.HealthStatusUp, report.Checks["redis"].Status)
+}
+
+func TestHealthService_NonCriticalDownDegrades(t *testing.T) {
+	// Setup
+	service := NewHealthService(time.Second)
+	service.AddCheck("postgres", true, healthy)
+	service.AddHeartbeat("payment_processor", &Heartbeat{}, time.Minute)
+
+	// Test
+	report := service.Check(context.Background())
+
+	// Assertions
+	assert.Equal(t, models.HealthStatusDegraded, report.Status)
+	assert.False(t, report.Checks["payment_processor"].Critical)
+	assert.Equal(t, "no heartbeat yet", report.Checks["payment_processor"].Error)
+}
+
+func TestHealthService_TimesOutSlowChecks(t *testing.T) {
+	// Setup
+	service := NewHealthService(20 * time.Millisecond)
+	service.AddCheck("redis", true, func(ctx context.Context, _ *models.HealthCheck) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	service.AddCheck("postgres", true, func(ctx context.Context, _ *models.HealthCheck) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// Test
+	start := time.Now()
+	report := service.Check(context.Background())
+
+	// Assertions
+	assert.Less(t, time.Since(start), time.Second, "checks run at once")
+	assert.Equal(t, models.HealthStatusDown, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["redis"].Error)
+	assert.GreaterOrEqual(t, report.Checks["redis"].LatencyMS, float64(20))
+}
+
+func TestHealthService_Heartbeat(t *testing.T) {
+	tests := []struct {
+		name     string
+		age      time.Duration
+		expected models.HealthStatus
+	}{
+		{name: "fresh", age: time.Second, expected: models.HealthStatusUp},
+		{name: "stale", age: 2 * time.Minute, expected: models.HealthStatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			heartbeat := &Heartbeat{}
+			heartbeat.last.Store(time.Now().Add(-tt.age).UnixNano())
+
+			service := NewHealthService(time.Second)
+			service.AddHeartbeat("expired_booking_processor", heartbeat, time.Minute)
+
+			// Test
+			report := service.Check(context.Background())
+
+			// Assertions
+			check := report.Checks["expired_booking_processor"]
+			assert.Equal(t, tt.expected, check.Status)
+			require.NotNil(t, check.LastHeartbeat)
+			assert.WithinDuration(t, heartbeat.Last(), *check.LastHeartbeat, 0)
+		})
+	}
+}
+
+func TestMigrationCheck(t *testing.T) {
+	// Setup
+	service := NewHealthService(time.Second)
+	service.AddCheck("clean", true, MigrationCheck(func(context.Context) (uint, bool, error) {
+		return 19, false, nil
+	}))
+	service.AddCheck("dirty", true, MigrationCheck(func(context.Context) (uint, bool, error) {
+		return 20, true, nil
+	}))
+
+	// Test
+	report := service.Check(context.Background())
+
+	// Assertions
+	require.NotNil(t, report.Checks["clean"].MigrationVersion)
+	assert.Equal(t, uint(19), *report.Checks["clean"].MigrationVersion)
+	assert.Equal(t, models.HealthStatusUp, report.Checks["clean"].Status)
+
+	assert.Equal(t, models.HealthStatusDown, report.Checks["dirty"].Status)
+	assert.Equal(t, uint(20), *report.Checks["dirty"].MigrationVersion)
+	assert.Equal(t, models.HealthStatusDown, report.Status)
+}
+
+func TestPaymentService_ProcessorHeartbeat(t *testing.T) {
+	// Setup
+	service, _ := newTestQueueService(t, &MockBookingRepository{}, SimulatorModeSucceed, RetryPolicy{MaxAttempts: 3})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		service.StartProcessor(ctx)
+		close(done)
+	}()
+
+	// Test
+	require.Eventually(t, func() bool {
+		return !service.ProcessorHeartbeat().Last().IsZero()
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	// Assertions
+	assert.WithinDuration(t, time.Now(), service.ProcessorHeartbeat().Last(), 5*time.Second)
+	assert.True(t, service.ExpiryHeartbeat().Last().IsZero())
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ticket-booking-system/internal/metrics"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrHoldNotFound is returned for holds that never existed, have expired
+	// or have already been released or checked out
+	ErrHoldNotFound = errors.New("hold not found or expired")
+
+	// ErrHoldAlreadyExtended is returned when extending a hold a second time
+	ErrHoldAlreadyExtended = errors.New("the hold has already been extended")
+)
+
+type HoldService struct {
+	holdRepo  repository.HoldRepositoryInterface
+	eventRepo repository.EventRepositoryInterface
+	uow       repository.UnitOfWorkInterface
+	holdTTL   time.Duration
+	limits    BookingLimits
+	metrics   *metrics.Metrics
+}
+
+func NewHoldService(
+	holdRepo repository.HoldRepositoryInterface,
+	eventRepo repository.EventRepositoryInterface,
+	uow repository.UnitOfWorkInterface,
+	holdTTL time.Duration,
+) *HoldService {
+	return &HoldService{
+		holdRepo:  holdRepo,
+		eventRepo: eventRepo,
+		uow:       uow,
+		holdTTL:   holdTTL,
+	}
+}
+
+// SetMetrics sets the metrics that failed ticket reservations are counted in
+func (s *HoldService) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
+
+// SetLimits sets the per-user limits holds are checked against, the same ones
+// bookings are
+func (s *HoldService) SetLimits(limits BookingLimits) {
+	s.limits = limits
+}
+
+// CreateHold holds tickets, and seats at reserved seating events, for the user
+// for the hold TTL. Held tickets count against the event's availability until
+// the hold is checked out, released or expires.
+func (s *HoldService) CreateHold(ctx context.Context, userID uuid.UUID, req *models.CreateHoldRequest) (*models.SeatHold, error) {
+	eventID, err := uuid.Parse(req.EventID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event ID: %w", err)
+	}
+
+	event, err := s.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event not found: %w", err)
+	}
+
+	if event.DateTime.Before(time.Now()) {
+		return nil, fmt.Errorf("cannot hold tickets for past events")
+	}
+
+	quantity := req.Quantity
+	var seatIDs []uuid.UUID
+	if event.VenueID != nil {
+		seatIDs, err = parseSeatIDs(req.SeatIDs)
+		if err != nil {
+			return nil, err
+		}
+		quantity = len(seatIDs)
+	} else if len(req.SeatIDs) > 0 {
+		return nil, fmt.Errorf("seat_ids can only be used for reserved seating events")
+	}
+
+	items, err := bookingItems(event, req.Items, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	hold := &models.SeatHold{
+		ID:      uuid.New(),
+		EventID: eventID,
+		UserID:  userID,
+		Items:   items,
+		SeatIDs: seatIDs,
+	}
+
+	// ReserveTickets locks the event row, so holds and bookings for the event
+	// are checked against each other one at a time
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		if err := reserveTickets(ctx, repos, s.metrics, eventID, items); err != nil {
+			return err
+		}
+
+		if err := s.limits.check(ctx, repos, userID, eventID, hold.Quantity()); err != nil {
+			return err
+		}
+
+		if len(seatIDs) > 0 {
+			if err := checkSeatZones(ctx, repos, event, items, seatIDs); err != nil {
+				return err
+			}
+			if err := checkSeatsAvailable(ctx, repos, event, seatIDs); err != nil {
+				return err
+			}
+		}
+
+		hold.CreatedAt = time.Now()
+		hold.ExpiresAt = hold.CreatedAt.Add(s.holdTTL)
+		return repos.Holds.Create(ctx, hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// checkSeatsAvailable checks that the seats belong to the event's venue and
+// are neither booked nor held
+func checkSeatsAvailable(ctx context.Context, repos *repository.Repositories, event *models.Event, seatIDs []uuid.UUID) error {
+	sections, err := repos.Venues.GetSeatMap(ctx, *event.VenueID, event.ID)
+	if err != nil {
+		return err
+	}
+
+	statuses := make(map[uuid.UUID]models.SeatStatus)
+	for _, section := range sections {
+		for _, row := range section.Rows {
+			for _, seat := range row.Seats {
+				statuses[seat.ID] = seat.Status
+			}
+		}
+	}
+
+	for _, seatID := range seatIDs {
+		status, ok := statuses[seatID]
+		if !ok {
+			return fmt.Errorf("seat %s is not in the event's venue", seatID)
+		}
+		if status != models.SeatStatusAvailable {
+			return ErrSeatUnavailable
+		}
+	}
+
+	return checkSeatsNotHeld(ctx, repos.Holds, event.ID, seatIDs)
+}
+
+// checkSeatsNotHeld checks that none of the seats are in a live hold for the
+// event
+func checkSeatsNotHeld(ctx context.Context, holdRepo repository.HoldRepositoryInterface, eventID uuid.UUID, seatIDs []uuid.UUID) error {
+	held, err := heldSeats(ctx, holdRepo, eventID)
+	if err != nil {
+		return err
+	}
+
+	for _, seatID := range seatIDs {
+		if held[seatID] {
+			return ErrSeatUnavailable
+		}
+	}
+
+	return nil
+}
+
+// heldSeats returns the seats in live holds for the event. Without a hold
+// repository nothing is held.
+func heldSeats(ctx context.Context, holdRepo repository.HoldRepositoryInterface, eventID uuid.UUID) (map[uuid.UUID]bool, error) {
+	held := make(map[uuid.UUID]bool)
+	if holdRepo == nil {
+		return held, nil
+	}
+
+	holds, err := holdRepo.GetByEventID(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holds: %w", err)
+	}
+
+	for _, hold := range holds {
+		for _, seatID := range hold.SeatIDs {
+			held[seatID] = true
+		}
+	}
+
+	return held, nil
+}
+
+// heldTickets returns the number of tickets in the user's live holds for the
+// event. Without a hold repository nothing is held.
+func heldTickets(ctx context.Context, holdRepo repository.HoldRepositoryInterface, userID, eventID uuid.UUID) (int, error) {
+	if holdRepo == nil {
+		return 0, nil
+	}
+
+	holds, err := holdRepo.GetByEventID(ctx, eventID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get holds: %w", err)
+	}
+
+	held := 0
+	for _, hold := range holds {
+		if hold.UserID == userID {
+			held += hold.Quantity()
+		}
+	}
+
+	return held, nil
+}
+
+// GetHold returns a live hold owned by the caller. Admins may read any hold.
+func (s *HoldService) GetHold(ctx context.Context, id uuid.UUID, caller *models.Caller) (*models.SeatHold, error) {
+	hold, err := s.holdRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrHoldNotFound) {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if hold.UserID != caller.UserID && !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	return hold, nil
+}
+
+// ExtendHold keeps a hold for another hold TTL. A hold can only be extended
+// once, and only while it is live.
+func (s *HoldService) ExtendHold(ctx context.Context, id uuid.UUID, caller *models.Caller) (*models.SeatHold, error) {
+	hold, err := s.GetHold(ctx, id, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	if hold.Extended {
+		return nil, ErrHoldAlreadyExtended
+	}
+
+	hold.Extended = true
+	hold.ExpiresAt = hold.ExpiresAt.Add(s.holdTTL)
+
+	err = s.holdRepo.Update(ctx, hold)
+	if errors.Is(err, repository.ErrHoldNotFound) {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// ReleaseHold gives a hold's tickets back before it expires
+func (s *HoldService) ReleaseHold(ctx context.Context, id uuid.UUID, caller *models.Caller) error {
+	hold, err := s.GetHold(ctx, id, caller)
+	if err != nil {
+		return err
+	}
+
+	released, err := s.holdRepo.Delete(ctx, hold)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return ErrHoldNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,402 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockHoldRepository struct {
+	mock.Mock
+}
+
+func (m *MockHoldRepository) Create(ctx context.Context, hold *models.SeatHold) error {
+	args := m.Called(hold)
+	return args.Error(0)
+}
+
+func (m *MockHoldRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.SeatHold, error) {
+	args := m.Called(id)
+	hold, _ := args.Get(0).(*models.SeatHold)
+	return hold, args.Error(1)
+}
+
+func (m *MockHoldRepository) GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*models.SeatHold, error) {
+	args := m.Called(eventID)
+	return args.Get(0).([]*models.SeatHold), args.Error(1)
+}
+
+func (m *MockHoldRepository) Update(ctx context.Context, hold *models.SeatHold) error {
+	args := m.Called(hold)
+	return args.Error(0)
+}
+
+func (m *MockHoldRepository) Delete(ctx context.Context, hold *models.SeatHold) (bool, error) {
+	args := m.Called(hold)
+	return args.Bool(0), args.Error(1)
+}
+
+func newHoldTestService() (*HoldService, *MockHoldRepository, *MockEventRepository) {
+	mockHoldRepo := &MockHoldRepository{}
+	mockEventRepo := &MockEventRepository{}
+
+	uow := newMockUnitOfWork(&MockBookingRepository{}, mockEventRepo)
+	uow.repos.Holds = mockHoldRepo
+
+	return NewHoldService(mockHoldRepo, mockEventRepo, uow, 10*time.Minute), mockHoldRepo, mockEventRepo
+}
+
+func liveHold(event *models.Event, userID uuid.UUID, quantity int) *models.SeatHold {
+	now := time.Now()
+	return &models.SeatHold{
+		ID:        uuid.New(),
+		EventID:   event.ID,
+		UserID:    userID,
+		Items:     singleItem(event, quantity),
+		ExpiresAt: now.Add(10 * time.Minute),
+		CreatedAt: now,
+	}
+}
+
+func TestHoldService_CreateHold(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, mockEventRepo := newHoldTestService()
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	vip := event.TicketTypes[1]
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, mock.Anything).Return(nil)
+	mockHoldRepo.On("Create", mock.AnythingOfType("*models.SeatHold")).Return(nil)
+
+	// Test
+	hold, err := service.CreateHold(context.Background(), userID, &models.CreateHoldRequest{
+		EventID: event.ID.String(),
+		Items:   []models.BookingItemRequest{{TicketTypeID: vip.ID.String(), Quantity: 2}},
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, userID, hold.UserID)
+	assert.Equal(t, 2, hold.Quantity())
+	assert.Equal(t, usd(12000), hold.Items[0].UnitPrice)
+	assert.False(t, hold.Extended)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), hold.ExpiresAt, time.Minute)
+	mockEventRepo.AssertExpectations(t)
+	mockHoldRepo.AssertExpectations(t)
+}
+
+func TestHoldService_CreateHold_InsufficientTickets(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, mockEventRepo := newHoldTestService()
+
+	event := newTieredEvent()
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, mock.Anything).Return(errors.New("insufficient tickets available"))
+
+	// Test
+	hold, err := service.CreateHold(context.Background(), uuid.New(), &models.CreateHoldRequest{
+		EventID: event.ID.String(),
+		Items:   []models.BookingItemRequest{{TicketTypeID: event.TicketTypes[1].ID.String(), Quantity: 6}},
+	})
+
+	// Assertions
+	require.Error(t, err)
+	assert.Nil(t, hold)
+	mockHoldRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestHoldService_CreateHold_SeatHeld(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, mockEventRepo := newHoldTestService()
+	mockVenueRepo := &MockVenueRepository{}
+	service.uow.(*MockUnitOfWork).repos.Venues = mockVenueRepo
+
+	event := newSeatedEvent()
+	seat := &models.Seat{ID: uuid.New(), Number: 1, Status: models.SeatStatusAvailable}
+	other := liveHold(event, uuid.New(), 1)
+	other.SeatIDs = []uuid.UUID{seat.ID}
+
+	// Mock expectations: the seat is free in the database but in another hold
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, singleItem(event, 1)).Return(nil)
+	mockVenueRepo.On("GetSeatMap", *event.VenueID, event.ID).Return([]*models.Section{
+		{Name: "Stalls", Rows: []*models.SeatRow{{Label: "A", Seats: []*models.Seat{seat}}}},
+	}, nil)
+	mockHoldRepo.On("GetByEventID", event.ID).Return([]*models.SeatHold{other}, nil)
+
+	// Test
+	hold, err := service.CreateHold(context.Background(), uuid.New(), &models.CreateHoldRequest{
+		EventID: event.ID.String(),
+		SeatIDs: []string{seat.ID.String()},
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrSeatUnavailable)
+	assert.Nil(t, hold)
+	mockHoldRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestHoldService_ExtendHold_OnlyOnce(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, _ := newHoldTestService()
+
+	userID := uuid.New()
+	hold := liveHold(newTieredEvent(), userID, 2)
+	expiresAt := hold.ExpiresAt
+	caller := &models.Caller{UserID: userID, Role: models.RoleCustomer}
+
+	// Mock expectations
+	mockHoldRepo.On("GetByID", hold.ID).Return(hold, nil)
+	mockHoldRepo.On("Update", hold).Return(nil).Once()
+
+	// Test
+	extended, err := service.ExtendHold(context.Background(), hold.ID, caller)
+	require.NoError(t, err)
+	_, err = service.ExtendHold(context.Background(), hold.ID, caller)
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrHoldAlreadyExtended)
+	assert.True(t, extended.Extended)
+	assert.Equal(t, expiresAt.Add(10*time.Minute), extended.ExpiresAt)
+	mockHoldRepo.AssertExpectations(t)
+}
+
+func TestHoldService_ExtendHold_Expired(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, _ := newHoldTestService()
+
+	userID := uuid.New()
+	hold := liveHold(newTieredEvent(), userID, 2)
+
+	// Mock expectations: the hold expires between reading and saving it
+	mockHoldRepo.On("GetByID", hold.ID).Return(hold, nil)
+	mockHoldRepo.On("Update", hold).Return(repository.ErrHoldNotFound)
+
+	// Test
+	_, err := service.ExtendHold(context.Background(), hold.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrHoldNotFound)
+}
+
+func TestHoldService_GetHold_Forbidden(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, _ := newHoldTestService()
+
+	hold := liveHold(newTieredEvent(), uuid.New(), 2)
+
+	// Mock expectations
+	mockHoldRepo.On("GetByID", hold.ID).Return(hold, nil)
+
+	// Test
+	_, err := service.GetHold(context.Background(), hold.ID, &models.Caller{UserID: uuid.New(), Role: models.RoleCustomer})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func newCheckoutTestService() (*BookingService, *MockHoldRepository, *MockBookingRepository, *MockEventRepository) {
+	mockHoldRepo := &MockHoldRepository{}
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+
+	uow := newMockUnitOfWork(mockBookingRepo, mockEventRepo)
+	uow.repos.Holds = mockHoldRepo
+
+	return NewBookingService(mockBookingRepo, mockEventRepo, uow, 15, logging.Discard()), mockHoldRepo, mockBookingRepo, mockEventRepo
+}
+
+func TestBookingService_CheckoutHold(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, mockBookingRepo, mockEventRepo := newCheckoutTestService()
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	hold := liveHold(event, userID, 3)
+
+	// Mock expectations: the hold is deleted before its tickets are reserved
+	mockHoldRepo.On("GetByID", hold.ID).Return(hold, nil)
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+	mockHoldRepo.On("Delete", hold).Return(true, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, hold.Items).Return(nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Return(nil)
+
+	// Test
+	booking, err := service.CheckoutHold(context.Background(), hold.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer}, &models.CheckoutHoldRequest{})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, userID, booking.UserID)
+	assert.Equal(t, 3, booking.Quantity)
+	assert.Equal(t, usd(9000), booking.TotalAmount)
+	assert.Equal(t, models.BookingStatusPending, booking.Status)
+	mockHoldRepo.AssertExpectations(t)
+	mockEventRepo.AssertExpectations(t)
+	mockBookingRepo.AssertExpectations(t)
+	mockHoldRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBookingService_CheckoutHold_AlreadyCheckedOut(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, mockBookingRepo, mockEventRepo := newCheckoutTestService()
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	hold := liveHold(event, userID, 3)
+
+	// Mock expectations: a concurrent checkout deleted the hold first
+	mockHoldRepo.On("GetByID", hold.ID).Return(hold, nil)
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+	mockHoldRepo.On("Delete", hold).Return(false, nil)
+
+	// Test
+	booking, err := service.CheckoutHold(context.Background(), hold.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer}, &models.CheckoutHoldRequest{})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrHoldNotFound)
+	assert.Nil(t, booking)
+	mockBookingRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockHoldRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBookingService_CheckoutHold_RestoresHoldOnFailure(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, mockBookingRepo, mockEventRepo := newCheckoutTestService()
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	hold := liveHold(event, userID, 3)
+
+	// Mock expectations
+	mockHoldRepo.On("GetByID", hold.ID).Return(hold, nil)
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+	mockHoldRepo.On("Delete", hold).Return(true, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, hold.Items).Return(nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Return(errors.New("connection reset"))
+	mockHoldRepo.On("Create", hold).Return(nil)
+
+	// Test
+	booking, err := service.CheckoutHold(context.Background(), hold.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer}, &models.CheckoutHoldRequest{})
+
+	// Assertions
+	require.Error(t, err)
+	assert.Nil(t, booking)
+	mockHoldRepo.AssertExpectations(t)
+}
+
+func TestBookingService_CreateBooking_SeatOnHold(t *testing.T) {
+	// Setup
+	service, mockHoldRepo, mockBookingRepo, mockEventRepo := newCheckoutTestService()
+
+	event := newSeatedEvent()
+	seatID := uuid.New()
+	hold := liveHold(event, uuid.New(), 1)
+	hold.SeatIDs = []uuid.UUID{seatID}
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, singleItem(event, 1)).Return(nil)
+	mockHoldRepo.On("GetByEventID", event.ID).Return([]*models.SeatHold{hold}, nil)
+
+	// Test
+	booking, err := service.CreateBooking(context.Background(), uuid.New(), &models.CreateBookingRequest{
+		EventID: event.ID.String(),
+		SeatIDs: []string{seatID.String()},
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrSeatUnavailable)
+	assert.Nil(t, booking)
+	mockBookingRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestEventService_GetSeatMap_ShowsHeldSeats(t *testing.T) {
+	// Setup
+	mockEventRepo := &MockEventRepository{}
+	mockVenueRepo := &MockVenueRepository{}
+	mockHoldRepo := &MockHoldRepository{}
+	service := NewEventService(mockEventRepo, mockVenueRepo, mockHoldRepo, newMockUnitOfWork(&MockBookingRepository{}, mockEventRepo))
+
+	event := newSeatedEvent()
+	held := &models.Seat{ID: uuid.New(), Number: 1, Status: models.SeatStatusAvailable}
+	free := &models.Seat{ID: uuid.New(), Number: 2, Status: models.SeatStatusAvailable}
+	hold := liveHold(event, uuid.New(), 1)
+	hold.SeatIDs = []uuid.UUID{held.ID}
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockVenueRepo.On("GetSeatMap", *event.VenueID, event.ID).Return([]*models.Section{
+		{Name: "Stalls", Rows: []*models.SeatRow{{Label: "A", Seats: []*models.Seat{held, free}}}},
+	}, nil)
+	mockHoldRepo.On("GetByEventID", event.ID).Return([]*models.SeatHold{hold}, nil)
+
+	// Test
+	seatMap, err := service.GetSeatMap(context.Background(), event.ID)
+
+	// Assertions
+	require.NoError(t, err)
+	seats := seatMap.Sections[0].Rows[0].Seats
+	assert.Equal(t, models.SeatStatusHeld, seats[0].Status)
+	assert.Equal(t, models.SeatStatusAvailable, seats[1].Status)
+}
+
+func TestEventRepository_Availability_CountsHolds(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	holdRepo := repository.NewHoldRepository(rdb, time.Hour)
+	eventRepo := repository.NewEventRepository(db, holdRepo)
+	userRepo := repository.NewUserRepository(db)
+	uow := repository.NewUnitOfWork(db, holdRepo, logging.Discard())
+	holdService := NewHoldService(holdRepo, eventRepo, uow, 10*time.Minute)
+
+	organizer := createTestUser(t, userRepo, models.RoleOrganizer)
+	customer := createTestUser(t, userRepo, models.RoleCustomer)
+
+	price := usd(3000)
+	name := "Held Event " + uuid.NewString()
+	event, err := NewEventService(eventRepo, repository.NewVenueRepository(db), holdRepo, uow).CreateEvent(ctx,
+		&models.Caller{UserID: organizer.ID, Role: organizer.Role},
+		&models.CreateEventRequest{
+			Name:         name,
+			DateTime:     time.Now().Add(24 * time.Hour),
+			TotalTickets: 2,
+			TicketPrice:  &price,
+		})
+	require.NoError(t, err)
+	t.Cleanup(func() { eventRepo.Delete(ctx, event.ID) })
+
+	// Test: every ticket is held, none is booked
+	_, err = holdService.CreateHold(ctx, customer.ID, &models.CreateHoldRequest{EventID: event.ID.String(), Quantity: 2})
+	require.NoError(t, err)
+
+	// Assertions
+	available, err := eventRepo.GetAvailableTickets(ctx, event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, available)
+
+	page, err := eventRepo.GetAll(ctx, &models.EventFilter{Search: name, HasAvailability: true})
+	require.NoError(t, err)
+	assert.Empty(t, page.Data)
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a
+	// different request body.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+
+	// ErrIdempotencyKeyInProgress is returned while the original request for a
+	// key is still being processed.
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// idempotencyLockTimeout is how long an unfinished request holds its key
+// before a retry may take it over, e.g. after the server crashed mid-request.
+const idempotencyLockTimeout = time.Minute
+
+type IdempotencyService struct {
+	idempotencyRepo repository.IdempotencyRepositoryInterface
+	ttl             time.Duration
+}
+
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyRepositoryInterface, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+	}
+}
+
+// Begin claims key for a request with the given body hash. It returns nil if
+// the caller should process the request, or the stored record if the request
+// was already completed and its response should be replayed.
+func (s *IdempotencyService) Begin(ctx context.Context, scope, key, requestHash string) (*models.IdempotencyRecord, error) {
+	now := time.Now()
+
+	acquired, err := s.idempotencyRepo.Acquire(ctx, scope, key, requestHash, now.Add(-idempotencyLockTimeout), now.Add(-s.ttl))
+	if err != nil {
+		return nil, err
+	}
+	if acquired {
+		return nil, nil
+	}
+
+	record, err := s.idempotencyRepo.Get(ctx, scope, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if record.CompletedAt == nil || record.StatusCode == nil {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return record, nil
+}
+
+// Complete stores the response so that retries with the same key replay it
+func (s *IdempotencyService) Complete(ctx context.Context, scope, key string, statusCode int, responseBody []byte) error {
+	return s.idempotencyRepo.Complete(ctx, scope, key, statusCode, responseBody)
+}
+
+// Release gives up key without storing a response, so that a retry with it is
+// processed again
+func (s *IdempotencyService) Release(ctx context.Context, scope, key string) error {
+	return s.idempotencyRepo.Release(ctx, scope, key)
+}
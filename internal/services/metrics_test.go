@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/metrics"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookingService_CreateBooking_CountsReservationFailure(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+	registry := prometheus.NewRegistry()
+	service.SetMetrics(metrics.New(registry))
+
+	event := &models.Event{
+		ID:           uuid.New(),
+		DateTime:     time.Now().Add(24 * time.Hour),
+		TotalTickets: 10,
+		Currency:     "USD",
+		TicketPrice:  usd(5000),
+		TicketTypes:  generalAdmission(usd(5000), 10),
+	}
+	soldOut := fmt.Errorf("%w for General Admission. Available: 0, Requested: 2", repository.ErrInsufficientTickets)
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, singleItem(event, 2)).Return(soldOut)
+
+	// Test
+	_, err := service.CreateBooking(context.Background(), uuid.New(), &models.CreateBookingRequest{
+		EventID:  event.ID.String(),
+		Quantity: 2,
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, repository.ErrInsufficientTickets)
+	expected := fmt.Sprintf(`
+# HELP ticket_booking_ticket_reservation_failures_total Ticket reservations that failed, by event and reason.
+# TYPE ticket_booking_ticket_reservation_failures_total counter
+ticket_booking_ticket_reservation_failures_total{event_id=%q,reason="sold_out"} 1
+`, event.ID)
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "ticket_booking_ticket_reservation_failures_total"))
+}
+
+func TestOutboxRelay_PublishPending_CountsBookings(t *testing.T) {
+	// Setup
+	relay, mockOutboxRepo, _, _ := newTestOutboxRelay(t)
+	registry := prometheus.NewRegistry()
+	relay.SetMetrics(metrics.New(registry))
+	bookingID := uuid.New()
+
+	// Mock expectations
+	mockOutboxRepo.On("TryLockRelay").Return(true, nil)
+	mockOutboxRepo.On("GetUnpublished", outboxBatchSize).Return([]*models.OutboxEvent{
+		newBookingEvent(1, bookingID, models.BookingEventCreated),
+		newBookingEvent(2, uuid.New(), models.BookingEventCreated),
+		newBookingEvent(3, bookingID, models.BookingEventConfirmed),
+	}, nil)
+	mockOutboxRepo.On("MarkPublished", []int64{1, 2, 3}).Return(nil)
+
+	// Test
+	_, err := relay.PublishPending(context.Background())
+
+	// Assertions
+	require.NoError(t, err)
+	expected := `
+# HELP ticket_booking_bookings_total Booking state changes by the status changed to.
+# TYPE ticket_booking_bookings_total counter
+ticket_booking_bookings_total{status="confirmed"} 1
+ticket_booking_bookings_total{status="created"} 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "ticket_booking_bookings_total"))
+}
+
+func TestPaymentService_HandleJob_RecordsMetrics(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	service, rdb := newTestQueueService(t, mockBookingRepo, SimulatorModeSucceed, RetryPolicy{MaxAttempts: 3})
+	registry := prometheus.NewRegistry()
+	m := metrics.New(registry)
+	m.RegisterPaymentQueue(service)
+	service.SetMetrics(m)
+	ctx := context.Background()
+
+	booking := &models.Booking{ID: uuid.New(), Status: models.BookingStatusPending, TotalAmount: usd(10000)}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockBookingRepo.On("ConfirmPayment", booking.ID, mock.AnythingOfType("string")).Return(nil)
+
+	require.NoError(t, service.QueuePayment(ctx, booking.ID, booking.TotalAmount))
+	require.NoError(t, service.QueuePayment(ctx, uuid.New(), booking.TotalAmount))
+
+	// Test
+	service.handleJob(ctx, takeJob(t, rdb))
+
+	// Assertions
+	expected := `
+# HELP ticket_booking_payment_queue_depth Payment jobs in each queue.
+# TYPE ticket_booking_payment_queue_depth gauge
+ticket_booking_payment_queue_depth{queue="dead_letter"} 0
+ticket_booking_payment_queue_depth{queue="processing"} 0
+ticket_booking_payment_queue_depth{queue="queued"} 1
+ticket_booking_payment_queue_depth{queue="retry"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "ticket_booking_payment_queue_depth"))
+
+	count, err := testutil.GatherAndCount(registry, "ticket_booking_payment_job_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
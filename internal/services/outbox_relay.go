@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/metrics"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// outboxBatchSize is the maximum number of events published per transaction
+const outboxBatchSize = 100
+
+// OutboxRelay publishes outbox events to a Redis Stream, in the order they were
+// recorded. An event is marked as published only after it has been added to
+// the stream, so delivery is at least once: if the relay fails in between, the
+// event is published again and consumers should skip event IDs they have seen.
+type OutboxRelay struct {
+	uow      repository.UnitOfWorkInterface
+	rdb      *redis.Client
+	stream   string
+	maxLen   int64
+	interval time.Duration
+	metrics  *metrics.Metrics
+	logger   *slog.Logger
+}
+
+// NewOutboxRelay returns a relay that publishes to stream every interval,
+// trimming it to about maxLen entries
+func NewOutboxRelay(uow repository.UnitOfWorkInterface, rdb *redis.Client, stream string, maxLen int64, interval time.Duration, logger *slog.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		uow:      uow,
+		rdb:      rdb,
+		stream:   stream,
+		maxLen:   maxLen,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// SetMetrics sets the metrics that published booking events are counted in.
+// Events are counted once, by the relay that publishes them.
+func (r *OutboxRelay) SetMetrics(m *metrics.Metrics) {
+	r.metrics = m
+}
+
+// PublishPending publishes the next batch of unpublished events and returns
+// how many it published. It publishes nothing while another relay is
+// publishing.
+func (r *OutboxRelay) PublishPending(ctx context.Context) (int, error) {
+	var published []*models.OutboxEvent
+
+	err := r.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		locked, err := repos.Outbox.TryLockRelay(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		events, err := repos.Outbox.GetUnpublished(ctx, outboxBatchSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		// Publish the batch atomically, so a failure cannot leave a later
+		// event of a booking in the stream without an earlier one
+		_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, event := range events {
+				pipe.XAdd(ctx, &redis.XAddArgs{
+					Stream: r.stream,
+					MaxLen: r.maxLen,
+					Approx: true,
+					Values: outboxMessageValues(event),
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish outbox events: %w", err)
+		}
+
+		ids := make([]int64, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+
+		published = events
+		return repos.Outbox.MarkPublished(ctx, ids)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range published {
+		r.metrics.BookingEvent(event.Type)
+	}
+
+	return len(published), nil
+}
+
+// StartRelay publishes outbox events until ctx is cancelled. It polls every
+// interval, and straight away again after a full batch.
+func (r *OutboxRelay) StartRelay(ctx context.Context) {
+	r.logger.Info("Starting outbox relay")
+
+	for ctx.Err() == nil {
+		published, err := r.PublishPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error("Failed to publish outbox events", logging.Err(err))
+		}
+
+		if published < outboxBatchSize {
+			sleep(ctx, r.interval)
+		}
+	}
+}
+
+// outboxMessageValues returns the fields of the stream entry of an event
+func outboxMessageValues(event *models.OutboxEvent) map[string]interface{} {
+	return map[string]interface{}{
+		"event_id":       strconv.FormatInt(event.ID, 10),
+		"type":           event.Type,
+		"aggregate_type": event.AggregateType,
+		"aggregate_id":   event.AggregateID.String(),
+		"payload":        string(event.Payload),
+		"created_at":     event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// parseOutboxMessage returns the event published as a stream entry
+func parseOutboxMessage(message redis.XMessage) (*models.OutboxEvent, error) {
+	field := func(name string) string {
+		value, _ := message.Values[name].(string)
+		return value
+	}
+
+	id, err := strconv.ParseInt(field("event_id"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event_id: %w", err)
+	}
+	aggregateID, err := uuid.Parse(field("aggregate_id"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregate_id: %w", err)
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, field("created_at"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid created_at: %w", err)
+	}
+
+	return &models.OutboxEvent{
+		ID:            id,
+		AggregateType: field("aggregate_type"),
+		AggregateID:   aggregateID,
+		Type:          field("type"),
+		Payload:       json.RawMessage(field("payload")),
+		CreatedAt:     createdAt,
+	}, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) TryLockRelay(ctx context.Context) (bool, error) {
+	args := m.Called()
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOutboxRepository) GetUnpublished(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	args := m.Called(limit)
+	events, _ := args.Get(0).([]*models.OutboxEvent)
+	return events, args.Error(1)
+}
+
+func (m *MockOutboxRepository) MarkPublished(ctx context.Context, ids []int64) error {
+	args := m.Called(ids)
+	return args.Error(0)
+}
+
+func newTestOutboxRelay(t *testing.T) (*OutboxRelay, *MockOutboxRepository, *miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	mockOutboxRepo := &MockOutboxRepository{}
+	uow := newMockUnitOfWork(&MockBookingRepository{}, &MockEventRepository{})
+	uow.repos.Outbox = mockOutboxRepo
+
+	return NewOutboxRelay(uow, rdb, "booking_events", 1000, time.Millisecond, logging.Discard()), mockOutboxRepo, mr, rdb
+}
+
+func newBookingEvent(id int64, bookingID uuid.UUID, eventType string) *models.OutboxEvent {
+	payload, _ := json.Marshal(map[string]string{"booking_id": bookingID.String()})
+	return &models.OutboxEvent{
+		ID:            id,
+		AggregateType: "booking",
+		AggregateID:   bookingID,
+		Type:          eventType,
+		Payload:       payload,
+		CreatedAt:     time.Now(),
+	}
+}
+
+func TestOutboxRelay_PublishPending_InOrder(t *testing.T) {
+	// Setup
+	relay, mockOutboxRepo, _, rdb := newTestOutboxRelay(t)
+	bookingID := uuid.New()
+	events := []*models.OutboxEvent{
+		newBookingEvent(1, bookingID, models.BookingEventCreated),
+		newBookingEvent(2, uuid.New(), models.BookingEventCreated),
+		newBookingEvent(3, bookingID, models.BookingEventConfirmed),
+	}
+
+	// Mock expectations
+	mockOutboxRepo.On("TryLockRelay").Return(true, nil)
+	mockOutboxRepo.On("GetUnpublished", outboxBatchSize).Return(events, nil)
+	mockOutboxRepo.On("MarkPublished", []int64{1, 2, 3}).Return(nil)
+
+	// Test
+	published, err := relay.PublishPending(context.Background())
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, 3, published)
+	mockOutboxRepo.AssertExpectations(t)
+
+	entries, err := rdb.XRange(context.Background(), "booking_events", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		assert.Equal(t, events[i].Type, entry.Values["type"])
+		assert.Equal(t, events[i].AggregateID.String(), entry.Values["aggregate_id"])
+		assert.Equal(t, string(events[i].Payload), entry.Values["payload"])
+	}
+	assert.Equal(t, "1", entries[0].Values["event_id"])
+	assert.Equal(t, "3", entries[2].Values["event_id"])
+}
+
+func TestOutboxRelay_PublishPending_RedisDown(t *testing.T) {
+	// Setup
+	relay, mockOutboxRepo, mr, _ := newTestOutboxRelay(t)
+	mr.Close()
+
+	// Mock expectations
+	mockOutboxRepo.On("TryLockRelay").Return(true, nil)
+	mockOutboxRepo.On("GetUnpublished", outboxBatchSize).Return([]*models.OutboxEvent{newBookingEvent(1, uuid.New(), models.BookingEventCreated)}, nil)
+
+	// Test
+	published, err := relay.PublishPending(context.Background())
+
+	// Assertions
+	assert.Error(t, err)
+	assert.Zero(t, published)
+	mockOutboxRepo.AssertNotCalled(t, "MarkPublished", mock.Anything)
+}
+
+func TestOutboxRelay_PublishPending_AnotherRelayHoldsLock(t *testing.T) {
+	// Setup
+	relay, mockOutboxRepo, _, rdb := newTestOutboxRelay(t)
+
+	// Mock expectations
+	mockOutboxRepo.On("TryLockRelay").Return(false, nil)
+
+	// Test
+	published, err := relay.PublishPending(context.Background())
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Zero(t, published)
+	mockOutboxRepo.AssertNotCalled(t, "GetUnpublished", mock.Anything)
+	length, err := rdb.XLen(context.Background(), "booking_events").Result()
+	require.NoError(t, err)
+	assert.Zero(t, length)
+}
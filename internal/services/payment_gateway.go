@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrPaymentDeclined is returned when the gateway refuses the payment.
+	// Retrying a declined payment will not succeed.
+	ErrPaymentDeclined = errors.New("payment declined")
+
+	// ErrPaymentTimeout is returned when the gateway does not answer in time.
+	// The outcome is unknown and the operation may be retried.
+	ErrPaymentTimeout = errors.New("payment gateway timeout")
+)
+
+// PaymentGateway is the contract between PaymentService and a payment provider.
+// Funds are first authorized, then captured. An authorization that is not
+// captured must be voided, and captured funds can be refunded.
+//
+// Implementations must be idempotent per booking so that a payment job that
+// runs twice cannot charge twice.
+type PaymentGateway interface {
+	// Authorize reserves amount for the booking and returns the authorization ID.
+	// The booking ID is the idempotency key: authorizing the same booking again
+	// returns the existing authorization unless it was voided.
+	Authorize(ctx context.Context, bookingID uuid.UUID, amount models.Money) (string, error)
+	// Capture collects amount from a previous authorization. Capturing an
+	// already captured authorization for the same amount is a no-op.
+	Capture(ctx context.Context, authorizationID string, amount models.Money) error
+	// Void releases an authorization that has not been captured.
+	Void(ctx context.Context, authorizationID string) error
+	// Refund returns amount of a captured authorization and returns the refund ID.
+	// Refunding again with the same idempotency key returns the existing refund.
+	Refund(ctx context.Context, authorizationID, idempotencyKey string, amount models.Money) (string, error)
+}
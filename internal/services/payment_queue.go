@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/metrics"
+	"ticket-booking-system/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	paymentQueueKey      = "payment_queue"
+	paymentProcessingKey = "payment_processing"
+	paymentRetryKey      = "payment_retry"
+	paymentDeadLetterKey = "payment_dlq"
+
+	// maximum number of due retries moved back to the queue per poll
+	retryPromotionBatch = 100
+)
+
+// RetryPolicy controls how failed payment jobs are retried before they are
+// parked in the dead-letter queue.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff returns the delay before a job that has failed attempt times is
+// retried. The delay doubles on every attempt and is capped at MaxDelay.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+
+	return delay
+}
+
+// Moves every retry whose score (due time in ms) has passed back onto the queue
+var promoteRetriesScript = redis.NewScript(`
+local jobs = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, job in ipairs(jobs) do
+	redis.call('ZREM', KEYS[1], job)
+	redis.call('LPUSH', KEYS[2], job)
+end
+return #jobs
+`)
+
+// Removes a job from the dead-letter queue and requeues it, unless another
+// replay already took it
+var replayDeadLetterScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 1 then
+	redis.call('LPUSH', KEYS[2], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+func (s *PaymentService) enqueue(ctx context.Context, job *PaymentJob) error {
+	jobData, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payment job: %w", err)
+	}
+
+	err = s.rdb.LPush(ctx, paymentQueueKey, jobData).Err()
+	if err != nil {
+		return fmt.Errorf("failed to queue payment job: %w", err)
+	}
+
+	return nil
+}
+
+// handleJob processes a job taken from the processing list and then removes it
+// from that list, rescheduling or dead-lettering it on failure.
+func (s *PaymentService) handleJob(ctx context.Context, raw string) {
+	var job PaymentJob
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		s.logger.ErrorContext(ctx, "Dropping unreadable payment job", logging.Err(err))
+		s.ack(ctx, raw)
+		return
+	}
+
+	// Trace the attempt as part of the request that queued the job
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, job.TraceContext), "payment.process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("payment.job_id", job.ID.String()),
+			attribute.String("booking.id", job.BookingID.String()),
+			attribute.Int("payment.attempt", job.Attempts+1),
+		),
+	)
+	defer span.End()
+
+	ctx = logging.With(ctx,
+		logging.BookingID(job.BookingID),
+		slog.String("payment_job_id", job.ID.String()),
+		slog.Int("attempt", job.Attempts+1),
+	)
+
+	start := time.Now()
+	var err error
+	if job.RefundAuthorizationID != "" {
+		err = s.refundUnconfirmedPayment(ctx, job.BookingID, job.RefundAuthorizationID, job.Amount)
+	} else {
+		err = s.ProcessPayment(ctx, job.BookingID)
+	}
+	s.metrics.ObservePaymentJob(paymentOutcome(err), time.Since(start))
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	if err == nil || errors.Is(err, ErrPaymentDeclined) {
+		// A declined payment has already failed the booking, retrying cannot help
+		s.ack(ctx, raw)
+		return
+	}
+
+	s.logger.WarnContext(ctx, "Failed to process payment", logging.Err(err))
+
+	if err := s.retryOrDeadLetter(ctx, raw, &job, err); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to reschedule payment job", logging.Err(err))
+	}
+}
+
+// paymentOutcome returns the outcome of a payment job that returned err
+func paymentOutcome(err error) string {
+	switch {
+	case err == nil:
+		return metrics.PaymentSucceeded
+	case errors.Is(err, ErrPaymentDeclined):
+		return metrics.PaymentDeclined
+	default:
+		return metrics.PaymentFailed
+	}
+}
+
+func (s *PaymentService) ack(ctx context.Context, raw string) {
+	if err := s.rdb.LRem(ctx, paymentProcessingKey, 1, raw).Err(); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to remove payment job from processing list", logging.Err(err))
+	}
+}
+
+func (s *PaymentService) retryOrDeadLetter(ctx context.Context, raw string, job *PaymentJob, cause error) error {
+	job.Attempts++
+	job.LastError = cause.Error()
+
+	if job.Attempts >= s.retryPolicy.MaxAttempts {
+		now := time.Now()
+		job.FailedAt = &now
+
+		jobData, err := json.Marshal(job)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payment job: %w", err)
+		}
+
+		_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.LPush(ctx, paymentDeadLetterKey, jobData)
+			pipe.LRem(ctx, paymentProcessingKey, 1, raw)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		s.logger.ErrorContext(ctx, "Payment job moved to dead-letter queue", slog.Int("attempts", job.Attempts))
+		return nil
+	}
+
+	delay := s.retryPolicy.Backoff(job.Attempts)
+
+	jobData, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payment job: %w", err)
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, paymentRetryKey, redis.Z{
+			Score:  float64(time.Now().Add(delay).UnixMilli()),
+			Member: jobData,
+		})
+		pipe.LRem(ctx, paymentProcessingKey, 1, raw)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Payment job scheduled for retry", slog.Duration("delay", delay))
+	return nil
+}
+
+// promoteDueRetries moves jobs whose backoff has elapsed back onto the queue
+func (s *PaymentService) promoteDueRetries(ctx context.Context) (int, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	return promoteRetriesScript.Run(ctx, s.rdb,
+		[]string{paymentRetryKey, paymentQueueKey},
+		now, retryPromotionBatch,
+	).Int()
+}
+
+// recoverInFlightJobs requeues jobs that were being processed when the previous
+// processor stopped, oldest first. Jobs may therefore run more than once.
+func (s *PaymentService) recoverInFlightJobs(ctx context.Context) (int, error) {
+	recovered := 0
+	for {
+		err := s.rdb.LMove(ctx, paymentProcessingKey, paymentQueueKey, "LEFT", "RIGHT").Err()
+		if err == redis.Nil {
+			return recovered, nil
+		}
+		if err != nil {
+			return recovered, err
+		}
+		recovered++
+	}
+}
+
+// QueueDepths returns the number of payment jobs waiting, in progress,
+// waiting to be retried and dead-lettered
+func (s *PaymentService) QueueDepths(ctx context.Context) (map[string]int64, error) {
+	pipe := s.rdb.Pipeline()
+	queued := pipe.LLen(ctx, paymentQueueKey)
+	processing := pipe.LLen(ctx, paymentProcessingKey)
+	retry := pipe.ZCard(ctx, paymentRetryKey)
+	deadLetter := pipe.LLen(ctx, paymentDeadLetterKey)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read payment queue depths: %w", err)
+	}
+
+	return map[string]int64{
+		"queued":      queued.Val(),
+		"processing":  processing.Val(),
+		"retry":       retry.Val(),
+		"dead_letter": deadLetter.Val(),
+	}, nil
+}
+
+// GetDeadLetterJobs returns the jobs parked in the dead-letter queue, most
+// recently failed first.
+func (s *PaymentService) GetDeadLetterJobs(ctx context.Context) ([]*PaymentJob, error) {
+	entries, err := s.rdb.LRange(context.Background(), paymentDeadLetterKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter queue: %w", err)
+	}
+
+	jobs := make([]*PaymentJob, 0, len(entries))
+	for _, raw := range entries {
+		var job PaymentJob
+		if err := json.Unmarshal([]byte(raw), &job); err != nil {
+			s.logger.WarnContext(ctx, "Skipping unreadable dead-letter entry", logging.Err(err))
+			continue
+		}
+		jobs = append(jobs, &job)
+	}
+
+	return jobs, nil
+}
+
+// ReplayDeadLetterJob moves a dead-lettered job back onto the payment queue
+// with its attempt count reset.
+func (s *PaymentService) ReplayDeadLetterJob(ctx context.Context, id uuid.UUID) error {
+	entries, err := s.rdb.LRange(ctx, paymentDeadLetterKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read dead-letter queue: %w", err)
+	}
+
+	for _, raw := range entries {
+		var job PaymentJob
+		if err := json.Unmarshal([]byte(raw), &job); err != nil || job.ID != id {
+			continue
+		}
+
+		replayed, err := s.replay(ctx, raw, &job)
+		if err != nil {
+			return err
+		}
+		if !replayed {
+			break
+		}
+
+		s.logger.InfoContext(ctx, "Replayed dead-letter payment job", slog.String("payment_job_id", job.ID.String()), logging.BookingID(job.BookingID))
+		return nil
+	}
+
+	return fmt.Errorf("dead-letter job not found")
+}
+
+// ReplayDeadLetterJobs moves every dead-lettered job back onto the payment
+// queue and returns how many were replayed.
+func (s *PaymentService) ReplayDeadLetterJobs(ctx context.Context) (int, error) {
+	entries, err := s.rdb.LRange(ctx, paymentDeadLetterKey, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read dead-letter queue: %w", err)
+	}
+
+	replayed := 0
+	for _, raw := range entries {
+		var job PaymentJob
+		if err := json.Unmarshal([]byte(raw), &job); err != nil {
+			continue
+		}
+
+		ok, err := s.replay(ctx, raw, &job)
+		if err != nil {
+			return replayed, err
+		}
+		if ok {
+			replayed++
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Replayed dead-letter payment jobs", slog.Int("count", replayed))
+	return replayed, nil
+}
+
+func (s *PaymentService) replay(ctx context.Context, raw string, job *PaymentJob) (bool, error) {
+	job.Attempts = 0
+	job.LastError = ""
+	job.FailedAt = nil
+
+	jobData, err := json.Marshal(job)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal payment job: %w", err)
+	}
+
+	moved, err := replayDeadLetterScript.Run(ctx, s.rdb,
+		[]string{paymentDeadLetterKey, paymentQueueKey},
+		raw, jobData,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to replay payment job: %w", err)
+	}
+
+	return moved == 1, nil
+}
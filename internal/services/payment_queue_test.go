@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+	"ticket-booking-system/internal/tracing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestQueueService(t *testing.T, bookingRepo *MockBookingRepository, mode SimulatorMode, policy RetryPolicy) (*PaymentService, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	gateway := NewSimulatorGateway(SimulatorConfig{Mode: mode})
+	return NewPaymentService(rdb, bookingRepo, &MockRefundRepository{}, gateway, 10*time.Millisecond, policy, logging.Discard()), rdb
+}
+
+// takeJob moves the next job to the processing list the way StartProcessor does
+func takeJob(t *testing.T, rdb *redis.Client) string {
+	t.Helper()
+
+	raw, err := rdb.LMove(context.Background(), paymentQueueKey, paymentProcessingKey, "RIGHT", "LEFT").Result()
+	require.NoError(t, err)
+	return raw
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	assert.Equal(t, 1*time.Second, policy.Backoff(1))
+	assert.Equal(t, 2*time.Second, policy.Backoff(2))
+	assert.Equal(t, 4*time.Second, policy.Backoff(3))
+	assert.Equal(t, 8*time.Second, policy.Backoff(4))
+	assert.Equal(t, 10*time.Second, policy.Backoff(5))
+	assert.Equal(t, 10*time.Second, policy.Backoff(50))
+}
+
+func TestPaymentService_HandleJob_RetriesThenDeadLetters(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	service, rdb := newTestQueueService(t, mockBookingRepo, SimulatorModeTimeout, RetryPolicy{MaxAttempts: 2})
+	ctx := context.Background()
+
+	bookingID := uuid.New()
+	booking := &models.Booking{
+		ID:          bookingID,
+		Status:      models.BookingStatusPending,
+		TotalAmount: usd(10000),
+	}
+	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
+
+	require.NoError(t, service.QueuePayment(ctx, bookingID, booking.TotalAmount))
+
+	// First attempt times out and is scheduled for retry
+	service.handleJob(ctx, takeJob(t, rdb))
+	assert.EqualValues(t, 0, rdb.LLen(ctx, paymentProcessingKey).Val())
+	assert.EqualValues(t, 1, rdb.ZCard(ctx, paymentRetryKey).Val())
+
+	promoted, err := service.promoteDueRetries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, promoted)
+
+	// Second attempt exhausts the retry budget
+	service.handleJob(ctx, takeJob(t, rdb))
+	assert.EqualValues(t, 0, rdb.LLen(ctx, paymentProcessingKey).Val())
+	assert.EqualValues(t, 0, rdb.ZCard(ctx, paymentRetryKey).Val())
+
+	jobs, err := service.GetDeadLetterJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, bookingID, jobs[0].BookingID)
+	assert.Equal(t, 2, jobs[0].Attempts)
+	assert.NotEmpty(t, jobs[0].LastError)
+	assert.NotNil(t, jobs[0].FailedAt)
+
+	// Replaying puts the job back on the queue with a fresh attempt count
+	require.NoError(t, service.ReplayDeadLetterJob(ctx, jobs[0].ID))
+	assert.EqualValues(t, 0, rdb.LLen(ctx, paymentDeadLetterKey).Val())
+
+	var replayed PaymentJob
+	require.NoError(t, json.Unmarshal([]byte(rdb.LIndex(ctx, paymentQueueKey, 0).Val()), &replayed))
+	assert.Equal(t, jobs[0].ID, replayed.ID)
+	assert.Equal(t, 0, replayed.Attempts)
+
+	assert.Error(t, service.ReplayDeadLetterJob(ctx, jobs[0].ID))
+}
+
+func TestPaymentService_HandleJob_DeclinedIsNotRetried(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	service, rdb := newTestQueueService(t, mockBookingRepo, SimulatorModeDecline, RetryPolicy{MaxAttempts: 5})
+	ctx := context.Background()
+
+	bookingID := uuid.New()
+	booking := &models.Booking{
+		ID:          bookingID,
+		Status:      models.BookingStatusPending,
+		TotalAmount: usd(10000),
+	}
+	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
+	mockBookingRepo.On("UpdatePendingStatus", bookingID, models.BookingStatusFailed).Return(nil)
+
+	require.NoError(t, service.QueuePayment(ctx, bookingID, booking.TotalAmount))
+
+	// Test
+	service.handleJob(ctx, takeJob(t, rdb))
+
+	// Assertions
+	assert.EqualValues(t, 0, rdb.LLen(ctx, paymentProcessingKey).Val())
+	assert.EqualValues(t, 0, rdb.ZCard(ctx, paymentRetryKey).Val())
+	assert.EqualValues(t, 0, rdb.LLen(ctx, paymentDeadLetterKey).Val())
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestPaymentService_RecoverInFlightJobs(t *testing.T) {
+	// Setup
+	service, rdb := newTestQueueService(t, &MockBookingRepository{}, SimulatorModeSucceed, RetryPolicy{MaxAttempts: 5})
+	ctx := context.Background()
+
+	first, second := uuid.New(), uuid.New()
+	require.NoError(t, service.QueuePayment(ctx, first, usd(1000)))
+	require.NoError(t, service.QueuePayment(ctx, second, usd(1000)))
+
+	// Simulate a crash after both jobs were taken but before they were acked
+	takeJob(t, rdb)
+	takeJob(t, rdb)
+
+	// Test
+	recovered, err := service.recoverInFlightJobs(ctx)
+
+	// Assertions: jobs are requeued and still come out oldest first
+	require.NoError(t, err)
+	assert.Equal(t, 2, recovered)
+	assert.EqualValues(t, 0, rdb.LLen(ctx, paymentProcessingKey).Val())
+
+	var job PaymentJob
+	require.NoError(t, json.Unmarshal([]byte(takeJob(t, rdb)), &job))
+	assert.Equal(t, first, job.BookingID)
+}
+
+func TestPaymentService_HandleJob_ContinuesRequestTrace(t *testing.T) {
+	// Setup
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	mockBookingRepo := &MockBookingRepository{}
+	service, rdb := newTestQueueService(t, mockBookingRepo, SimulatorModeSucceed, RetryPolicy{MaxAttempts: 3})
+	booking := &models.Booking{ID: uuid.New(), Status: models.BookingStatusPending, TotalAmount: usd(10000)}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockBookingRepo.On("ConfirmPayment", booking.ID, mock.AnythingOfType("string")).Return(nil)
+
+	// Test
+	requestCtx, request := tracing.Tracer().Start(context.Background(), "POST /api/v1/bookings")
+	require.NoError(t, service.QueuePayment(requestCtx, booking.ID, booking.TotalAmount))
+	request.End()
+
+	// The worker has no trace of its own
+	service.handleJob(context.Background(), takeJob(t, rdb))
+
+	// Assertions
+	var payment sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "payment.process" {
+			payment = span
+		}
+	}
+	require.NotNil(t, payment)
+	assert.Equal(t, request.SpanContext().TraceID(), payment.SpanContext().TraceID())
+	assert.Equal(t, request.SpanContext().SpanID(), payment.Parent().SpanID())
+}
+
+// flakyRefundGateway fails the first failures refunds, and any refund whose
+// context is already done
+type flakyRefundGateway struct {
+	PaymentGateway
+	failures int
+	refunds  int
+}
+
+func (g *flakyRefundGateway) Refund(ctx context.Context, authorizationID, idempotencyKey string, amount models.Money) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if g.failures > 0 {
+		g.failures--
+		return "", ErrPaymentTimeout
+	}
+	g.refunds++
+	return g.PaymentGateway.Refund(ctx, authorizationID, idempotencyKey, amount)
+}
+
+func TestPaymentService_ProcessPayment_RefundOutlivesCaller(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	gateway := &flakyRefundGateway{PaymentGateway: NewSimulatorGateway(SimulatorConfig{Mode: SimulatorModeSucceed})}
+	service, rdb := newTestQueueService(t, mockBookingRepo, SimulatorModeSucceed, RetryPolicy{MaxAttempts: 3})
+	service.gateway = gateway
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	booking := &models.Booking{ID: uuid.New(), Status: models.BookingStatusPending, TotalAmount: usd(10000)}
+
+	// Mock expectations: the booking expires and the caller gives up while
+	// the payment is being confirmed
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockBookingRepo.On("ConfirmPayment", booking.ID, mock.AnythingOfType("string")).
+		Run(func(mock.Arguments) { cancel() }).
+		Return(repository.ErrBookingNotPending)
+
+	// Test
+	err := service.ProcessPayment(ctx, booking.ID)
+
+	// Assertions
+	assert.Error(t, err)
+	assert.Equal(t, 1, gateway.refunds)
+	assert.EqualValues(t, 0, rdb.LLen(context.Background(), paymentQueueKey).Val())
+}
+
+func TestPaymentService_ProcessPayment_QueuesFailedRefund(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	gateway := &flakyRefundGateway{PaymentGateway: NewSimulatorGateway(SimulatorConfig{Mode: SimulatorModeSucceed}), failures: 1}
+	service, rdb := newTestQueueService(t, mockBookingRepo, SimulatorModeSucceed, RetryPolicy{MaxAttempts: 3})
+	service.gateway = gateway
+	ctx := context.Background()
+
+	booking := &models.Booking{ID: uuid.New(), Status: models.BookingStatusPending, TotalAmount: usd(10000)}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockBookingRepo.On("ConfirmPayment", booking.ID, mock.AnythingOfType("string")).Return(repository.ErrBookingNotPending)
+
+	// Test: the refund fails and is queued
+	require.Error(t, service.ProcessPayment(ctx, booking.ID))
+	assert.Equal(t, 0, gateway.refunds)
+
+	var job PaymentJob
+	require.NoError(t, json.Unmarshal([]byte(rdb.LIndex(ctx, paymentQueueKey, 0).Val()), &job))
+	assert.Equal(t, booking.ID, job.BookingID)
+	assert.NotEmpty(t, job.RefundAuthorizationID)
+
+	// The queued job refunds without charging again
+	service.handleJob(ctx, takeJob(t, rdb))
+
+	// Assertions
+	assert.Equal(t, 1, gateway.refunds)
+	assert.EqualValues(t, 0, rdb.LLen(ctx, paymentProcessingKey).Val())
+	assert.EqualValues(t, 0, rdb.ZCard(ctx, paymentRetryKey).Val())
+	mockBookingRepo.AssertNumberOfCalls(t, "GetByID", 1)
+}
@@ -2,137 +2,374 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/metrics"
 	"ticket-booking-system/internal/models"
 	"ticket-booking-system/internal/repository"
+	"ticket-booking-system/internal/tracing"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
 type PaymentService struct {
-	rdb         *redis.Client
-	bookingRepo repository.BookingRepositoryInterface
+	rdb            *redis.Client
+	bookingRepo    repository.BookingRepositoryInterface
+	refundRepo     repository.RefundRepositoryInterface
+	gateway        PaymentGateway
+	gatewayTimeout time.Duration
+	retryPolicy    RetryPolicy
+	releases       TicketReleaseListener
+	metrics        *metrics.Metrics
+	logger         *slog.Logger
+
+	processorHeartbeat Heartbeat
+	expiryHeartbeat    Heartbeat
 }
 
+// PaymentJob is a queued payment. TraceContext carries the trace of the
+// request that queued it, so the payment is traced as part of that request.
+// Jobs with a RefundAuthorizationID instead give back a payment that was
+// captured but could not be confirmed.
 type PaymentJob struct {
-	BookingID uuid.UUID `json:"booking_id"`
-	Amount    float64   `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	ID           uuid.UUID         `json:"id"`
+	BookingID    uuid.UUID         `json:"booking_id"`
+	Amount       models.Money      `json:"amount"`
+	CreatedAt    time.Time         `json:"created_at"`
+	Attempts     int               `json:"attempts"`
+	LastError    string            `json:"last_error,omitempty"`
+	FailedAt     *time.Time        `json:"failed_at,omitempty"`
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+
+	// Set on refund jobs to the authorization to refund
+	RefundAuthorizationID string `json:"refund_authorization_id,omitempty"`
 }
 
-func NewPaymentService(rdb *redis.Client, bookingRepo repository.BookingRepositoryInterface) *PaymentService {
+func NewPaymentService(
+	rdb *redis.Client,
+	bookingRepo repository.BookingRepositoryInterface,
+	refundRepo repository.RefundRepositoryInterface,
+	gateway PaymentGateway,
+	gatewayTimeout time.Duration,
+	retryPolicy RetryPolicy,
+	logger *slog.Logger,
+) *PaymentService {
 	return &PaymentService{
-		rdb:         rdb,
-		bookingRepo: bookingRepo,
+		rdb:            rdb,
+		bookingRepo:    bookingRepo,
+		refundRepo:     refundRepo,
+		gateway:        gateway,
+		gatewayTimeout: gatewayTimeout,
+		retryPolicy:    retryPolicy,
+		logger:         logger,
 	}
 }
 
-func (s *PaymentService) ProcessPayment(bookingID uuid.UUID) error {
-	// Simulate payment processing
-	// In a real application, this would integrate with a payment gateway
+// SetReleaseListener sets the listener notified when a declined payment or an
+// expired booking releases tickets
+func (s *PaymentService) SetReleaseListener(listener TicketReleaseListener) {
+	s.releases = listener
+}
+
+// SetMetrics sets the metrics that payment jobs are recorded in
+func (s *PaymentService) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
+
+// ProcessorHeartbeat beats each time the payment processor waits for a job
+func (s *PaymentService) ProcessorHeartbeat() *Heartbeat {
+	return &s.processorHeartbeat
+}
+
+// ExpiryHeartbeat beats each time the expired booking processor runs
+func (s *PaymentService) ExpiryHeartbeat() *Heartbeat {
+	return &s.expiryHeartbeat
+}
+
+// ticketsReleased notifies the release listener, if any
+func (s *PaymentService) ticketsReleased(ctx context.Context, eventID uuid.UUID) {
+	if s.releases != nil {
+		s.releases.TicketsReleased(ctx, eventID)
+	}
+}
+
+func (s *PaymentService) ProcessPayment(ctx context.Context, bookingID uuid.UUID) error {
+	booking, err := s.bookingRepo.GetByID(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+
+	// The booking may have expired or been cancelled while the job was queued
+	if booking.Status != models.BookingStatusPending {
+		s.logger.InfoContext(ctx, "Skipping payment for booking that is no longer pending", slog.String("status", string(booking.Status)))
+		return nil
+	}
+
+	gatewayCtx, cancel := context.WithTimeout(ctx, s.gatewayTimeout)
+	defer cancel()
+
+	authorizationID, err := s.gateway.Authorize(gatewayCtx, bookingID, booking.TotalAmount)
+	if err != nil {
+		if errors.Is(err, ErrPaymentDeclined) {
+			return s.failBooking(ctx, booking, err)
+		}
+		return fmt.Errorf("failed to authorize payment: %w", err)
+	}
 
-	// Simulate random payment success/failure (80% success rate)
-	// In production, this would be replaced with actual payment processing
-	time.Sleep(2 * time.Second) // Simulate processing time
+	err = s.gateway.Capture(gatewayCtx, authorizationID, booking.TotalAmount)
+	if err != nil {
+		if voidErr := s.voidAuthorization(context.WithoutCancel(ctx), authorizationID); voidErr != nil {
+			s.logger.ErrorContext(ctx, "Failed to void payment authorization", slog.String("authorization_id", authorizationID), logging.Err(voidErr))
+		}
+		if errors.Is(err, ErrPaymentDeclined) {
+			return s.failBooking(ctx, booking, err)
+		}
+		return fmt.Errorf("failed to capture payment: %w", err)
+	}
 
-	// For demo purposes, we'll simulate successful payment
-	// In reality, this would depend on the payment gateway response
-	err := s.bookingRepo.UpdateStatus(bookingID, models.BookingStatusConfirmed)
+	err = s.bookingRepo.ConfirmPayment(ctx, bookingID, authorizationID)
+	if err != nil && !errors.Is(err, repository.ErrBookingNotPending) {
+		// The booking may still be pending, so keep the payment: the retried job
+		// gets the same captured authorization back and confirms with it
+		return fmt.Errorf("failed to confirm payment: %w", err)
+	}
 	if err != nil {
-		log.Printf("Failed to confirm booking %s: %v", bookingID, err)
+		// The booking left PENDING while we were charging, so give the money back.
+		// The refund gets its own timeout, as the gateway calls above may have
+		// used up most of theirs.
+		s.logger.WarnContext(ctx, "Failed to confirm booking, refunding payment", logging.Err(err))
+		refundCtx := context.WithoutCancel(ctx)
+		if refundErr := s.refundUnconfirmedPayment(refundCtx, bookingID, authorizationID, booking.TotalAmount); refundErr != nil {
+			s.logger.ErrorContext(ctx, "Failed to refund payment, queueing a retry", slog.String("authorization_id", authorizationID), logging.Err(refundErr))
+			if queueErr := s.queueRefund(refundCtx, bookingID, authorizationID, booking.TotalAmount); queueErr != nil {
+				s.logger.ErrorContext(ctx, "Failed to queue payment refund", slog.String("authorization_id", authorizationID), logging.Err(queueErr))
+			}
+		}
 		return err
 	}
 
-	log.Printf("Payment processed successfully for booking %s", bookingID)
+	s.logger.InfoContext(ctx, "Payment processed")
 	return nil
 }
 
-func (s *PaymentService) QueuePayment(bookingID uuid.UUID, amount float64) error {
-	job := PaymentJob{
-		BookingID: bookingID,
-		Amount:    amount,
-		CreatedAt: time.Now(),
+// voidAuthorization releases an authorization that could not be captured. It
+// gets its own timeout, as a capture that timed out has used up the one the
+// other gateway calls share.
+func (s *PaymentService) voidAuthorization(ctx context.Context, authorizationID string) error {
+	gatewayCtx, cancel := context.WithTimeout(ctx, s.gatewayTimeout)
+	defer cancel()
+
+	return s.gateway.Void(gatewayCtx, authorizationID)
+}
+
+// refundUnconfirmedPayment gives back a payment that was captured for a
+// booking but could not be confirmed. The booking ID is the gateway
+// idempotency key, so a retried refund never pays out twice.
+func (s *PaymentService) refundUnconfirmedPayment(ctx context.Context, bookingID uuid.UUID, authorizationID string, amount models.Money) error {
+	gatewayCtx, cancel := context.WithTimeout(ctx, s.gatewayTimeout)
+	defer cancel()
+
+	if _, err := s.gateway.Refund(gatewayCtx, authorizationID, bookingID.String(), amount); err != nil {
+		return fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Unconfirmed payment refunded", slog.String("authorization_id", authorizationID))
+	return nil
+}
+
+// queueRefund queues a refund of an unconfirmed payment, which is retried and
+// dead-lettered like a payment job
+func (s *PaymentService) queueRefund(ctx context.Context, bookingID uuid.UUID, authorizationID string, amount models.Money) error {
+	job := &PaymentJob{
+		ID:                    uuid.New(),
+		BookingID:             bookingID,
+		Amount:                amount,
+		RefundAuthorizationID: authorizationID,
+		CreatedAt:             time.Now(),
+		TraceContext:          tracing.Inject(ctx),
+	}
+
+	return s.enqueue(ctx, job)
+}
+
+// failBooking marks the booking as failed, which releases its seats. A
+// booking that left PENDING in the meantime, e.g. confirmed by another attempt,
+// is left as it is.
+func (s *PaymentService) failBooking(ctx context.Context, booking *models.Booking, cause error) error {
+	err := s.bookingRepo.UpdatePendingStatus(ctx, booking.ID, models.BookingStatusFailed)
+	if errors.Is(err, repository.ErrBookingNotPending) {
+		s.logger.InfoContext(ctx, "Payment declined for booking that is no longer pending")
+		return cause
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to mark booking as failed", logging.Err(err))
+		return err
 	}
 
-	jobData, err := json.Marshal(job)
+	s.logger.InfoContext(ctx, "Payment declined", logging.EventID(booking.EventID))
+	s.ticketsReleased(ctx, booking.EventID)
+	return cause
+}
+
+// ProcessRefund returns the money for a refund through the payment gateway.
+// The refund ID is the gateway idempotency key, so a refund that failed or was
+// interrupted can be processed again without paying out twice.
+func (s *PaymentService) ProcessRefund(ctx context.Context, refund *models.Refund) error {
+	if refund.Status == models.RefundStatusCompleted {
+		return nil
+	}
+
+	booking, err := s.bookingRepo.GetByID(ctx, refund.BookingID)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payment job: %w", err)
+		return err
+	}
+	if booking.PaymentReference == nil {
+		return fmt.Errorf("booking %s has no captured payment to refund", booking.ID)
+	}
+
+	// The outcome is recorded even if the caller gives up after the gateway call
+	recordCtx := context.WithoutCancel(ctx)
+
+	if refund.Amount.IsPositive() {
+		gatewayCtx, cancel := context.WithTimeout(ctx, s.gatewayTimeout)
+		defer cancel()
+
+		refundReference, err := s.gateway.Refund(gatewayCtx, *booking.PaymentReference, refund.ID.String(), refund.Amount)
+		if err != nil {
+			refund.Status = models.RefundStatusFailed
+			if updateErr := s.refundRepo.UpdateStatus(recordCtx, refund); updateErr != nil {
+				s.logger.ErrorContext(ctx, "Failed to mark refund as failed", slog.String("refund_id", refund.ID.String()), logging.BookingID(booking.ID), logging.Err(updateErr))
+			}
+			return fmt.Errorf("failed to refund payment: %w", err)
+		}
+		refund.RefundReference = &refundReference
+	}
+
+	refund.Status = models.RefundStatusCompleted
+	if err := s.refundRepo.UpdateStatus(recordCtx, refund); err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Refund processed", slog.String("refund_id", refund.ID.String()), logging.BookingID(booking.ID), slog.String("amount", refund.Amount.String()))
+	return nil
+}
+
+func (s *PaymentService) QueuePayment(ctx context.Context, bookingID uuid.UUID, amount models.Money) error {
+	job := &PaymentJob{
+		ID:           uuid.New(),
+		BookingID:    bookingID,
+		Amount:       amount,
+		CreatedAt:    time.Now(),
+		TraceContext: tracing.Inject(ctx),
 	}
 
-	// Add job to Redis queue
-	err = s.rdb.LPush(context.Background(), "payment_queue", jobData).Err()
+	err := s.enqueue(ctx, job)
 	if err != nil {
-		return fmt.Errorf("failed to queue payment job: %w", err)
+		return err
 	}
 
-	log.Printf("Payment job queued for booking %s", bookingID)
+	s.logger.InfoContext(ctx, "Payment job queued", logging.BookingID(bookingID), slog.String("payment_job_id", job.ID.String()))
 	return nil
 }
 
-func (s *PaymentService) StartProcessor() {
-	log.Println("Starting payment processor...")
+// StartProcessor consumes payment jobs with at-least-once semantics. Each job
+// is atomically moved to a processing list while it runs and only removed once
+// it has succeeded, been scheduled for retry or been dead-lettered. It returns
+// when ctx is cancelled, after finishing the job in progress.
+func (s *PaymentService) StartProcessor(ctx context.Context) {
+	s.logger.Info("Starting payment processor")
+	s.processorHeartbeat.Beat()
 
-	for {
-		// Block and wait for jobs from the queue
-		result, err := s.rdb.BRPop(context.Background(), 0, "payment_queue").Result()
-		if err != nil {
-			log.Printf("Error waiting for payment jobs: %v", err)
-			time.Sleep(5 * time.Second)
-			continue
+	// Jobs in progress are completed even when shutdown begins
+	jobCtx := context.WithoutCancel(ctx)
+
+	// Requeue jobs left in the processing list by a previous crash
+	recovered, err := s.recoverInFlightJobs(ctx)
+	if err != nil {
+		s.logger.Error("Failed to recover in-flight payment jobs", logging.Err(err))
+	} else if recovered > 0 {
+		s.logger.Info("Requeued in-flight payment jobs", slog.Int("count", recovered))
+	}
+
+	for ctx.Err() == nil {
+		s.processorHeartbeat.Beat()
+
+		// Move retries whose backoff has elapsed back onto the queue
+		if _, err := s.promoteDueRetries(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to promote payment retries", logging.Err(err))
 		}
 
-		// Parse job data
-		var job PaymentJob
-		err = json.Unmarshal([]byte(result[1]), &job)
-		if err != nil {
-			log.Printf("Failed to unmarshal payment job: %v", err)
+		// Wait briefly for a job so due retries are promoted and shutdown is noticed
+		raw, err := s.rdb.BLMove(ctx, paymentQueueKey, paymentProcessingKey, "RIGHT", "LEFT", time.Second).Result()
+		if err == redis.Nil || ctx.Err() != nil {
 			continue
 		}
-
-		// Process payment
-		err = s.ProcessPayment(job.BookingID)
 		if err != nil {
-			log.Printf("Failed to process payment for booking %s: %v", job.BookingID, err)
-			// In production, you might want to retry failed payments or move them to a dead letter queue
+			s.logger.Error("Failed to wait for payment jobs", logging.Err(err))
+			sleep(ctx, 5*time.Second)
+			continue
 		}
+
+		s.handleJob(jobCtx, raw)
 	}
 }
 
-func (s *PaymentService) ProcessExpiredBookings() error {
+func (s *PaymentService) ProcessExpiredBookings(ctx context.Context) error {
 	// Get all expired bookings
-	expiredBookings, err := s.bookingRepo.GetExpiredBookings()
+	expiredBookings, err := s.bookingRepo.GetExpiredBookings(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get expired bookings: %w", err)
 	}
 
-	// Cancel expired bookings
+	// Cancel expired bookings, unless they were paid in the meantime
 	for _, booking := range expiredBookings {
-		err := s.bookingRepo.UpdateStatus(booking.ID, models.BookingStatusCancelled)
+		err := s.bookingRepo.Expire(ctx, booking.ID)
 		if err != nil {
-			log.Printf("Failed to cancel expired booking %s: %v", booking.ID, err)
+			s.logger.ErrorContext(ctx, "Failed to cancel expired booking", logging.BookingID(booking.ID), logging.Err(err))
 			continue
 		}
-		log.Printf("Cancelled expired booking %s", booking.ID)
+		s.logger.InfoContext(ctx, "Cancelled expired booking", logging.BookingID(booking.ID), logging.EventID(booking.EventID))
+		s.ticketsReleased(ctx, booking.EventID)
 	}
 
 	return nil
 }
 
-func (s *PaymentService) StartExpiredBookingProcessor() {
-	log.Println("Starting expired booking processor...")
+// StartExpiredBookingProcessor cancels expired bookings every minute until ctx
+// is cancelled.
+func (s *PaymentService) StartExpiredBookingProcessor(ctx context.Context) {
+	s.logger.Info("Starting expired booking processor")
+	s.expiryHeartbeat.Beat()
 
 	ticker := time.NewTicker(1 * time.Minute) // Check every minute
 	defer ticker.Stop()
 
-	for range ticker.C {
-		err := s.ProcessExpiredBookings()
-		if err != nil {
-			log.Printf("Error processing expired bookings: %v", err)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.ProcessExpiredBookings(ctx)
+			if err != nil {
+				s.logger.Error("Failed to process expired bookings", logging.Err(err))
+			}
+			s.expiryHeartbeat.Beat()
 		}
 	}
 }
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPaymentService(bookingRepo *MockBookingRepository, mode SimulatorMode) *PaymentService {
+	gateway := NewSimulatorGateway(SimulatorConfig{Mode: mode})
+	return NewPaymentService(nil, bookingRepo, &MockRefundRepository{}, gateway, 50*time.Millisecond, RetryPolicy{MaxAttempts: 3}, logging.Discard())
+}
+
+func TestPaymentService_ProcessPayment_Success(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	service := newTestPaymentService(mockBookingRepo, SimulatorModeSucceed)
+
+	bookingID := uuid.New()
+	booking := &models.Booking{
+		ID:          bookingID,
+		Status:      models.BookingStatusPending,
+		TotalAmount: usd(10000),
+	}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
+	mockBookingRepo.On("ConfirmPayment", bookingID, mock.AnythingOfType("string")).Return(nil)
+
+	// Test
+	err := service.ProcessPayment(context.Background(), bookingID)
+
+	// Assertions
+	assert.NoError(t, err)
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestPaymentService_ProcessPayment_Declined(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	service := newTestPaymentService(mockBookingRepo, SimulatorModeDecline)
+
+	bookingID := uuid.New()
+	booking := &models.Booking{
+		ID:          bookingID,
+		Status:      models.BookingStatusPending,
+		TotalAmount: usd(10000),
+	}
+
+	// Mock expectations: a declined payment fails the booking and releases its seats
+	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
+	mockBookingRepo.On("UpdatePendingStatus", bookingID, models.BookingStatusFailed).Return(nil)
+
+	// Test
+	err := service.ProcessPayment(context.Background(), bookingID)
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrPaymentDeclined)
+	mockBookingRepo.AssertExpectations(t)
+	mockBookingRepo.AssertNotCalled(t, "ConfirmPayment", mock.Anything, mock.Anything)
+}
+
+func TestPaymentService_ProcessPayment_DeclinedAfterConfirmed(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	listener := &MockReleaseListener{}
+	service := newTestPaymentService(mockBookingRepo, SimulatorModeDecline)
+	service.SetReleaseListener(listener)
+
+	bookingID := uuid.New()
+	booking := &models.Booking{
+		ID:          bookingID,
+		EventID:     uuid.New(),
+		Status:      models.BookingStatusPending,
+		TotalAmount: usd(10000),
+	}
+
+	// Mock expectations: another attempt confirmed the booking during this one
+	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
+	mockBookingRepo.On("UpdatePendingStatus", bookingID, models.BookingStatusFailed).Return(repository.ErrBookingNotPending)
+
+	// Test
+	err := service.ProcessPayment(context.Background(), bookingID)
+
+	// Assertions: the confirmed booking keeps its tickets
+	assert.ErrorIs(t, err, ErrPaymentDeclined)
+	mockBookingRepo.AssertExpectations(t)
+	listener.AssertNotCalled(t, "TicketsReleased", mock.Anything)
+}
+
+func TestPaymentService_ProcessPayment_Timeout(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	service := newTestPaymentService(mockBookingRepo, SimulatorModeTimeout)
+
+	bookingID := uuid.New()
+	booking := &models.Booking{
+		ID:          bookingID,
+		Status:      models.BookingStatusPending,
+		TotalAmount: usd(10000),
+	}
+
+	// Mock expectations: a timeout leaves the booking pending
+	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
+
+	// Test
+	err := service.ProcessPayment(context.Background(), bookingID)
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrPaymentTimeout)
+	mockBookingRepo.AssertExpectations(t)
+	mockBookingRepo.AssertNotCalled(t, "UpdatePendingStatus", mock.Anything, mock.Anything)
+}
+
+func TestPaymentService_ProcessPayment_NotPending(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	service := newTestPaymentService(mockBookingRepo, SimulatorModeSucceed)
+
+	bookingID := uuid.New()
+	booking := &models.Booking{
+		ID:     bookingID,
+		Status: models.BookingStatusCancelled,
+	}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
+
+	// Test
+	err := service.ProcessPayment(context.Background(), bookingID)
+
+	// Assertions
+	assert.NoError(t, err)
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestSimulatorGateway_RefundExceedsCapture(t *testing.T) {
+	gateway := NewSimulatorGateway(SimulatorConfig{Mode: SimulatorModeSucceed})
+	ctx := context.Background()
+
+	authorizationID, err := gateway.Authorize(ctx, uuid.New(), usd(5000))
+	assert.NoError(t, err)
+	assert.NoError(t, gateway.Capture(ctx, authorizationID, usd(5000)))
+
+	refundID, err := gateway.Refund(ctx, authorizationID, "refund-1", usd(3000))
+	assert.NoError(t, err)
+
+	// Retrying with the same key does not refund twice
+	retriedID, err := gateway.Refund(ctx, authorizationID, "refund-1", usd(3000))
+	assert.NoError(t, err)
+	assert.Equal(t, refundID, retriedID)
+
+	_, err = gateway.Refund(ctx, authorizationID, "refund-2", usd(3000))
+	assert.Error(t, err)
+
+	assert.Error(t, gateway.Void(ctx, authorizationID))
+}
+
+func TestPaymentService_ProcessPayment_DuplicateJobChargesOnce(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	gateway := NewSimulatorGateway(SimulatorConfig{Mode: SimulatorModeSucceed})
+	service := NewPaymentService(nil, mockBookingRepo, &MockRefundRepository{}, gateway, 50*time.Millisecond, RetryPolicy{MaxAttempts: 3}, logging.Discard())
+
+	bookingID := uuid.New()
+	booking := &models.Booking{
+		ID:          bookingID,
+		Status:      models.BookingStatusPending,
+		TotalAmount: usd(10000),
+	}
+
+	// Both jobs see the booking as pending, as two racing processors would
+	var references []string
+	mockBookingRepo.On("GetByID", bookingID).Return(booking, nil)
+	mockBookingRepo.On("ConfirmPayment", bookingID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { references = append(references, args.String(1)) }).
+		Return(nil)
+
+	// Test
+	assert.NoError(t, service.ProcessPayment(context.Background(), bookingID))
+	assert.NoError(t, service.ProcessPayment(context.Background(), bookingID))
+
+	// Assertions: both runs used the same authorization and it was captured once
+	assert.Len(t, references, 2)
+	assert.Equal(t, references[0], references[1])
+	assert.Len(t, gateway.authorizations, 1)
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestPaymentService_ProcessPayment_ConfirmErrorKeepsPayment(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	gateway := &flakyRefundGateway{PaymentGateway: NewSimulatorGateway(SimulatorConfig{Mode: SimulatorModeSucceed})}
+	service := NewPaymentService(nil, mockBookingRepo, &MockRefundRepository{}, gateway, 50*time.Millisecond, RetryPolicy{MaxAttempts: 3}, logging.Discard())
+
+	booking := &models.Booking{ID: uuid.New(), Status: models.BookingStatusPending, TotalAmount: usd(10000)}
+
+	// Mock expectations: the first confirmation fails for a reason unrelated to
+	// the booking, the retry's succeeds
+	var references []string
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockBookingRepo.On("ConfirmPayment", booking.ID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { references = append(references, args.String(1)) }).
+		Return(errors.New("connection reset")).Once()
+	mockBookingRepo.On("ConfirmPayment", booking.ID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { references = append(references, args.String(1)) }).
+		Return(nil).Once()
+
+	// Test
+	firstErr := service.ProcessPayment(context.Background(), booking.ID)
+	retryErr := service.ProcessPayment(context.Background(), booking.ID)
+
+	// Assertions: nothing was refunded and the retry confirmed the same payment
+	assert.Error(t, firstErr)
+	assert.NoError(t, retryErr)
+	assert.Equal(t, 0, gateway.refunds)
+	require.Len(t, references, 2)
+	assert.Equal(t, references[0], references[1])
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestPaymentService_ProcessPayment_NoLongerPendingRefunds(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	gateway := &flakyRefundGateway{PaymentGateway: NewSimulatorGateway(SimulatorConfig{Mode: SimulatorModeSucceed})}
+	service := NewPaymentService(nil, mockBookingRepo, &MockRefundRepository{}, gateway, 50*time.Millisecond, RetryPolicy{MaxAttempts: 3}, logging.Discard())
+
+	booking := &models.Booking{ID: uuid.New(), Status: models.BookingStatusPending, TotalAmount: usd(10000)}
+
+	// Mock expectations: the booking expired while the payment was captured
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockBookingRepo.On("ConfirmPayment", booking.ID, mock.AnythingOfType("string")).Return(repository.ErrBookingNotPending)
+
+	// Test
+	err := service.ProcessPayment(context.Background(), booking.ID)
+
+	// Assertions
+	assert.ErrorIs(t, err, repository.ErrBookingNotPending)
+	assert.Equal(t, 1, gateway.refunds)
+}
+
+// slowCaptureGateway times every capture out, and like a real gateway cannot
+// be reached with a context that is done
+type slowCaptureGateway struct {
+	*SimulatorGateway
+}
+
+func (g *slowCaptureGateway) Void(ctx context.Context, authorizationID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return g.SimulatorGateway.Void(ctx, authorizationID)
+}
+
+func (g *slowCaptureGateway) Capture(ctx context.Context, authorizationID string, amount models.Money) error {
+	<-ctx.Done()
+	return fmt.Errorf("%w: %v", ErrPaymentTimeout, ctx.Err())
+}
+
+func TestPaymentService_ProcessPayment_CaptureTimeoutVoids(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	gateway := &slowCaptureGateway{NewSimulatorGateway(SimulatorConfig{Mode: SimulatorModeSucceed})}
+	service := NewPaymentService(nil, mockBookingRepo, &MockRefundRepository{}, gateway, 20*time.Millisecond, RetryPolicy{MaxAttempts: 3}, logging.Discard())
+
+	booking := &models.Booking{ID: uuid.New(), Status: models.BookingStatusPending, TotalAmount: usd(10000)}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+
+	// Test
+	err := service.ProcessPayment(context.Background(), booking.ID)
+
+	// Assertions: the void reached the gateway after the capture's timeout
+	assert.ErrorIs(t, err, ErrPaymentTimeout)
+	require.Len(t, gateway.authorizations, 1)
+	for _, auth := range gateway.authorizations {
+		assert.True(t, auth.voided)
+	}
+	mockBookingRepo.AssertNotCalled(t, "ConfirmPayment", mock.Anything, mock.Anything)
+}
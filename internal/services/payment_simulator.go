@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type SimulatorMode string
+
+const (
+	SimulatorModeSucceed SimulatorMode = "succeed"
+	SimulatorModeDecline SimulatorMode = "decline"
+	SimulatorModeTimeout SimulatorMode = "timeout"
+	SimulatorModeRandom  SimulatorMode = "random"
+)
+
+func ParseSimulatorMode(value string) (SimulatorMode, error) {
+	switch mode := SimulatorMode(value); mode {
+	case SimulatorModeSucceed, SimulatorModeDecline, SimulatorModeTimeout, SimulatorModeRandom:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown payment simulator mode %q", value)
+	}
+}
+
+type SimulatorConfig struct {
+	Mode        SimulatorMode
+	Latency     time.Duration // simulated processing time per call
+	FailureRate float64       // probability of a decline in random mode
+}
+
+type simulatedAuthorization struct {
+	amount   models.Money
+	captured bool
+	voided   bool
+	refunded models.Money
+}
+
+// SimulatorGateway is an in-process PaymentGateway. Its mode decides how
+// authorizations behave: always succeed, always decline, hang until the
+// caller's context expires, or decline at random.
+type SimulatorGateway struct {
+	config SimulatorConfig
+
+	mu             sync.Mutex
+	rng            *rand.Rand
+	authorizations map[string]*simulatedAuthorization
+	byBooking      map[uuid.UUID]string // booking ID -> latest authorization ID
+	refunds        map[string]string    // idempotency key -> refund ID
+}
+
+func NewSimulatorGateway(config SimulatorConfig) *SimulatorGateway {
+	return &SimulatorGateway{
+		config:         config,
+		rng:            rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec // simulated failures need no secure source
+		authorizations: make(map[string]*simulatedAuthorization),
+		byBooking:      make(map[uuid.UUID]string),
+		refunds:        make(map[string]string),
+	}
+}
+
+func (g *SimulatorGateway) Authorize(ctx context.Context, bookingID uuid.UUID, amount models.Money) (string, error) {
+	if g.config.Mode == SimulatorModeTimeout {
+		<-ctx.Done()
+		return "", fmt.Errorf("%w: %v", ErrPaymentTimeout, ctx.Err())
+	}
+
+	if err := g.wait(ctx); err != nil {
+		return "", err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if authorizationID, ok := g.byBooking[bookingID]; ok && !g.authorizations[authorizationID].voided {
+		return authorizationID, nil
+	}
+
+	if g.config.Mode == SimulatorModeDecline ||
+		(g.config.Mode == SimulatorModeRandom && g.rng.Float64() < g.config.FailureRate) {
+		return "", fmt.Errorf("%w for booking %s", ErrPaymentDeclined, bookingID)
+	}
+
+	authorizationID := "sim_auth_" + uuid.NewString()
+	g.authorizations[authorizationID] = &simulatedAuthorization{amount: amount, refunded: models.Zero(amount.Currency)}
+	g.byBooking[bookingID] = authorizationID
+
+	return authorizationID, nil
+}
+
+func (g *SimulatorGateway) Capture(ctx context.Context, authorizationID string, amount models.Money) error {
+	if err := g.wait(ctx); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[authorizationID]
+	if !ok {
+		return fmt.Errorf("authorization %s not found", authorizationID)
+	}
+	if auth.voided {
+		return fmt.Errorf("authorization %s has been voided", authorizationID)
+	}
+	if amount.Currency != auth.amount.Currency {
+		return fmt.Errorf("capture currency %s does not match authorized currency %s", amount.Currency, auth.amount.Currency)
+	}
+	if auth.captured {
+		if amount == auth.amount {
+			return nil
+		}
+		return fmt.Errorf("authorization %s already captured", authorizationID)
+	}
+	if amount.Cmp(auth.amount) > 0 {
+		return fmt.Errorf("capture amount %s exceeds authorized amount %s", amount, auth.amount)
+	}
+
+	auth.captured = true
+	auth.amount = amount
+
+	return nil
+}
+
+func (g *SimulatorGateway) Void(ctx context.Context, authorizationID string) error {
+	if err := g.wait(ctx); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[authorizationID]
+	if !ok {
+		return fmt.Errorf("authorization %s not found", authorizationID)
+	}
+	if auth.captured {
+		return fmt.Errorf("authorization %s already captured, refund it instead", authorizationID)
+	}
+
+	auth.voided = true
+
+	return nil
+}
+
+func (g *SimulatorGateway) Refund(ctx context.Context, authorizationID, idempotencyKey string, amount models.Money) (string, error) {
+	if err := g.wait(ctx); err != nil {
+		return "", err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if refundID, ok := g.refunds[idempotencyKey]; ok {
+		return refundID, nil
+	}
+
+	auth, ok := g.authorizations[authorizationID]
+	if !ok {
+		return "", fmt.Errorf("authorization %s not found", authorizationID)
+	}
+	if !auth.captured {
+		return "", fmt.Errorf("authorization %s has not been captured", authorizationID)
+	}
+	if amount.Currency != auth.amount.Currency {
+		return "", fmt.Errorf("refund currency %s does not match captured currency %s", amount.Currency, auth.amount.Currency)
+	}
+	if remaining := auth.amount.Sub(auth.refunded); amount.Cmp(remaining) > 0 {
+		return "", fmt.Errorf("refund amount %s exceeds remaining captured amount %s", amount, remaining)
+	}
+
+	auth.refunded = auth.refunded.Add(amount)
+	refundID := "sim_refund_" + uuid.NewString()
+	g.refunds[idempotencyKey] = refundID
+
+	return refundID, nil
+}
+
+// wait simulates the gateway's processing time
+func (g *SimulatorGateway) wait(ctx context.Context) error {
+	if g.config.Latency <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(g.config.Latency)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %v", ErrPaymentTimeout, ctx.Err())
+	}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidPromoCode is returned when a promo code's settings are inconsistent
+	ErrInvalidPromoCode = errors.New("invalid promo code")
+
+	// ErrPromoCodeExists is returned when creating a code that is already in use
+	ErrPromoCodeExists = errors.New("promo code already exists")
+
+	// ErrPromoCodeInUse is returned when deleting a promo code that bookings
+	// have used. Deactivate it instead.
+	ErrPromoCodeInUse = errors.New("promo code has been used by bookings, deactivate it instead")
+
+	// ErrPromoCodeRejected is returned when a booking's promo code does not
+	// apply to it
+	ErrPromoCodeRejected = errors.New("promo code cannot be applied")
+)
+
+type PromoCodeService struct {
+	promoCodeRepo repository.PromoCodeRepositoryInterface
+	eventRepo     repository.EventRepositoryInterface
+}
+
+func NewPromoCodeService(promoCodeRepo repository.PromoCodeRepositoryInterface, eventRepo repository.EventRepositoryInterface) *PromoCodeService {
+	return &PromoCodeService{
+		promoCodeRepo: promoCodeRepo,
+		eventRepo:     eventRepo,
+	}
+}
+
+// NormalizePromoCode returns the stored form of a code. Codes are matched
+// case-insensitively.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *PromoCodeService) CreatePromoCode(ctx context.Context, req *models.CreatePromoCodeRequest) (*models.PromoCode, error) {
+	promoCode := &models.PromoCode{
+		Code:           NormalizePromoCode(req.Code),
+		Description:    req.Description,
+		DiscountType:   req.DiscountType,
+		PercentOff:     req.PercentOff,
+		AmountOff:      req.AmountOff,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerUser:     req.MaxPerUser,
+		MinQuantity:    req.MinQuantity,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		Active:         true,
+	}
+
+	if promoCode.MinQuantity == 0 {
+		promoCode.MinQuantity = 1
+	}
+
+	if req.EventID != nil {
+		eventID, err := uuid.Parse(*req.EventID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event ID: %w", err)
+		}
+
+		event, err := s.eventRepo.GetByID(ctx, eventID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPromoCode, err)
+		}
+		promoCode.EventID = &event.ID
+
+		if promoCode.AmountOff != nil && promoCode.AmountOff.Currency != event.Currency {
+			return nil, fmt.Errorf("%w: amount_off must be in the event's currency %s", ErrInvalidPromoCode, event.Currency)
+		}
+
+		if req.TicketTypeID != nil {
+			ticketTypeID, err := uuid.Parse(*req.TicketTypeID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ticket type ID: %w", err)
+			}
+			if _, err := findTicketType(event, ticketTypeID); err != nil {
+				return nil, fmt.Errorf("%w: ticket type is not sold for this event", ErrInvalidPromoCode)
+			}
+			promoCode.TicketTypeID = &ticketTypeID
+		}
+	} else if req.TicketTypeID != nil {
+		return nil, fmt.Errorf("%w: event_id is required with ticket_type_id", ErrInvalidPromoCode)
+	}
+
+	if err := validatePromoCode(promoCode); err != nil {
+		return nil, err
+	}
+
+	err := s.promoCodeRepo.Create(ctx, promoCode)
+	if errors.Is(err, repository.ErrPromoCodeTaken) {
+		return nil, ErrPromoCodeExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return promoCode, nil
+}
+
+func (s *PromoCodeService) GetPromoCode(ctx context.Context, id uuid.UUID) (*models.PromoCode, error) {
+	return s.promoCodeRepo.GetByID(ctx, id)
+}
+
+func (s *PromoCodeService) GetPromoCodes(ctx context.Context, page *models.PageRequest) (*models.Page[*models.PromoCode], error) {
+	return s.promoCodeRepo.GetAll(ctx, page)
+}
+
+func (s *PromoCodeService) UpdatePromoCode(ctx context.Context, id uuid.UUID, req *models.UpdatePromoCodeRequest) (*models.PromoCode, error) {
+	promoCode, err := s.promoCodeRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update fields if provided
+	if req.Description != nil {
+		promoCode.Description = *req.Description
+	}
+	if req.PercentOff != nil {
+		promoCode.PercentOff = req.PercentOff
+	}
+	if req.AmountOff != nil {
+		if promoCode.AmountOff != nil && req.AmountOff.Currency != promoCode.AmountOff.Currency {
+			return nil, fmt.Errorf("%w: the currency of amount_off cannot change", ErrInvalidPromoCode)
+		}
+		promoCode.AmountOff = req.AmountOff
+	}
+	if req.MaxRedemptions != nil {
+		promoCode.MaxRedemptions = req.MaxRedemptions
+	}
+	if req.MaxPerUser != nil {
+		promoCode.MaxPerUser = req.MaxPerUser
+	}
+	if req.MinQuantity != nil {
+		promoCode.MinQuantity = *req.MinQuantity
+	}
+	if req.ValidFrom != nil {
+		promoCode.ValidFrom = req.ValidFrom
+	}
+	if req.ValidUntil != nil {
+		promoCode.ValidUntil = req.ValidUntil
+	}
+	if req.Active != nil {
+		promoCode.Active = *req.Active
+	}
+
+	if err := validatePromoCode(promoCode); err != nil {
+		return nil, err
+	}
+
+	if err := s.promoCodeRepo.Update(ctx, promoCode); err != nil {
+		return nil, err
+	}
+
+	return promoCode, nil
+}
+
+func (s *PromoCodeService) DeletePromoCode(ctx context.Context, id uuid.UUID) error {
+	err := s.promoCodeRepo.Delete(ctx, id)
+	if errors.Is(err, repository.ErrPromoCodeReferenced) {
+		return ErrPromoCodeInUse
+	}
+	return err
+}
+
+func validatePromoCode(promoCode *models.PromoCode) error {
+	switch promoCode.DiscountType {
+	case models.DiscountTypePercent:
+		if promoCode.PercentOff == nil || promoCode.AmountOff != nil {
+			return fmt.Errorf("%w: PERCENT codes take percent_off and no amount_off", ErrInvalidPromoCode)
+		}
+		if *promoCode.PercentOff <= 0 || *promoCode.PercentOff > models.HundredPercent {
+			return fmt.Errorf("%w: percent_off must be more than 0 and at most 100", ErrInvalidPromoCode)
+		}
+	case models.DiscountTypeFixed:
+		if promoCode.AmountOff == nil || promoCode.PercentOff != nil {
+			return fmt.Errorf("%w: FIXED codes take amount_off and no percent_off", ErrInvalidPromoCode)
+		}
+		if !promoCode.AmountOff.IsPositive() {
+			return fmt.Errorf("%w: amount_off must be positive", ErrInvalidPromoCode)
+		}
+	}
+	if promoCode.ValidFrom != nil && promoCode.ValidUntil != nil && !promoCode.ValidFrom.Before(*promoCode.ValidUntil) {
+		return fmt.Errorf("%w: valid_from must be before valid_until", ErrInvalidPromoCode)
+	}
+	return nil
+}
+
+// applyPromoCode checks that a promo code applies to the booking and takes its
+// discount off the booking's total. It locks the code's row, so it must run
+// in the transaction that inserts the booking; the usage limits then count
+// every other booking that used the code.
+func applyPromoCode(ctx context.Context, repos *repository.Repositories, code string, booking *models.Booking, now time.Time) error {
+	promoCode, err := repos.PromoCodes.GetByCodeForUpdate(ctx, NormalizePromoCode(code))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPromoCodeRejected, err)
+	}
+
+	switch {
+	case !promoCode.Active:
+		return fmt.Errorf("%w: the code is no longer active", ErrPromoCodeRejected)
+	case promoCode.ValidFrom != nil && now.Before(*promoCode.ValidFrom):
+		return fmt.Errorf("%w: the code is not valid yet", ErrPromoCodeRejected)
+	case promoCode.ValidUntil != nil && !now.Before(*promoCode.ValidUntil):
+		return fmt.Errorf("%w: the code has expired", ErrPromoCodeRejected)
+	case promoCode.EventID != nil && *promoCode.EventID != booking.EventID:
+		return fmt.Errorf("%w: the code is not valid for this event", ErrPromoCodeRejected)
+	case promoCode.AmountOff != nil && promoCode.AmountOff.Currency != booking.TotalAmount.Currency:
+		return fmt.Errorf("%w: the code is not valid for payments in %s", ErrPromoCodeRejected, booking.TotalAmount.Currency)
+	}
+
+	eligible := promoCode.EligibleQuantity(booking.Items)
+	if eligible == 0 {
+		return fmt.Errorf("%w: the code does not apply to the selected tickets", ErrPromoCodeRejected)
+	}
+	if eligible < promoCode.MinQuantity {
+		return fmt.Errorf("%w: the code requires at least %d eligible tickets", ErrPromoCodeRejected, promoCode.MinQuantity)
+	}
+
+	total, byUser, err := repos.PromoCodes.CountRedemptions(ctx, promoCode.ID, booking.UserID)
+	if err != nil {
+		return err
+	}
+	if promoCode.MaxRedemptions != nil && total >= *promoCode.MaxRedemptions {
+		return fmt.Errorf("%w: the code has been fully redeemed", ErrPromoCodeRejected)
+	}
+	if promoCode.MaxPerUser != nil && byUser >= *promoCode.MaxPerUser {
+		return fmt.Errorf("%w: you have already used this code", ErrPromoCodeRejected)
+	}
+
+	discount := promoCode.Discount(booking.Items)
+	booking.PromoCodeID = &promoCode.ID
+	booking.DiscountAmount = discount
+	booking.TotalAmount = booking.TotalAmount.Sub(discount)
+
+	return nil
+}
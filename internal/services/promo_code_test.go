@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPromoCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockPromoCodeRepository) Create(ctx context.Context, promoCode *models.PromoCode) error {
+	args := m.Called(promoCode)
+	return args.Error(0)
+}
+
+func (m *MockPromoCodeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PromoCode, error) {
+	args := m.Called(id)
+	promoCode, _ := args.Get(0).(*models.PromoCode)
+	return promoCode, args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) GetByCodeForUpdate(ctx context.Context, code string) (*models.PromoCode, error) {
+	args := m.Called(code)
+	promoCode, _ := args.Get(0).(*models.PromoCode)
+	return promoCode, args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) CountRedemptions(ctx context.Context, id, userID uuid.UUID) (int, int, error) {
+	args := m.Called(id, userID)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockPromoCodeRepository) GetAll(ctx context.Context, page *models.PageRequest) (*models.Page[*models.PromoCode], error) {
+	args := m.Called(page)
+	return args.Get(0).(*models.Page[*models.PromoCode]), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) Update(ctx context.Context, promoCode *models.PromoCode) error {
+	args := m.Called(promoCode)
+	return args.Error(0)
+}
+
+func (m *MockPromoCodeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func percentOff(percent int) *models.Percent {
+	p := models.Percent(percent) * models.OnePercent
+	return &p
+}
+
+func amountOff(amount models.Money) *models.Money {
+	return &amount
+}
+
+func TestPromoCode_Discount(t *testing.T) {
+	standardID, vipID := uuid.New(), uuid.New()
+	items := []*models.BookingItem{
+		{TicketTypeID: standardID, Quantity: 3, UnitPrice: usd(3333)},
+		{TicketTypeID: vipID, Quantity: 1, UnitPrice: usd(12000)},
+	}
+
+	tests := []struct {
+		name     string
+		code     models.PromoCode
+		expected models.Money
+	}{
+		{"percentage rounds to cents", models.PromoCode{DiscountType: models.DiscountTypePercent, PercentOff: percentOff(15)}, usd(3300)}, // 32.9985
+		{"fixed amount", models.PromoCode{DiscountType: models.DiscountTypeFixed, AmountOff: amountOff(usd(2500))}, usd(2500)},
+		{"fixed amount capped at the price", models.PromoCode{DiscountType: models.DiscountTypeFixed, AmountOff: amountOff(usd(50000))}, usd(21999)},
+		{"limited to a ticket type", models.PromoCode{DiscountType: models.DiscountTypePercent, PercentOff: percentOff(50), TicketTypeID: &vipID}, usd(6000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.code.Discount(items))
+		})
+	}
+}
+
+func newPromoTestService() (*BookingService, *MockBookingRepository, *MockEventRepository, *MockPromoCodeRepository) {
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	mockPromoCodeRepo := &MockPromoCodeRepository{}
+
+	uow := newMockUnitOfWork(mockBookingRepo, mockEventRepo)
+	uow.repos.PromoCodes = mockPromoCodeRepo
+
+	return NewBookingService(mockBookingRepo, mockEventRepo, uow, 15, logging.Discard()), mockBookingRepo, mockEventRepo, mockPromoCodeRepo
+}
+
+func TestBookingService_CreateBooking_PromoCode(t *testing.T) {
+	// Setup
+	service, mockBookingRepo, mockEventRepo, mockPromoCodeRepo := newPromoTestService()
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	promoCode := &models.PromoCode{
+		ID:             uuid.New(),
+		Code:           "SUMMER20",
+		DiscountType:   models.DiscountTypePercent,
+		PercentOff:     percentOff(20),
+		MaxRedemptions: intPtr(100),
+		MaxPerUser:     intPtr(1),
+		MinQuantity:    2,
+		EventID:        &event.ID,
+		Active:         true,
+	}
+
+	// Mock expectations: the code is looked up case-insensitively
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, singleItem(event, 2)).Return(nil)
+	mockPromoCodeRepo.On("GetByCodeForUpdate", "SUMMER20").Return(promoCode, nil)
+	mockPromoCodeRepo.On("CountRedemptions", promoCode.ID, userID).Return(10, 0, nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Return(nil)
+
+	// Test
+	booking, err := service.CreateBooking(context.Background(), userID, &models.CreateBookingRequest{
+		EventID:   event.ID.String(),
+		Items:     []models.BookingItemRequest{{TicketTypeID: event.TicketTypes[0].ID.String(), Quantity: 2}},
+		PromoCode: " summer20",
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, usd(1200), booking.DiscountAmount) // 20% of 2 * 30
+	assert.Equal(t, usd(4800), booking.TotalAmount)
+	assert.Equal(t, &promoCode.ID, booking.PromoCodeID)
+	mockPromoCodeRepo.AssertExpectations(t)
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestBookingService_CreateBooking_PromoCodeRejected(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	otherEventID := uuid.New()
+
+	tests := []struct {
+		name          string
+		modify        func(p *models.PromoCode)
+		total, byUser int
+		message       string
+	}{
+		{"inactive", func(p *models.PromoCode) { p.Active = false }, 0, 0, "no longer active"},
+		{"not valid yet", func(p *models.PromoCode) { p.ValidFrom = &future }, 0, 0, "not valid yet"},
+		{"expired", func(p *models.PromoCode) { p.ValidUntil = &past }, 0, 0, "expired"},
+		{"other event", func(p *models.PromoCode) { p.EventID = &otherEventID }, 0, 0, "not valid for this event"},
+		{"minimum quantity", func(p *models.PromoCode) { p.MinQuantity = 3 }, 0, 0, "at least 3"},
+		{"other currency", func(p *models.PromoCode) { p.AmountOff = amountOff(models.NewMoney(1000, "EUR")) }, 0, 0, "payments in USD"},
+		{"fully redeemed", func(p *models.PromoCode) { p.MaxRedemptions = intPtr(5) }, 5, 0, "fully redeemed"},
+		{"per user limit", func(p *models.PromoCode) { p.MaxPerUser = intPtr(1) }, 3, 1, "already used"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			service, mockBookingRepo, mockEventRepo, mockPromoCodeRepo := newPromoTestService()
+
+			userID := uuid.New()
+			event := newTieredEvent()
+			promoCode := &models.PromoCode{
+				ID:           uuid.New(),
+				Code:         "SAVE10",
+				DiscountType: models.DiscountTypeFixed,
+				AmountOff:    amountOff(usd(1000)),
+				MinQuantity:  1,
+				Active:       true,
+			}
+			tt.modify(promoCode)
+
+			// Mock expectations
+			mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+			mockEventRepo.On("ReserveTickets", event.ID, mock.Anything).Return(nil)
+			mockPromoCodeRepo.On("GetByCodeForUpdate", "SAVE10").Return(promoCode, nil)
+			mockPromoCodeRepo.On("CountRedemptions", promoCode.ID, userID).Return(tt.total, tt.byUser, nil)
+
+			// Test
+			_, err := service.CreateBooking(context.Background(), userID, &models.CreateBookingRequest{
+				EventID:   event.ID.String(),
+				Items:     []models.BookingItemRequest{{TicketTypeID: event.TicketTypes[0].ID.String(), Quantity: 2}},
+				PromoCode: "SAVE10",
+			})
+
+			// Assertions
+			assert.ErrorIs(t, err, ErrPromoCodeRejected)
+			assert.Contains(t, err.Error(), tt.message)
+			mockBookingRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestPromoCodeService_CreatePromoCode_Validation(t *testing.T) {
+	eventID := uuid.New().String()
+	ticketTypeID := uuid.New().String()
+
+	tests := []struct {
+		name string
+		req  models.CreatePromoCodeRequest
+	}{
+		{"percentage over 100", models.CreatePromoCodeRequest{Code: "HALF", DiscountType: models.DiscountTypePercent, PercentOff: percentOff(150)}},
+		{"percentage without percent_off", models.CreatePromoCodeRequest{Code: "HALF", DiscountType: models.DiscountTypePercent, AmountOff: amountOff(usd(500))}},
+		{"fixed amount not positive", models.CreatePromoCodeRequest{Code: "ZERO", DiscountType: models.DiscountTypeFixed, AmountOff: amountOff(usd(0))}},
+		{"ticket type without event", models.CreatePromoCodeRequest{Code: "VIP", DiscountType: models.DiscountTypeFixed, AmountOff: amountOff(usd(500)), TicketTypeID: &ticketTypeID}},
+		{"unknown event", models.CreatePromoCodeRequest{Code: "EVENT", DiscountType: models.DiscountTypeFixed, AmountOff: amountOff(usd(500)), EventID: &eventID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockPromoCodeRepo := &MockPromoCodeRepository{}
+			mockEventRepo := &MockEventRepository{}
+			service := NewPromoCodeService(mockPromoCodeRepo, mockEventRepo)
+			mockEventRepo.On("GetByID", mock.Anything).Return((*models.Event)(nil), fmt.Errorf("event not found"))
+
+			// Test
+			_, err := service.CreatePromoCode(context.Background(), &tt.req)
+
+			// Assertions
+			assert.ErrorIs(t, err, ErrInvalidPromoCode)
+			mockPromoCodeRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestPromoCodeService_CreatePromoCode_Normalizes(t *testing.T) {
+	// Setup
+	mockPromoCodeRepo := &MockPromoCodeRepository{}
+	service := NewPromoCodeService(mockPromoCodeRepo, &MockEventRepository{})
+
+	// Mock expectations
+	mockPromoCodeRepo.On("Create", mock.AnythingOfType("*models.PromoCode")).Return(nil)
+
+	// Test
+	promoCode, err := service.CreatePromoCode(context.Background(), &models.CreatePromoCodeRequest{
+		Code:         "earlybird",
+		DiscountType: models.DiscountTypeFixed,
+		AmountOff:    amountOff(usd(500)),
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, "EARLYBIRD", promoCode.Code)
+	assert.Equal(t, 1, promoCode.MinQuantity)
+	assert.True(t, promoCode.Active)
+	mockPromoCodeRepo.AssertExpectations(t)
+}
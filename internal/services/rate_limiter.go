@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const rateLimitKeyPrefix = "rate_limit:"
+
+// RateLimit allows Limit requests in any period of Window
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitResult is the outcome of counting a request against a RateLimit
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // requests left in the window
+	RetryAfter time.Duration // until a request is allowed again, when refused
+}
+
+// ParseRateLimits parses per-route limits separated by commas, each written as
+// "METHOD /route=limit/window", e.g. "POST /api/v1/bookings=10/1m". The route
+// is the pattern it is registered with, such as /api/v1/holds/:id/checkout.
+func ParseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, rate, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		count, window, hasWindow := strings.Cut(rate, "/")
+		if !ok || !hasPath || !hasWindow {
+			return nil, fmt.Errorf("invalid rate limit %q, want \"METHOD /route=limit/window\"", entry)
+		}
+
+		limit, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid rate limit %q: limit must be a positive number", entry)
+		}
+
+		duration, err := time.ParseDuration(strings.TrimSpace(window))
+		if err != nil || duration < time.Second {
+			return nil, fmt.Errorf("invalid rate limit %q: window must be a duration of at least 1s", entry)
+		}
+
+		limits[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = RateLimit{Limit: limit, Window: duration}
+	}
+
+	return limits, nil
+}
+
+// Counts a request in sliding window logs: sorted sets of the request times
+// in the window, one per key. The request is allowed only if every key is
+// under the limit, and is then counted against all of them. The time is
+// Redis's, so every instance uses the same clock. Returns whether the request
+// is allowed, the requests remaining under the fullest key and, when refused,
+// the milliseconds until every key has room again.
+var slidingWindowScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+local fullest = 0
+local retry = 0
+for _, key in ipairs(KEYS) do
+	redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+	local count = redis.call('ZCARD', key)
+	if count > fullest then
+		fullest = count
+	end
+	if count >= limit then
+		local oldest = redis.call('ZRANGE', key, 0, count - limit, 'WITHSCORES')
+		local wait = tonumber(oldest[#oldest]) + window - now
+		if wait > retry then
+			retry = wait
+		end
+	end
+end
+
+if fullest >= limit then
+	return {0, 0, retry}
+end
+
+for _, key in ipairs(KEYS) do
+	redis.call('ZADD', key, now, ARGV[3])
+	redis.call('PEXPIRE', key, window)
+end
+return {1, limit - fullest - 1, 0}
+`)
+
+// RateLimiter counts requests in Redis, so limits hold across every instance
+type RateLimiter struct {
+	rdb *redis.Client
+}
+
+func NewRateLimiter(rdb *redis.Client) *RateLimiter {
+	return &RateLimiter{rdb: rdb}
+}
+
+// Allow counts a request against key and reports whether it is within limit.
+// Refused requests are not counted.
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	return l.AllowAll(ctx, []string{key}, limit)
+}
+
+// AllowAll counts a request against every key and reports whether it is
+// within limit for all of them. Refused requests are not counted against any
+// key.
+func (l *RateLimiter) AllowAll(ctx context.Context, keys []string, limit RateLimit) (*RateLimitResult, error) {
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = rateLimitKeyPrefix + key
+	}
+
+	values, err := slidingWindowScript.Run(ctx, l.rdb,
+		redisKeys,
+		limit.Window.Milliseconds(), limit.Limit, uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits("POST /api/v1/bookings=10/1m, post /api/v1/holds/:id/checkout=3/30s,")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]RateLimit{
+		"POST /api/v1/bookings":           {Limit: 10, Window: time.Minute},
+		"POST /api/v1/holds/:id/checkout": {Limit: 3, Window: 30 * time.Second},
+	}, limits)
+
+	empty, err := ParseRateLimits("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestParseRateLimits_Invalid(t *testing.T) {
+	tests := []string{
+		"POST /api/v1/bookings",
+		"/api/v1/bookings=10/1m",
+		"POST /api/v1/bookings=10",
+		"POST /api/v1/bookings=0/1m",
+		"POST /api/v1/bookings=ten/1m",
+		"POST /api/v1/bookings=10/soon",
+		"POST /api/v1/bookings=10/10ms",
+	}
+
+	for _, value := range tests {
+		t.Run(value, func(t *testing.T) {
+			_, err := ParseRateLimits(value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRateLimiter_SlidingWindow(t *testing.T) {
+	// Setup
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	start := time.Now()
+	mr.SetTime(start)
+
+	limiter := NewRateLimiter(rdb)
+	limit := RateLimit{Limit: 2, Window: time.Minute}
+	ctx := context.Background()
+
+	// Test: two requests fill the window
+	first, err := limiter.Allow(ctx, "bookings:user:1", limit)
+	require.NoError(t, err)
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 1, first.Remaining)
+
+	mr.SetTime(start.Add(20 * time.Second))
+	second, err := limiter.Allow(ctx, "bookings:user:1", limit)
+	require.NoError(t, err)
+	assert.True(t, second.Allowed)
+	assert.Equal(t, 0, second.Remaining)
+
+	// The third is refused until the first leaves the window
+	mr.SetTime(start.Add(30 * time.Second))
+	third, err := limiter.Allow(ctx, "bookings:user:1", limit)
+	require.NoError(t, err)
+	assert.False(t, third.Allowed)
+	assert.Equal(t, 30*time.Second, third.RetryAfter)
+
+	// Other keys have their own window
+	other, err := limiter.Allow(ctx, "bookings:user:2", limit)
+	require.NoError(t, err)
+	assert.True(t, other.Allowed)
+
+	// Once the first request leaves the window, one more is allowed
+	mr.SetTime(start.Add(61 * time.Second))
+	fourth, err := limiter.Allow(ctx, "bookings:user:1", limit)
+	require.NoError(t, err)
+	assert.True(t, fourth.Allowed)
+	assert.Equal(t, 0, fourth.Remaining)
+}
+
+func TestRateLimiter_AllowAll(t *testing.T) {
+	// Setup
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	start := time.Now()
+	mr.SetTime(start)
+
+	limiter := NewRateLimiter(rdb)
+	limit := RateLimit{Limit: 2, Window: time.Minute}
+	ctx := context.Background()
+
+	// Test: the user fills their window from one IP
+	_, err := limiter.Allow(ctx, "bookings:user:1", limit)
+	require.NoError(t, err)
+	mr.SetTime(start.Add(10 * time.Second))
+	_, err = limiter.Allow(ctx, "bookings:user:1", limit)
+	require.NoError(t, err)
+
+	// From a fresh IP the user is still refused, and the IP is not counted
+	mr.SetTime(start.Add(20 * time.Second))
+	refused, err := limiter.AllowAll(ctx, []string{"bookings:ip:192.0.2.2", "bookings:user:1"}, limit)
+	require.NoError(t, err)
+	assert.False(t, refused.Allowed)
+	assert.Equal(t, 40*time.Second, refused.RetryAfter)
+
+	allowed, err := limiter.AllowAll(ctx, []string{"bookings:ip:192.0.2.2", "bookings:user:2"}, limit)
+	require.NoError(t, err)
+	assert.True(t, allowed.Allowed)
+	assert.Equal(t, 1, allowed.Remaining, "counted against the fuller key")
+
+	// Remaining is that of the fullest key
+	last, err := limiter.AllowAll(ctx, []string{"bookings:ip:192.0.2.2", "bookings:user:3"}, limit)
+	require.NoError(t, err)
+	assert.True(t, last.Allowed)
+	assert.Equal(t, 0, last.Remaining)
+}
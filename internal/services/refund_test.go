@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRefundRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefundRepository) Create(ctx context.Context, refund *models.Refund) error {
+	args := m.Called(refund)
+	return args.Error(0)
+}
+
+func (m *MockRefundRepository) GetByBookingID(ctx context.Context, bookingID uuid.UUID) (*models.Refund, error) {
+	args := m.Called(bookingID)
+	refund, _ := args.Get(0).(*models.Refund)
+	return refund, args.Error(1)
+}
+
+func (m *MockRefundRepository) UpdateStatus(ctx context.Context, refund *models.Refund) error {
+	args := m.Called(refund)
+	return args.Error(0)
+}
+
+func newRefundTestService() (*BookingService, *MockBookingRepository, *MockEventRepository, *MockRefundRepository) {
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	mockRefundRepo := &MockRefundRepository{}
+
+	uow := newMockUnitOfWork(mockBookingRepo, mockEventRepo)
+	uow.repos.Refunds = mockRefundRepo
+
+	return NewBookingService(mockBookingRepo, mockEventRepo, uow, 15, logging.Discard()), mockBookingRepo, mockEventRepo, mockRefundRepo
+}
+
+func newConfirmedBooking(userID, eventID uuid.UUID) *models.Booking {
+	reference := "sim_auth_test"
+	return &models.Booking{
+		ID:               uuid.New(),
+		UserID:           userID,
+		EventID:          eventID,
+		Quantity:         2,
+		Status:           models.BookingStatusConfirmed,
+		TotalAmount:      usd(10000),
+		PaymentReference: &reference,
+	}
+}
+
+func TestCancellationPolicy_RefundAmount(t *testing.T) {
+	policy := models.CancellationPolicy{FullRefundHours: 48, NoRefundHours: 24, PartialRefundPercent: 50}
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		hoursLeft  time.Duration
+		wantAmount models.Money
+		wantOK     bool
+	}{
+		{"full refund before the full refund window closes", 72, usd(7551), true},
+		{"partial refund keeps the rounded fee", 30, usd(3775), true}, // fee 37.755 rounds to 37.76
+		{"no refund close to the event", 12, usd(0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, ok := policy.RefundAmount(usd(7551), now.Add(tt.hoursLeft*time.Hour), now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantAmount, amount)
+		})
+	}
+}
+
+func TestBookingService_RefundBooking_PartialRefund(t *testing.T) {
+	// Setup
+	service, mockBookingRepo, mockEventRepo, mockRefundRepo := newRefundTestService()
+
+	userID := uuid.New()
+	event := &models.Event{
+		ID:                 uuid.New(),
+		DateTime:           time.Now().Add(30 * time.Hour),
+		CancellationPolicy: models.CancellationPolicy{FullRefundHours: 48, NoRefundHours: 24, PartialRefundPercent: 25},
+	}
+	booking := newConfirmedBooking(userID, event.ID)
+
+	// Mock expectations
+	mockBookingRepo.On("GetByIDForUpdate", booking.ID).Return(booking, nil)
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockBookingRepo.On("UpdateStatus", booking.ID, models.BookingStatusRefunded).Return(nil)
+	mockRefundRepo.On("Create", mock.AnythingOfType("*models.Refund")).Return(nil)
+
+	// Test
+	refund, err := service.RefundBooking(context.Background(), booking.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, booking.ID, refund.BookingID)
+	assert.Equal(t, usd(2500), refund.Amount)
+	assert.Equal(t, models.RefundStatusPending, refund.Status)
+
+	mockBookingRepo.AssertExpectations(t)
+	mockEventRepo.AssertExpectations(t)
+	mockRefundRepo.AssertExpectations(t)
+}
+
+func TestBookingService_RefundBooking_WindowClosed(t *testing.T) {
+	// Setup
+	service, mockBookingRepo, mockEventRepo, mockRefundRepo := newRefundTestService()
+
+	userID := uuid.New()
+	event := &models.Event{
+		ID:                 uuid.New(),
+		DateTime:           time.Now().Add(2 * time.Hour),
+		CancellationPolicy: models.DefaultCancellationPolicy,
+	}
+	booking := newConfirmedBooking(userID, event.ID)
+
+	// Mock expectations
+	mockBookingRepo.On("GetByIDForUpdate", booking.ID).Return(booking, nil)
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+
+	// Test
+	refund, err := service.RefundBooking(context.Background(), booking.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrRefundWindowClosed)
+	assert.Nil(t, refund)
+	mockBookingRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+	mockRefundRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBookingService_RefundBooking_NotConfirmed(t *testing.T) {
+	// Setup
+	service, mockBookingRepo, _, _ := newRefundTestService()
+
+	userID := uuid.New()
+	booking := newConfirmedBooking(userID, uuid.New())
+	booking.Status = models.BookingStatusPending
+
+	// Mock expectations
+	mockBookingRepo.On("GetByIDForUpdate", booking.ID).Return(booking, nil)
+
+	// Test
+	_, err := service.RefundBooking(context.Background(), booking.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrBookingNotRefundable)
+}
+
+func TestBookingService_RefundBooking_OtherUser(t *testing.T) {
+	// Setup
+	service, mockBookingRepo, _, _ := newRefundTestService()
+
+	booking := newConfirmedBooking(uuid.New(), uuid.New())
+
+	// Mock expectations
+	mockBookingRepo.On("GetByIDForUpdate", booking.ID).Return(booking, nil)
+
+	// Test
+	_, err := service.RefundBooking(context.Background(), booking.ID, &models.Caller{UserID: uuid.New(), Role: models.RoleCustomer})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrForbidden)
+	mockBookingRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
+
+func TestBookingService_RefundBooking_RetryReturnsExistingRefund(t *testing.T) {
+	// Setup
+	service, mockBookingRepo, _, mockRefundRepo := newRefundTestService()
+
+	userID := uuid.New()
+	booking := newConfirmedBooking(userID, uuid.New())
+	booking.Status = models.BookingStatusRefunded
+	existing := &models.Refund{ID: uuid.New(), BookingID: booking.ID, Amount: usd(10000), Status: models.RefundStatusFailed}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByIDForUpdate", booking.ID).Return(booking, nil)
+	mockRefundRepo.On("GetByBookingID", booking.ID).Return(existing, nil)
+
+	// Test
+	refund, err := service.RefundBooking(context.Background(), booking.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, existing, refund)
+	mockRefundRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestPaymentService_ProcessRefund(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockRefundRepo := &MockRefundRepository{}
+	gateway := NewSimulatorGateway(SimulatorConfig{Mode: SimulatorModeSucceed})
+	service := NewPaymentService(nil, mockBookingRepo, mockRefundRepo, gateway, 50*time.Millisecond, RetryPolicy{MaxAttempts: 3}, logging.Discard())
+
+	ctx := context.Background()
+	booking := newConfirmedBooking(uuid.New(), uuid.New())
+	authorizationID, err := gateway.Authorize(ctx, booking.ID, booking.TotalAmount)
+	require.NoError(t, err)
+	require.NoError(t, gateway.Capture(ctx, authorizationID, booking.TotalAmount))
+	booking.PaymentReference = &authorizationID
+
+	refund := &models.Refund{ID: uuid.New(), BookingID: booking.ID, Amount: usd(6000), Status: models.RefundStatusPending}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockRefundRepo.On("UpdateStatus", refund).Return(nil)
+
+	// Test
+	require.NoError(t, service.ProcessRefund(ctx, refund))
+
+	// Assertions
+	assert.Equal(t, models.RefundStatusCompleted, refund.Status)
+	require.NotNil(t, refund.RefundReference)
+
+	// A completed refund is not sent to the gateway again
+	require.NoError(t, service.ProcessRefund(ctx, refund))
+	mockRefundRepo.AssertNumberOfCalls(t, "UpdateStatus", 1)
+}
+
+func TestPaymentService_ProcessRefund_GatewayFailure(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockRefundRepo := &MockRefundRepository{}
+	service := newTestPaymentService(mockBookingRepo, SimulatorModeSucceed)
+	service.refundRepo = mockRefundRepo
+
+	// The gateway has never seen this payment, so the refund is rejected
+	booking := newConfirmedBooking(uuid.New(), uuid.New())
+	refund := &models.Refund{ID: uuid.New(), BookingID: booking.ID, Amount: usd(10000), Status: models.RefundStatusPending}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockRefundRepo.On("UpdateStatus", refund).Return(nil)
+
+	// Test
+	err := service.ProcessRefund(context.Background(), refund)
+
+	// Assertions
+	assert.Error(t, err)
+	assert.Equal(t, models.RefundStatusFailed, refund.Status)
+	mockRefundRepo.AssertExpectations(t)
+}
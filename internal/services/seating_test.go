@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockVenueRepository struct {
+	mock.Mock
+}
+
+func (m *MockVenueRepository) Create(ctx context.Context, venue *models.Venue) error {
+	args := m.Called(venue)
+	return args.Error(0)
+}
+
+func (m *MockVenueRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Venue, error) {
+	args := m.Called(id)
+	venue, _ := args.Get(0).(*models.Venue)
+	return venue, args.Error(1)
+}
+
+func (m *MockVenueRepository) GetAll(ctx context.Context, page *models.PageRequest) (*models.Page[*models.Venue], error) {
+	args := m.Called(page)
+	return args.Get(0).(*models.Page[*models.Venue]), args.Error(1)
+}
+
+func (m *MockVenueRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockVenueRepository) CountSeats(ctx context.Context, id uuid.UUID) (int, error) {
+	args := m.Called(id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockVenueRepository) GetSeatMap(ctx context.Context, venueID, eventID uuid.UUID) ([]*models.Section, error) {
+	args := m.Called(venueID, eventID)
+	sections, _ := args.Get(0).([]*models.Section)
+	return sections, args.Error(1)
+}
+
+func (m *MockVenueRepository) GetSeatZones(ctx context.Context, venueID uuid.UUID, seatIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	args := m.Called(venueID, seatIDs)
+	zones, _ := args.Get(0).(map[uuid.UUID]string)
+	return zones, args.Error(1)
+}
+
+func newSeatedEvent() *models.Event {
+	venueID := uuid.New()
+	return &models.Event{
+		ID:           uuid.New(),
+		Name:         "Seated Event",
+		DateTime:     time.Now().Add(24 * time.Hour),
+		TotalTickets: 100,
+		Currency:     "USD",
+		TicketPrice:  usd(4000),
+		TicketTypes:  generalAdmission(usd(4000), 100),
+		VenueID:      &venueID,
+	}
+}
+
+func TestBookingService_CreateBooking_Seats(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+
+	event := newSeatedEvent()
+	seatIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	// Mock expectations: the quantity is the number of seats
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, singleItem(event, 2)).Return(nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Return(nil)
+	mockBookingRepo.On("ClaimSeats", mock.Anything, event.ID, *event.VenueID, seatIDs).Return(nil)
+
+	// Test
+	booking, err := service.CreateBooking(context.Background(), uuid.New(), &models.CreateBookingRequest{
+		EventID: event.ID.String(),
+		SeatIDs: []string{seatIDs[0].String(), seatIDs[1].String()},
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, 2, booking.Quantity)
+	assert.Equal(t, usd(8000), booking.TotalAmount)
+	assert.Equal(t, seatIDs, booking.SeatIDs)
+	mockEventRepo.AssertExpectations(t)
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestBookingService_CreateBooking_SeatTaken(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+
+	event := newSeatedEvent()
+	seatID := uuid.New()
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, singleItem(event, 1)).Return(nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Return(nil)
+	mockBookingRepo.On("ClaimSeats", mock.Anything, event.ID, *event.VenueID, []uuid.UUID{seatID}).Return(repository.ErrSeatTaken)
+
+	// Test
+	booking, err := service.CreateBooking(context.Background(), uuid.New(), &models.CreateBookingRequest{
+		EventID: event.ID.String(),
+		SeatIDs: []string{seatID.String()},
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrSeatUnavailable)
+	assert.Nil(t, booking)
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestBookingService_CreateBooking_SeatZones(t *testing.T) {
+	frontSeat, backSeat := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		vip     int
+		seats   []uuid.UUID
+		wantErr bool
+	}{
+		{"zone ticket for zone seat", 1, []uuid.UUID{frontSeat}, false},
+		{"one ticket of each for one seat of each", 1, []uuid.UUID{frontSeat, backSeat}, false},
+		{"cheaper ticket for zone seat", 0, []uuid.UUID{frontSeat}, true},
+		{"zone ticket for other seat", 1, []uuid.UUID{backSeat}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup: VIP tickets are sold for the front zone, standard ones for
+			// every other seat
+			mockBookingRepo := &MockBookingRepository{}
+			mockEventRepo := &MockEventRepository{}
+			mockVenueRepo := &MockVenueRepository{}
+			uow := newMockUnitOfWork(mockBookingRepo, mockEventRepo)
+			uow.repos.Venues = mockVenueRepo
+			service := NewBookingService(mockBookingRepo, mockEventRepo, uow, 15, logging.Discard())
+
+			event := newSeatedEvent()
+			standard := event.TicketTypes[0]
+			vip := &models.TicketType{ID: uuid.New(), Name: "VIP", Price: usd(9000), Allocation: 10, Available: 10, Zone: "front"}
+			event.TicketTypes = append(event.TicketTypes, vip)
+
+			var items []models.BookingItemRequest
+			if tt.vip > 0 {
+				items = append(items, models.BookingItemRequest{TicketTypeID: vip.ID.String(), Quantity: tt.vip})
+			}
+			if standardTickets := len(tt.seats) - tt.vip; standardTickets > 0 {
+				items = append(items, models.BookingItemRequest{TicketTypeID: standard.ID.String(), Quantity: standardTickets})
+			}
+			seatIDs := make([]string, len(tt.seats))
+			for i, seatID := range tt.seats {
+				seatIDs[i] = seatID.String()
+			}
+
+			// Mock expectations
+			mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+			mockEventRepo.On("ReserveTickets", event.ID, mock.Anything).Return(nil)
+			mockVenueRepo.On("GetSeatZones", *event.VenueID, tt.seats).Return(map[uuid.UUID]string{frontSeat: "front", backSeat: ""}, nil)
+			mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Return(nil)
+			mockBookingRepo.On("ClaimSeats", mock.Anything, event.ID, *event.VenueID, tt.seats).Return(nil)
+
+			// Test
+			booking, err := service.CreateBooking(context.Background(), uuid.New(), &models.CreateBookingRequest{
+				EventID: event.ID.String(),
+				Items:   items,
+				SeatIDs: seatIDs,
+			})
+
+			// Assertions
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "zones")
+				assert.Nil(t, booking)
+				mockBookingRepo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.seats, booking.SeatIDs)
+		})
+	}
+}
+
+func TestBookingService_CreateBooking_SeatRules(t *testing.T) {
+	seatID := uuid.New().String()
+
+	tests := []struct {
+		name    string
+		seated  bool
+		req     models.CreateBookingRequest
+		message string
+	}{
+		{"seated event without seats", true, models.CreateBookingRequest{Quantity: 2}, "seat_ids is required"},
+		{"duplicate seats", true, models.CreateBookingRequest{SeatIDs: []string{seatID, seatID}}, "more than once"},
+		{"general admission with seats", false, models.CreateBookingRequest{SeatIDs: []string{seatID}}, "reserved seating"},
+		{"general admission without quantity", false, models.CreateBookingRequest{}, "quantity or items is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockBookingRepo := &MockBookingRepository{}
+			mockEventRepo := &MockEventRepository{}
+			service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+
+			event := newSeatedEvent()
+			if !tt.seated {
+				event.VenueID = nil
+			}
+			mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+
+			// Test
+			tt.req.EventID = event.ID.String()
+			_, err := service.CreateBooking(context.Background(), uuid.New(), &tt.req)
+
+			// Assertions
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+			mockEventRepo.AssertNotCalled(t, "ReserveTickets", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestEventService_CreateEvent_WithVenue(t *testing.T) {
+	// Setup
+	mockEventRepo := &MockEventRepository{}
+	mockVenueRepo := &MockVenueRepository{}
+	mockTicketTypeRepo := &MockTicketTypeRepository{}
+	uow := newMockUnitOfWork(&MockBookingRepository{}, mockEventRepo)
+	uow.repos.TicketTypes = mockTicketTypeRepo
+	service := NewEventService(mockEventRepo, mockVenueRepo, nil, uow)
+
+	venueID := uuid.New()
+	venueIDStr := venueID.String()
+
+	// Mock expectations
+	mockVenueRepo.On("CountSeats", venueID).Return(120, nil)
+	mockEventRepo.On("Create", mock.AnythingOfType("*models.Event")).Return(nil)
+	mockTicketTypeRepo.On("Create", mock.AnythingOfType("*models.TicketType")).Return(nil)
+
+	// Test
+	price := models.NewMoney(3000, "EUR")
+	event, err := service.CreateEvent(context.Background(), testOrganizer, &models.CreateEventRequest{
+		Name:         "Concert",
+		DateTime:     time.Now().Add(24 * time.Hour),
+		TotalTickets: 5, // ignored, the venue decides
+		TicketPrice:  &price,
+		VenueID:      &venueIDStr,
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, 120, event.TotalTickets)
+	assert.Equal(t, &venueID, event.VenueID)
+	assert.Equal(t, "EUR", event.Currency)
+	require.Len(t, event.TicketTypes, 1)
+	assert.Equal(t, 120, event.TicketTypes[0].Allocation)
+	mockVenueRepo.AssertExpectations(t)
+	mockEventRepo.AssertExpectations(t)
+}
+
+func TestEventService_GetSeatMap_GeneralAdmission(t *testing.T) {
+	// Setup
+	mockEventRepo := &MockEventRepository{}
+	mockVenueRepo := &MockVenueRepository{}
+	service := NewEventService(mockEventRepo, mockVenueRepo, nil, newMockUnitOfWork(&MockBookingRepository{}, mockEventRepo))
+
+	event := newSeatedEvent()
+	event.VenueID = nil
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+
+	// Test
+	seatMap, err := service.GetSeatMap(context.Background(), event.ID)
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrNotSeated)
+	assert.Nil(t, seatMap)
+	mockVenueRepo.AssertNotCalled(t, "GetSeatMap", mock.Anything, mock.Anything)
+}
+
+func TestVenueService_CreateVenue_NumbersSeats(t *testing.T) {
+	// Setup
+	mockVenueRepo := &MockVenueRepository{}
+	uow := &MockUnitOfWork{repos: &repository.Repositories{Venues: mockVenueRepo}}
+	service := NewVenueService(mockVenueRepo, uow)
+
+	// Mock expectations
+	mockVenueRepo.On("Create", mock.AnythingOfType("*models.Venue")).Return(nil)
+
+	// Test
+	venue, err := service.CreateVenue(context.Background(), &models.CreateVenueRequest{
+		Name: "Hall",
+		Sections: []models.CreateSectionRequest{
+			{Name: "Stalls", Rows: []models.CreateRowRequest{{Label: "A", Seats: 3}, {Label: "B", Seats: 2}}},
+		},
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	rows := venue.Sections[0].Rows
+	require.Len(t, rows, 2)
+	assert.Len(t, rows[0].Seats, 3)
+	assert.Equal(t, 3, rows[0].Seats[2].Number)
+	assert.Len(t, rows[1].Seats, 2)
+	mockVenueRepo.AssertExpectations(t)
+}
+
+func TestVenueService_CreateVenue_DuplicateRow(t *testing.T) {
+	// Setup
+	mockVenueRepo := &MockVenueRepository{}
+	service := NewVenueService(mockVenueRepo, &MockUnitOfWork{repos: &repository.Repositories{Venues: mockVenueRepo}})
+
+	// Test
+	_, err := service.CreateVenue(context.Background(), &models.CreateVenueRequest{
+		Name: "Hall",
+		Sections: []models.CreateSectionRequest{
+			{Name: "Stalls", Rows: []models.CreateRowRequest{{Label: "A", Seats: 3}, {Label: "A", Seats: 2}}},
+		},
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidSeatMap)
+	mockVenueRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
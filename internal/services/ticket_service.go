@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+)
+
+// qrCodeSize is the width and height of ticket QR codes, in pixels
+const qrCodeSize = 320
+
+var (
+	// ErrTicketNotFound is returned for tickets that do not belong to the
+	// booking they are requested for
+	ErrTicketNotFound = errors.New("ticket not found")
+
+	// ErrTicketRevoked is returned when rendering a ticket whose booking was
+	// refunded or cancelled
+	ErrTicketRevoked = errors.New("ticket has been revoked")
+)
+
+type TicketService struct {
+	ticketRepo  repository.TicketRepositoryInterface
+	bookingRepo repository.BookingRepositoryInterface
+	signer      *TicketSigner
+}
+
+func NewTicketService(
+	ticketRepo repository.TicketRepositoryInterface,
+	bookingRepo repository.BookingRepositoryInterface,
+	signer *TicketSigner,
+) *TicketService {
+	return &TicketService{
+		ticketRepo:  ticketRepo,
+		bookingRepo: bookingRepo,
+		signer:      signer,
+	}
+}
+
+// GetTickets returns the tickets of a booking owned by the caller, with the
+// signed payload of each valid ticket. Admins may read any booking's tickets.
+func (s *TicketService) GetTickets(ctx context.Context, bookingID uuid.UUID, caller *models.Caller) ([]*models.Ticket, error) {
+	if err := s.checkBookingAccess(ctx, bookingID, caller); err != nil {
+		return nil, err
+	}
+
+	tickets, err := s.ticketRepo.GetByBookingID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ticket := range tickets {
+		if ticket.Status != models.TicketStatusValid {
+			continue
+		}
+		if ticket.Payload, err = s.signer.Sign(ticket); err != nil {
+			return nil, err
+		}
+	}
+
+	return tickets, nil
+}
+
+// GetTicketQR renders a valid ticket's signed payload as a QR code PNG
+func (s *TicketService) GetTicketQR(ctx context.Context, bookingID, ticketID uuid.UUID, caller *models.Caller) ([]byte, error) {
+	if err := s.checkBookingAccess(ctx, bookingID, caller); err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil || ticket.BookingID != bookingID {
+		return nil, ErrTicketNotFound
+	}
+
+	if ticket.Status != models.TicketStatusValid {
+		return nil, ErrTicketRevoked
+	}
+
+	payload, err := s.signer.Sign(ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	png, err := qrcode.Encode(payload, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render QR code: %w", err)
+	}
+
+	return png, nil
+}
+
+// checkBookingAccess checks that the booking exists and the caller owns it or
+// is an admin
+func (s *TicketService) checkBookingAccess(ctx context.Context, bookingID uuid.UUID, caller *models.Caller) error {
+	booking, err := s.bookingRepo.GetByID(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+
+	if booking.UserID != caller.UserID && !caller.IsAdmin() {
+		return ErrForbidden
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ticketPayloadVersion prefixes signed ticket payloads so the format can change
+// without old tickets being misread
+const ticketPayloadVersion = "QTX1"
+
+// ErrInvalidTicketSignature is returned for ticket payloads that are malformed
+// or were not signed by this system
+var ErrInvalidTicketSignature = errors.New("invalid ticket signature")
+
+// TicketClaims are the facts a gate needs to admit a ticket holder
+type TicketClaims struct {
+	TicketID  uuid.UUID  `json:"tid"`
+	BookingID uuid.UUID  `json:"bid"`
+	EventID   uuid.UUID  `json:"eid"`
+	SeatID    *uuid.UUID `json:"sid,omitempty"`
+	IssuedAt  int64      `json:"iat"` // unix seconds
+}
+
+// TicketSigner signs ticket payloads with Ed25519, so that anyone holding the
+// public key, such as an offline scanner, can verify them without being able
+// to issue tickets.
+type TicketSigner struct {
+	privateKey ed25519.PrivateKey
+}
+
+// NewTicketSigner derives the signing key from secret. The same secret always
+// gives the same key, so issued tickets stay valid across restarts.
+func NewTicketSigner(secret string) *TicketSigner {
+	seed := sha256.Sum256([]byte(secret))
+	return &TicketSigner{privateKey: ed25519.NewKeyFromSeed(seed[:])}
+}
+
+// PublicKey returns the key that verifies signed ticket payloads
+func (s *TicketSigner) PublicKey() ed25519.PublicKey {
+	return s.privateKey.Public().(ed25519.PublicKey)
+}
+
+// Sign returns the signed payload of a ticket, in the form
+// QTX1.<base64url claims>.<base64url signature>
+func (s *TicketSigner) Sign(ticket *models.Ticket) (string, error) {
+	return s.sign(ticketPayloadVersion, TicketClaims{
+		TicketID:  ticket.ID,
+		BookingID: ticket.BookingID,
+		EventID:   ticket.EventID,
+		SeatID:    ticket.SeatID,
+		IssuedAt:  ticket.CreatedAt.Unix(),
+	})
+}
+
+// Verify checks a signed ticket payload and returns its claims
+func (s *TicketSigner) Verify(payload string) (*TicketClaims, error) {
+	return VerifyTicketPayload(s.PublicKey(), payload)
+}
+
+// VerifyTicketPayload checks a signed ticket payload against publicKey and
+// returns its claims
+func VerifyTicketPayload(publicKey ed25519.PublicKey, payload string) (*TicketClaims, error) {
+	claims := &TicketClaims{}
+	if !verify(publicKey, ticketPayloadVersion, payload, claims) {
+		return nil, ErrInvalidTicketSignature
+	}
+
+	return claims, nil
+}
+
+// sign returns claims as JSON signed in the form
+// <version>.<base64url claims>.<base64url signature>
+func (s *TicketSigner) sign(version string, claims any) (string, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s claims: %w", version, err)
+	}
+
+	signed := version + "." + base64.RawURLEncoding.EncodeToString(data)
+	signature := ed25519.Sign(s.privateKey, []byte(signed))
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verify checks a token returned by sign for version against publicKey and
+// decodes its claims into claims
+func verify(publicKey ed25519.PublicKey, version, token string, claims any) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != version {
+		return false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	signed := parts[0] + "." + parts[1]
+	if !ed25519.Verify(publicKey, []byte(signed), signature) {
+		return false
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(data, claims) == nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTicketRepository struct {
+	mock.Mock
+}
+
+func (m *MockTicketRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
+	args := m.Called(id)
+	ticket, _ := args.Get(0).(*models.Ticket)
+	return ticket, args.Error(1)
+}
+
+func (m *MockTicketRepository) GetByBookingID(ctx context.Context, bookingID uuid.UUID) ([]*models.Ticket, error) {
+	args := m.Called(bookingID)
+	return args.Get(0).([]*models.Ticket), args.Error(1)
+}
+
+func (m *MockTicketRepository) CheckIn(ctx context.Context, id, eventID, deviceID uuid.UUID, at time.Time) (bool, error) {
+	args := m.Called(id, eventID, deviceID, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTicketRepository) GetValidIDs(ctx context.Context, eventID uuid.UUID) ([]uuid.UUID, []uuid.UUID, error) {
+	args := m.Called(eventID)
+	pending, _ := args.Get(0).([]uuid.UUID)
+	checkedIn, _ := args.Get(1).([]uuid.UUID)
+	return pending, checkedIn, args.Error(2)
+}
+
+func newTicket(booking *models.Booking, number int) *models.Ticket {
+	return &models.Ticket{
+		ID:           uuid.New(),
+		BookingID:    booking.ID,
+		EventID:      booking.EventID,
+		Number:       number,
+		TicketTypeID: uuid.New(),
+		Status:       models.TicketStatusValid,
+		CreatedAt:    time.Now(),
+	}
+}
+
+func newTicketTestService() (*TicketService, *MockTicketRepository, *MockBookingRepository) {
+	mockTicketRepo := &MockTicketRepository{}
+	mockBookingRepo := &MockBookingRepository{}
+	return NewTicketService(mockTicketRepo, mockBookingRepo, NewTicketSigner("test-secret")), mockTicketRepo, mockBookingRepo
+}
+
+func TestTicketSigner_SignAndVerify(t *testing.T) {
+	// Setup
+	signer := NewTicketSigner("test-secret")
+	seatID := uuid.New()
+	ticket := newTicket(&models.Booking{ID: uuid.New(), EventID: uuid.New()}, 1)
+	ticket.SeatID = &seatID
+
+	// Test
+	payload, err := signer.Sign(ticket)
+	require.NoError(t, err)
+	claims, err := signer.Verify(payload)
+
+	// Assertions
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(payload, "QTX1."))
+	assert.Equal(t, ticket.ID, claims.TicketID)
+	assert.Equal(t, ticket.BookingID, claims.BookingID)
+	assert.Equal(t, ticket.EventID, claims.EventID)
+	assert.Equal(t, &seatID, claims.SeatID)
+	assert.Equal(t, ticket.CreatedAt.Unix(), claims.IssuedAt)
+}
+
+func TestTicketSigner_RejectsForgedPayloads(t *testing.T) {
+	signer := NewTicketSigner("test-secret")
+	payload, err := signer.Sign(newTicket(&models.Booking{ID: uuid.New(), EventID: uuid.New()}, 1))
+	require.NoError(t, err)
+
+	parts := strings.Split(payload, ".")
+	otherKey, err := NewTicketSigner("other-secret").Sign(newTicket(&models.Booking{ID: uuid.New(), EventID: uuid.New()}, 1))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"other signing key", otherKey},
+		{"swapped claims", parts[0] + "." + strings.Split(otherKey, ".")[1] + "." + parts[2]},
+		{"unknown version", "QTX0." + parts[1] + "." + parts[2]},
+		{"missing signature", parts[0] + "." + parts[1]},
+		{"garbage", "not a ticket"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := signer.Verify(tt.payload)
+			assert.ErrorIs(t, err, ErrInvalidTicketSignature)
+		})
+	}
+}
+
+func TestTicketService_GetTickets_SignsValidTickets(t *testing.T) {
+	// Setup
+	service, mockTicketRepo, mockBookingRepo := newTicketTestService()
+
+	userID := uuid.New()
+	booking := &models.Booking{ID: uuid.New(), UserID: userID, EventID: uuid.New(), Status: models.BookingStatusConfirmed}
+	valid := newTicket(booking, 1)
+	revoked := newTicket(booking, 2)
+	revoked.Status = models.TicketStatusRevoked
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockTicketRepo.On("GetByBookingID", booking.ID).Return([]*models.Ticket{valid, revoked}, nil)
+
+	// Test
+	tickets, err := service.GetTickets(context.Background(), booking.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
+
+	// Assertions
+	require.NoError(t, err)
+	require.Len(t, tickets, 2)
+	claims, err := service.signer.Verify(tickets[0].Payload)
+	require.NoError(t, err)
+	assert.Equal(t, valid.ID, claims.TicketID)
+	assert.Empty(t, tickets[1].Payload)
+}
+
+func TestTicketService_GetTicketQR(t *testing.T) {
+	// Setup
+	service, mockTicketRepo, mockBookingRepo := newTicketTestService()
+
+	userID := uuid.New()
+	booking := &models.Booking{ID: uuid.New(), UserID: userID, EventID: uuid.New(), Status: models.BookingStatusConfirmed}
+	ticket := newTicket(booking, 1)
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockTicketRepo.On("GetByID", ticket.ID).Return(ticket, nil)
+
+	// Test
+	png, err := service.GetTicketQR(context.Background(), booking.ID, ticket.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(png, []byte("\x89PNG\r\n\x1a\n")))
+}
+
+func TestTicketService_GetTicketQR_Errors(t *testing.T) {
+	userID := uuid.New()
+	booking := &models.Booking{ID: uuid.New(), UserID: userID, EventID: uuid.New(), Status: models.BookingStatusRefunded}
+
+	revoked := newTicket(booking, 1)
+	revoked.Status = models.TicketStatusRevoked
+	otherBooking := newTicket(&models.Booking{ID: uuid.New(), EventID: booking.EventID}, 1)
+
+	tests := []struct {
+		name     string
+		caller   *models.Caller
+		ticketID uuid.UUID
+		ticket   *models.Ticket
+		err      error
+		expected error
+	}{
+		{"revoked ticket", &models.Caller{UserID: userID, Role: models.RoleCustomer}, revoked.ID, revoked, nil, ErrTicketRevoked},
+		{"another booking's ticket", &models.Caller{UserID: userID, Role: models.RoleCustomer}, otherBooking.ID, otherBooking, nil, ErrTicketNotFound},
+		{"unknown ticket", &models.Caller{UserID: userID, Role: models.RoleCustomer}, uuid.New(), nil, errors.New("ticket not found"), ErrTicketNotFound},
+		{"another user's booking", &models.Caller{UserID: uuid.New(), Role: models.RoleCustomer}, revoked.ID, revoked, nil, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			service, mockTicketRepo, mockBookingRepo := newTicketTestService()
+			mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+			mockTicketRepo.On("GetByID", tt.ticketID).Return(tt.ticket, tt.err)
+
+			// Test
+			png, err := service.GetTicketQR(context.Background(), booking.ID, tt.ticketID, tt.caller)
+
+			// Assertions
+			assert.ErrorIs(t, err, tt.expected)
+			assert.Nil(t, png)
+		})
+	}
+}
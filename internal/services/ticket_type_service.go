@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidTicketType is returned when a ticket type's name is taken or
+	// its allocation does not fit the event
+	ErrInvalidTicketType = errors.New("invalid ticket type")
+
+	// ErrTicketTypeInUse is returned when changing a ticket type would break
+	// existing bookings, or when removing an event's last ticket type
+	ErrTicketTypeInUse = errors.New("ticket type is in use")
+)
+
+// TicketTypeService manages an event's ticket tiers. Changes lock the event
+// row, like ReserveTickets, so allocations are checked against a stable view
+// of the event's bookings.
+type TicketTypeService struct {
+	eventRepo repository.EventRepositoryInterface
+	uow       repository.UnitOfWorkInterface
+}
+
+func NewTicketTypeService(eventRepo repository.EventRepositoryInterface, uow repository.UnitOfWorkInterface) *TicketTypeService {
+	return &TicketTypeService{
+		eventRepo: eventRepo,
+		uow:       uow,
+	}
+}
+
+func (s *TicketTypeService) GetTicketTypes(ctx context.Context, eventID uuid.UUID) ([]*models.TicketType, error) {
+	event, err := s.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.TicketTypes, nil
+}
+
+// CreateTicketType adds a ticket type to an event organized by caller
+func (s *TicketTypeService) CreateTicketType(ctx context.Context, eventID uuid.UUID, caller *models.Caller, req *models.CreateTicketTypeRequest) (*models.TicketType, error) {
+	ticketType := &models.TicketType{
+		EventID:     eventID,
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		Allocation:  req.Allocation,
+		Zone:        req.Zone,
+	}
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		event, err := repos.Events.GetByIDForUpdate(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		if err := checkEventOrganizer(caller, event); err != nil {
+			return err
+		}
+
+		if err := checkTicketType(event, ticketType); err != nil {
+			return err
+		}
+
+		return repos.TicketTypes.Create(ctx, ticketType)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ticketType, nil
+}
+
+// UpdateTicketType changes a ticket type of an event organized by caller
+func (s *TicketTypeService) UpdateTicketType(ctx context.Context, eventID, id uuid.UUID, caller *models.Caller, req *models.UpdateTicketTypeRequest) (*models.TicketType, error) {
+	var ticketType *models.TicketType
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		event, err := repos.Events.GetByIDForUpdate(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		if err := checkEventOrganizer(caller, event); err != nil {
+			return err
+		}
+
+		ticketType, err = findTicketType(event, id)
+		if err != nil {
+			return err
+		}
+
+		// Update fields if provided
+		if req.Name != nil {
+			ticketType.Name = *req.Name
+		}
+		if req.Description != nil {
+			ticketType.Description = *req.Description
+		}
+		if req.Price != nil {
+			ticketType.Price = *req.Price
+		}
+		if req.Allocation != nil {
+			if *req.Allocation < ticketType.Reserved() {
+				return fmt.Errorf("%w: %d tickets of this type are already booked", ErrTicketTypeInUse, ticketType.Reserved())
+			}
+			ticketType.Available += *req.Allocation - ticketType.Allocation
+			ticketType.Allocation = *req.Allocation
+		}
+
+		if err := checkTicketType(event, ticketType); err != nil {
+			return err
+		}
+
+		return repos.TicketTypes.Update(ctx, ticketType)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ticketType, nil
+}
+
+// DeleteTicketType removes a ticket type that has never been booked from an
+// event organized by caller. An event always keeps at least one ticket type.
+func (s *TicketTypeService) DeleteTicketType(ctx context.Context, eventID, id uuid.UUID, caller *models.Caller) error {
+	return s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		event, err := repos.Events.GetByIDForUpdate(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		if err := checkEventOrganizer(caller, event); err != nil {
+			return err
+		}
+
+		if _, err := findTicketType(event, id); err != nil {
+			return err
+		}
+
+		if len(event.TicketTypes) == 1 {
+			return fmt.Errorf("%w: an event must have at least one ticket type", ErrTicketTypeInUse)
+		}
+
+		err = repos.TicketTypes.Delete(ctx, id)
+		if errors.Is(err, repository.ErrTicketTypeReferenced) {
+			return fmt.Errorf("%w: it has bookings", ErrTicketTypeInUse)
+		}
+		return err
+	})
+}
+
+func findTicketType(event *models.Event, id uuid.UUID) (*models.TicketType, error) {
+	for _, ticketType := range event.TicketTypes {
+		if ticketType.ID == id {
+			return ticketType, nil
+		}
+	}
+
+	return nil, fmt.Errorf("ticket type not found")
+}
+
+// checkTicketType validates a new or changed ticket type against the event's
+// other ticket types
+func checkTicketType(event *models.Event, ticketType *models.TicketType) error {
+	if err := checkPrice(ticketType.Price, event.Currency); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTicketType, err)
+	}
+	if ticketType.Zone != "" && event.VenueID == nil {
+		return fmt.Errorf("%w: zones can only be used for reserved seating events", ErrInvalidTicketType)
+	}
+
+	allocated := ticketType.Allocation
+	for _, other := range event.TicketTypes {
+		if other.ID == ticketType.ID {
+			continue
+		}
+		if other.Name == ticketType.Name {
+			return fmt.Errorf("%w: the event already has a ticket type named %q", ErrInvalidTicketType, ticketType.Name)
+		}
+		allocated += other.Allocation
+	}
+
+	if allocated > event.TotalTickets {
+		return fmt.Errorf("%w: ticket types would allocate %d tickets but the event has %d", ErrInvalidTicketType, allocated, event.TotalTickets)
+	}
+
+	return nil
+}
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTicketTypeRepository struct {
+	mock.Mock
+}
+
+func (m *MockTicketTypeRepository) Create(ctx context.Context, ticketType *models.TicketType) error {
+	args := m.Called(ticketType)
+	return args.Error(0)
+}
+
+func (m *MockTicketTypeRepository) GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*models.TicketType, error) {
+	args := m.Called(eventID)
+	ticketTypes, _ := args.Get(0).([]*models.TicketType)
+	return ticketTypes, args.Error(1)
+}
+
+func (m *MockTicketTypeRepository) Update(ctx context.Context, ticketType *models.TicketType) error {
+	args := m.Called(ticketType)
+	return args.Error(0)
+}
+
+func (m *MockTicketTypeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// newTieredEvent returns an event of testOrganizer with VIP and standard
+// tiers that allocate 80 of its 100 tickets
+func newTieredEvent() *models.Event {
+	return &models.Event{
+		ID:           uuid.New(),
+		OrganizerID:  &testOrganizer.UserID,
+		Name:         "Tiered Event",
+		DateTime:     time.Now().Add(24 * time.Hour),
+		TotalTickets: 100,
+		Currency:     "USD",
+		TicketPrice:  usd(3000),
+		TicketTypes: []*models.TicketType{
+			{ID: uuid.New(), Name: "Standard", Price: usd(3000), Allocation: 60, Available: 60},
+			{ID: uuid.New(), Name: "VIP", Price: usd(12000), Allocation: 20, Available: 5},
+		},
+	}
+}
+
+func newTicketTypeTestService() (*TicketTypeService, *MockEventRepository, *MockTicketTypeRepository) {
+	mockEventRepo := &MockEventRepository{}
+	mockTicketTypeRepo := &MockTicketTypeRepository{}
+
+	uow := newMockUnitOfWork(&MockBookingRepository{}, mockEventRepo)
+	uow.repos.TicketTypes = mockTicketTypeRepo
+
+	return NewTicketTypeService(mockEventRepo, uow), mockEventRepo, mockTicketTypeRepo
+}
+
+func TestBookingService_CreateBooking_Items(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+
+	event := newTieredEvent()
+	standard, vip := event.TicketTypes[0], event.TicketTypes[1]
+
+	expectedItems := []*models.BookingItem{
+		{TicketTypeID: vip.ID, Quantity: 1, UnitPrice: usd(12000)},
+		{TicketTypeID: standard.ID, Quantity: 3, UnitPrice: usd(3000)},
+	}
+
+	// Mock expectations: each tier is reserved at its own price
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("ReserveTickets", event.ID, expectedItems).Return(nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Return(nil)
+
+	// Test
+	booking, err := service.CreateBooking(context.Background(), uuid.New(), &models.CreateBookingRequest{
+		EventID: event.ID.String(),
+		Items: []models.BookingItemRequest{
+			{TicketTypeID: vip.ID.String(), Quantity: 1},
+			{TicketTypeID: standard.ID.String(), Quantity: 3},
+		},
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, 4, booking.Quantity)
+	assert.Equal(t, usd(21000), booking.TotalAmount) // 120 + 3 * 30
+	assert.Equal(t, expectedItems, booking.Items)
+	mockEventRepo.AssertExpectations(t)
+	mockBookingRepo.AssertExpectations(t)
+}
+
+func TestBookingService_CreateBooking_ItemRules(t *testing.T) {
+	event := newTieredEvent()
+	standardID := event.TicketTypes[0].ID.String()
+
+	tests := []struct {
+		name    string
+		req     models.CreateBookingRequest
+		message string
+	}{
+		{"quantity without items", models.CreateBookingRequest{Quantity: 2}, "items are required"},
+		{"unknown ticket type", models.CreateBookingRequest{Items: []models.BookingItemRequest{{TicketTypeID: uuid.New().String(), Quantity: 1}}}, "not sold for this event"},
+		{"duplicate ticket type", models.CreateBookingRequest{Items: []models.BookingItemRequest{{TicketTypeID: standardID, Quantity: 1}, {TicketTypeID: standardID, Quantity: 2}}}, "more than once"},
+		{"quantity mismatch", models.CreateBookingRequest{Quantity: 3, Items: []models.BookingItemRequest{{TicketTypeID: standardID, Quantity: 1}}}, "add up to 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockBookingRepo := &MockBookingRepository{}
+			mockEventRepo := &MockEventRepository{}
+			service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+			mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+
+			// Test
+			tt.req.EventID = event.ID.String()
+			_, err := service.CreateBooking(context.Background(), uuid.New(), &tt.req)
+
+			// Assertions
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+			mockEventRepo.AssertNotCalled(t, "ReserveTickets", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+var testOrganizer = &models.Caller{UserID: uuid.New(), Role: models.RoleOrganizer}
+
+func TestEventService_CreateEvent_TicketTypes(t *testing.T) {
+	// Setup
+	mockEventRepo := &MockEventRepository{}
+	mockTicketTypeRepo := &MockTicketTypeRepository{}
+	uow := newMockUnitOfWork(&MockBookingRepository{}, mockEventRepo)
+	uow.repos.TicketTypes = mockTicketTypeRepo
+	service := NewEventService(mockEventRepo, &MockVenueRepository{}, nil, uow)
+
+	// Mock expectations
+	mockEventRepo.On("Create", mock.AnythingOfType("*models.Event")).Return(nil)
+	mockTicketTypeRepo.On("Create", mock.AnythingOfType("*models.TicketType")).Return(nil).Twice()
+
+	// Test
+	event, err := service.CreateEvent(context.Background(), testOrganizer, &models.CreateEventRequest{
+		Name:     "Festival",
+		DateTime: time.Now().Add(24 * time.Hour),
+		TicketTypes: []models.CreateTicketTypeRequest{
+			{Name: "VIP", Price: usd(15000), Allocation: 50},
+			{Name: "Early Bird", Price: usd(4500), Allocation: 200},
+		},
+	})
+
+	// Assertions: capacity and ticket price default from the tiers
+	require.NoError(t, err)
+	assert.Equal(t, 250, event.TotalTickets)
+	assert.Equal(t, usd(4500), event.TicketPrice)
+	assert.Equal(t, "USD", event.Currency)
+	assert.Equal(t, &testOrganizer.UserID, event.OrganizerID)
+	assert.Len(t, event.TicketTypes, 2)
+	mockEventRepo.AssertExpectations(t)
+	mockTicketTypeRepo.AssertExpectations(t)
+}
+
+func TestEventService_CreateEvent_OverAllocated(t *testing.T) {
+	// Setup
+	mockEventRepo := &MockEventRepository{}
+	service := NewEventService(mockEventRepo, &MockVenueRepository{}, nil, newMockUnitOfWork(&MockBookingRepository{}, mockEventRepo))
+
+	// Test
+	_, err := service.CreateEvent(context.Background(), testOrganizer, &models.CreateEventRequest{
+		Name:         "Festival",
+		DateTime:     time.Now().Add(24 * time.Hour),
+		TotalTickets: 100,
+		TicketTypes: []models.CreateTicketTypeRequest{
+			{Name: "VIP", Price: usd(15000), Allocation: 50},
+			{Name: "Standard", Price: usd(4500), Allocation: 60},
+		},
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	mockEventRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestEventService_CreateEvent_MixedCurrencies(t *testing.T) {
+	// Setup
+	mockEventRepo := &MockEventRepository{}
+	service := NewEventService(mockEventRepo, &MockVenueRepository{}, nil, newMockUnitOfWork(&MockBookingRepository{}, mockEventRepo))
+
+	// Test
+	_, err := service.CreateEvent(context.Background(), testOrganizer, &models.CreateEventRequest{
+		Name:     "Festival",
+		DateTime: time.Now().Add(24 * time.Hour),
+		TicketTypes: []models.CreateTicketTypeRequest{
+			{Name: "VIP", Price: usd(15000), Allocation: 50},
+			{Name: "Standard", Price: models.NewMoney(4500, "GBP"), Allocation: 60},
+		},
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	mockEventRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestTicketTypeService_CreateTicketType_ExceedsCapacity(t *testing.T) {
+	// Setup
+	service, mockEventRepo, mockTicketTypeRepo := newTicketTypeTestService()
+	event := newTieredEvent()
+
+	// Mock expectations
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+
+	// Test: 80 of 100 tickets are already allocated
+	_, err := service.CreateTicketType(context.Background(), event.ID, testOrganizer, &models.CreateTicketTypeRequest{
+		Name:       "Student",
+		Price:      usd(1500),
+		Allocation: 25,
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidTicketType)
+	mockTicketTypeRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestTicketTypeService_CreateTicketType_OtherCurrency(t *testing.T) {
+	// Setup
+	service, mockEventRepo, mockTicketTypeRepo := newTicketTypeTestService()
+	event := newTieredEvent()
+
+	// Mock expectations
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+
+	// Test: the event sells in USD
+	_, err := service.CreateTicketType(context.Background(), event.ID, testOrganizer, &models.CreateTicketTypeRequest{
+		Name:       "Student",
+		Price:      models.NewMoney(1500, "EUR"),
+		Allocation: 10,
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidTicketType)
+	assert.Contains(t, err.Error(), "currency USD")
+	mockTicketTypeRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestTicketTypeService_CreateTicketType_Success(t *testing.T) {
+	// Setup
+	service, mockEventRepo, mockTicketTypeRepo := newTicketTypeTestService()
+	event := newTieredEvent()
+
+	// Mock expectations
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+	mockTicketTypeRepo.On("Create", mock.AnythingOfType("*models.TicketType")).Return(nil)
+
+	// Test
+	ticketType, err := service.CreateTicketType(context.Background(), event.ID, testOrganizer, &models.CreateTicketTypeRequest{
+		Name:       "Student",
+		Price:      usd(1500),
+		Allocation: 20,
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, ticketType.EventID)
+	mockTicketTypeRepo.AssertExpectations(t)
+}
+
+func TestTicketTypeService_UpdateTicketType_BelowReserved(t *testing.T) {
+	// Setup
+	service, mockEventRepo, mockTicketTypeRepo := newTicketTypeTestService()
+	event := newTieredEvent()
+	vip := event.TicketTypes[1] // 15 of 20 booked
+
+	// Mock expectations
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+
+	// Test
+	allocation := 10
+	_, err := service.UpdateTicketType(context.Background(), event.ID, vip.ID, testOrganizer, &models.UpdateTicketTypeRequest{
+		Allocation: &allocation,
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrTicketTypeInUse)
+	mockTicketTypeRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestTicketTypeService_DeleteTicketType(t *testing.T) {
+	t.Run("last ticket type", func(t *testing.T) {
+		// Setup
+		service, mockEventRepo, mockTicketTypeRepo := newTicketTypeTestService()
+		event := newTieredEvent()
+		event.TicketTypes = event.TicketTypes[:1]
+
+		// Mock expectations
+		mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+
+		// Test
+		err := service.DeleteTicketType(context.Background(), event.ID, event.TicketTypes[0].ID, testOrganizer)
+
+		// Assertions
+		assert.ErrorIs(t, err, ErrTicketTypeInUse)
+		mockTicketTypeRepo.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+
+	t.Run("booked ticket type", func(t *testing.T) {
+		// Setup
+		service, mockEventRepo, mockTicketTypeRepo := newTicketTypeTestService()
+		event := newTieredEvent()
+		vip := event.TicketTypes[1]
+
+		// Mock expectations
+		mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+		mockTicketTypeRepo.On("Delete", vip.ID).Return(repository.ErrTicketTypeReferenced)
+
+		// Test
+		err := service.DeleteTicketType(context.Background(), event.ID, vip.ID, testOrganizer)
+
+		// Assertions
+		assert.ErrorIs(t, err, ErrTicketTypeInUse)
+		mockTicketTypeRepo.AssertExpectations(t)
+	})
+}
+
+func TestTicketTypeService_OtherOrganizer(t *testing.T) {
+	// Setup
+	service, mockEventRepo, mockTicketTypeRepo := newTicketTypeTestService()
+	event := newTieredEvent()
+	other := &models.Caller{UserID: uuid.New(), Role: models.RoleOrganizer}
+
+	// Mock expectations
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+
+	// Test: the event belongs to testOrganizer
+	_, createErr := service.CreateTicketType(context.Background(), event.ID, other, &models.CreateTicketTypeRequest{
+		Name:       "Student",
+		Price:      usd(1500),
+		Allocation: 10,
+	})
+	allocation := 30
+	_, updateErr := service.UpdateTicketType(context.Background(), event.ID, event.TicketTypes[1].ID, other, &models.UpdateTicketTypeRequest{
+		Allocation: &allocation,
+	})
+	deleteErr := service.DeleteTicketType(context.Background(), event.ID, event.TicketTypes[1].ID, other)
+
+	// Assertions
+	assert.ErrorIs(t, createErr, ErrForbidden)
+	assert.ErrorIs(t, updateErr, ErrForbidden)
+	assert.ErrorIs(t, deleteErr, ErrForbidden)
+	assert.Equal(t, 20, event.TicketTypes[1].Allocation)
+	mockTicketTypeRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockTicketTypeRepo.AssertNotCalled(t, "Update", mock.Anything)
+	mockTicketTypeRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestTicketTypeService_Admin(t *testing.T) {
+	// Setup
+	service, mockEventRepo, mockTicketTypeRepo := newTicketTypeTestService()
+	event := newTieredEvent()
+	admin := &models.Caller{UserID: uuid.New(), Role: models.RoleAdmin}
+
+	// Mock expectations
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+	mockTicketTypeRepo.On("Create", mock.AnythingOfType("*models.TicketType")).Return(nil)
+
+	// Test: admins manage every event
+	_, err := service.CreateTicketType(context.Background(), event.ID, admin, &models.CreateTicketTypeRequest{
+		Name:       "Student",
+		Price:      usd(1500),
+		Allocation: 10,
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	mockTicketTypeRepo.AssertExpectations(t)
+}
+
+func TestEventService_OtherOrganizer(t *testing.T) {
+	// Setup
+	mockEventRepo := &MockEventRepository{}
+	uow := newMockUnitOfWork(&MockBookingRepository{}, mockEventRepo)
+	service := NewEventService(mockEventRepo, &MockVenueRepository{}, nil, uow)
+	event := newTieredEvent()
+	other := &models.Caller{UserID: uuid.New(), Role: models.RoleOrganizer}
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+
+	// Test: the event belongs to testOrganizer
+	name := "Renamed"
+	_, updateErr := service.UpdateEvent(context.Background(), event.ID, other, &models.UpdateEventRequest{Name: &name})
+	deleteErr := service.DeleteEvent(context.Background(), event.ID, other)
+	_, statsErr := service.GetEventStatistics(context.Background(), event.ID, other)
+
+	// Assertions
+	assert.ErrorIs(t, updateErr, ErrForbidden)
+	assert.ErrorIs(t, deleteErr, ErrForbidden)
+	assert.ErrorIs(t, statsErr, ErrForbidden)
+	assert.Equal(t, "Tiered Event", event.Name)
+	mockEventRepo.AssertNotCalled(t, "Update", mock.Anything)
+	mockEventRepo.AssertNotCalled(t, "Delete", mock.Anything)
+	mockEventRepo.AssertNotCalled(t, "GetStatistics", mock.Anything)
+}
+
+func TestEventRepository_Availability_SoldOutTier(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	eventRepo := repository.NewEventRepository(db, nil)
+	userRepo := repository.NewUserRepository(db)
+	uow := repository.NewUnitOfWork(db, nil, logging.Discard())
+	bookingService := NewBookingService(repository.NewBookingRepository(db), eventRepo, uow, 15, logging.Discard())
+
+	organizer := createTestUser(t, userRepo, models.RoleOrganizer)
+	customer := createTestUser(t, userRepo, models.RoleCustomer)
+
+	// Setup: the tiers allocate 5 of the event's 100 tickets
+	name := "Sold Out Tier " + uuid.NewString()
+	event, err := NewEventService(eventRepo, repository.NewVenueRepository(db), nil, uow).CreateEvent(ctx,
+		&models.Caller{UserID: organizer.ID, Role: organizer.Role},
+		&models.CreateEventRequest{
+			Name:         name,
+			DateTime:     time.Now().Add(24 * time.Hour),
+			TotalTickets: 100,
+			TicketTypes: []models.CreateTicketTypeRequest{
+				{Name: "Standard", Price: usd(3000), Allocation: 3},
+				{Name: "VIP", Price: usd(12000), Allocation: 2},
+			},
+		})
+	require.NoError(t, err)
+	t.Cleanup(func() { eventRepo.Delete(ctx, event.ID) })
+
+	book := func(ticketType *models.TicketType) {
+		t.Helper()
+		_, err := bookingService.CreateBooking(ctx, customer.ID, &models.CreateBookingRequest{
+			EventID: event.ID.String(),
+			Items:   []models.BookingItemRequest{{TicketTypeID: ticketType.ID.String(), Quantity: ticketType.Allocation}},
+		})
+		require.NoError(t, err)
+	}
+	listed := func() int {
+		t.Helper()
+		page, err := eventRepo.GetAll(ctx, &models.EventFilter{Search: name, HasAvailability: true})
+		require.NoError(t, err)
+		return len(page.Data)
+	}
+
+	// Test: with VIP sold out only standard tickets are left
+	book(event.TicketTypes[1])
+
+	available, err := eventRepo.GetAvailableTickets(ctx, event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, available)
+
+	stats, err := eventRepo.GetStatistics(ctx, event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.AvailableTickets)
+	assert.Equal(t, 1, listed())
+
+	// With every tier sold out nothing is left, whatever total_tickets says
+	book(event.TicketTypes[0])
+
+	available, err = eventRepo.GetAvailableTickets(ctx, event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, available)
+	assert.Equal(t, 0, listed())
+
+	// Sell-through counts the tiers' allocations too
+	sellThrough, err := eventRepo.GetSellThrough(ctx)
+	require.NoError(t, err)
+	var found *models.EventSellThrough
+	for _, candidate := range sellThrough {
+		if candidate.EventID == event.ID {
+			found = candidate
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, 5, found.TotalTickets)
+	assert.Equal(t, 5, found.Reserved)
+	assert.Equal(t, 0, found.Sold)
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+
 	"ticket-booking-system/internal/models"
 	"ticket-booking-system/internal/repository"
 
@@ -17,13 +19,26 @@ func NewUserService(userRepo repository.UserRepositoryInterface) *UserService {
 	}
 }
 
-func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
+func (s *UserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
 	user := &models.User{
 		Name:  req.Name,
 		Email: req.Email,
+		Role:  req.Role,
+	}
+
+	if user.Role == "" {
+		user.Role = models.RoleCustomer
+	}
+
+	if req.Password != "" {
+		passwordHash, err := HashPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = &passwordHash
 	}
 
-	err := s.userRepo.Create(user)
+	err := s.userRepo.Create(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -31,16 +46,16 @@ func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, e
 	return user, nil
 }
 
-func (s *UserService) GetUser(id uuid.UUID) (*models.User, error) {
-	return s.userRepo.GetByID(id)
+func (s *UserService) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return s.userRepo.GetByID(ctx, id)
 }
 
-func (s *UserService) GetUsers() ([]*models.User, error) {
-	return s.userRepo.GetAll()
+func (s *UserService) GetUsers(ctx context.Context, page *models.PageRequest) (*models.Page[*models.User], error) {
+	return s.userRepo.GetAll(ctx, page)
 }
 
-func (s *UserService) UpdateUser(id uuid.UUID, req *models.UpdateUserRequest) (*models.User, error) {
-	user, err := s.userRepo.GetByID(id)
+func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, req *models.UpdateUserRequest) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +68,7 @@ func (s *UserService) UpdateUser(id uuid.UUID, req *models.UpdateUserRequest) (*
 		user.Email = *req.Email
 	}
 
-	err = s.userRepo.Update(user)
+	err = s.userRepo.Update(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +76,31 @@ func (s *UserService) UpdateUser(id uuid.UUID, req *models.UpdateUserRequest) (*
 	return user, nil
 }
 
-func (s *UserService) DeleteUser(id uuid.UUID) error {
-	return s.userRepo.Delete(id)
+func (s *UserService) UpdateUserRole(ctx context.Context, id uuid.UUID, role models.Role) (*models.User, error) {
+	err := s.userRepo.UpdateRole(ctx, id, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetByID(ctx, id)
+}
+
+func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.userRepo.Delete(ctx, id)
+}
+
+// EnsureAdmin creates an admin account with the given credentials unless a user
+// with that email already exists
+func (s *UserService) EnsureAdmin(ctx context.Context, email, password string) error {
+	if _, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+		return nil
+	}
+
+	_, err := s.CreateUser(ctx, &models.CreateUserRequest{
+		Name:     "Administrator",
+		Email:    email,
+		Password: password,
+		Role:     models.RoleAdmin,
+	})
+	return err
 }
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidSeatMap is returned when a venue's sections or rows are not unique
+	ErrInvalidSeatMap = errors.New("invalid seat map")
+
+	// ErrVenueInUse is returned when deleting a venue that events still use
+	ErrVenueInUse = errors.New("venue is used by one or more events")
+)
+
+type VenueService struct {
+	venueRepo repository.VenueRepositoryInterface
+	uow       repository.UnitOfWorkInterface
+}
+
+func NewVenueService(venueRepo repository.VenueRepositoryInterface, uow repository.UnitOfWorkInterface) *VenueService {
+	return &VenueService{
+		venueRepo: venueRepo,
+		uow:       uow,
+	}
+}
+
+// CreateVenue stores a venue and its seat map. Seats in each row are numbered
+// from 1.
+func (s *VenueService) CreateVenue(ctx context.Context, req *models.CreateVenueRequest) (*models.Venue, error) {
+	venue := &models.Venue{
+		Name:    req.Name,
+		Address: req.Address,
+	}
+
+	sectionNames := make(map[string]bool, len(req.Sections))
+	for _, sectionReq := range req.Sections {
+		if sectionNames[sectionReq.Name] {
+			return nil, fmt.Errorf("%w: duplicate section name %q", ErrInvalidSeatMap, sectionReq.Name)
+		}
+		sectionNames[sectionReq.Name] = true
+
+		section := &models.Section{Name: sectionReq.Name}
+		rowLabels := make(map[string]bool, len(sectionReq.Rows))
+		for _, rowReq := range sectionReq.Rows {
+			if rowLabels[rowReq.Label] {
+				return nil, fmt.Errorf("%w: duplicate row %q in section %q", ErrInvalidSeatMap, rowReq.Label, sectionReq.Name)
+			}
+			rowLabels[rowReq.Label] = true
+
+			zone := sectionReq.Zone
+			if rowReq.Zone != "" {
+				zone = rowReq.Zone
+			}
+
+			row := &models.SeatRow{Label: rowReq.Label}
+			for number := 1; number <= rowReq.Seats; number++ {
+				row.Seats = append(row.Seats, &models.Seat{Number: number, Zone: zone})
+			}
+			section.Rows = append(section.Rows, row)
+		}
+		venue.Sections = append(venue.Sections, section)
+	}
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		return repos.Venues.Create(ctx, venue)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return venue, nil
+}
+
+func (s *VenueService) GetVenue(ctx context.Context, id uuid.UUID) (*models.Venue, error) {
+	return s.venueRepo.GetByID(ctx, id)
+}
+
+func (s *VenueService) GetVenues(ctx context.Context, page *models.PageRequest) (*models.Page[*models.Venue], error) {
+	return s.venueRepo.GetAll(ctx, page)
+}
+
+func (s *VenueService) DeleteVenue(ctx context.Context, id uuid.UUID) error {
+	err := s.venueRepo.Delete(ctx, id)
+	if errors.Is(err, repository.ErrVenueReferenced) {
+		return ErrVenueInUse
+	}
+	return err
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrAlreadyOnWaitlist is returned when joining a waitlist twice
+	ErrAlreadyOnWaitlist = errors.New("you are already on the waitlist for this event")
+
+	// ErrNotOnWaitlist is returned when the caller has no waiting entry or
+	// open offer for the event
+	ErrNotOnWaitlist = errors.New("you are not on the waitlist for this event")
+
+	// ErrTicketsAvailable is returned when joining the waitlist for tickets
+	// that can be booked right away
+	ErrTicketsAvailable = errors.New("tickets are available, book them instead")
+
+	// ErrNoOffer is returned when accepting an offer that was never made or
+	// is no longer open
+	ErrNoOffer = errors.New("you have no open offer for this event")
+)
+
+// TicketReleaseListener is notified after a booking gives its tickets back
+type TicketReleaseListener interface {
+	TicketsReleased(ctx context.Context, eventID uuid.UUID)
+}
+
+type WaitlistService struct {
+	waitlistRepo repository.WaitlistRepositoryInterface
+	bookingRepo  repository.BookingRepositoryInterface
+	eventRepo    repository.EventRepositoryInterface
+	uow          repository.UnitOfWorkInterface
+	offerTTL     time.Duration
+	limits       BookingLimits
+	logger       *slog.Logger
+}
+
+func NewWaitlistService(
+	waitlistRepo repository.WaitlistRepositoryInterface,
+	bookingRepo repository.BookingRepositoryInterface,
+	eventRepo repository.EventRepositoryInterface,
+	uow repository.UnitOfWorkInterface,
+	offerTTL time.Duration,
+	logger *slog.Logger,
+) *WaitlistService {
+	return &WaitlistService{
+		waitlistRepo: waitlistRepo,
+		bookingRepo:  bookingRepo,
+		eventRepo:    eventRepo,
+		uow:          uow,
+		offerTTL:     offerTTL,
+		logger:       logger,
+	}
+}
+
+// SetLimits sets the per-user limits offers are checked against, the same
+// ones bookings are
+func (s *WaitlistService) SetLimits(limits BookingLimits) {
+	s.limits = limits
+}
+
+// JoinWaitlist queues the user for tickets of a sold out ticket type. Tickets
+// released before the user joined are offered straight away.
+func (s *WaitlistService) JoinWaitlist(ctx context.Context, eventID, userID uuid.UUID, req *models.JoinWaitlistRequest) (*models.WaitlistEntry, error) {
+	event, err := s.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.DateTime.Before(time.Now()) {
+		return nil, fmt.Errorf("cannot join the waitlist for past events")
+	}
+	if event.VenueID != nil {
+		return nil, fmt.Errorf("waitlists are only available for general admission events")
+	}
+
+	var ticketType *models.TicketType
+	if req.TicketTypeID != "" {
+		ticketTypeID, err := uuid.Parse(req.TicketTypeID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket type ID: %w", err)
+		}
+		if ticketType, err = findTicketType(event, ticketTypeID); err != nil {
+			return nil, fmt.Errorf("ticket type %s is not sold for this event", ticketTypeID)
+		}
+	} else if len(event.TicketTypes) == 1 {
+		ticketType = event.TicketTypes[0]
+	} else {
+		return nil, fmt.Errorf("ticket_type_id is required for events with several ticket types")
+	}
+
+	if req.Quantity > ticketType.Allocation {
+		return nil, fmt.Errorf("only %d tickets of %s exist", ticketType.Allocation, ticketType.Name)
+	}
+	if ticketType.Available >= req.Quantity {
+		return nil, ErrTicketsAvailable
+	}
+
+	// Refuse users the offer would be refused to. The limits are checked again
+	// when the offer is made.
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		return s.limits.check(ctx, repos, userID, eventID, req.Quantity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.WaitlistEntry{
+		EventID:      eventID,
+		UserID:       userID,
+		TicketTypeID: ticketType.ID,
+		Quantity:     req.Quantity,
+		Status:       models.WaitlistStatusWaiting,
+	}
+
+	err = s.waitlistRepo.Create(ctx, entry)
+	if errors.Is(err, repository.ErrAlreadyWaiting) {
+		return nil, ErrAlreadyOnWaitlist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Tickets may have been released between the availability check and the
+	// insert, when there was nobody to offer them to
+	s.TicketsReleased(ctx, eventID)
+
+	return s.waitlistRepo.GetByUser(ctx, eventID, userID)
+}
+
+// GetPosition returns the user's latest waitlist entry for the event, with
+// its place in the queue while waiting
+func (s *WaitlistService) GetPosition(ctx context.Context, eventID, userID uuid.UUID) (*models.WaitlistEntry, error) {
+	entry, err := s.waitlistRepo.GetByUser(ctx, eventID, userID)
+	if err != nil {
+		return nil, ErrNotOnWaitlist
+	}
+
+	return entry, nil
+}
+
+// GetWaitlist returns one page of an event's waitlist in queue order
+func (s *WaitlistService) GetWaitlist(ctx context.Context, eventID uuid.UUID, filter *models.WaitlistFilter) (*models.Page[*models.WaitlistEntry], error) {
+	return s.waitlistRepo.GetByEventID(ctx, eventID, filter)
+}
+
+// LeaveWaitlist takes the user off the event's waitlist. Leaving with an open
+// offer declines it: the offered booking is cancelled and its tickets go to
+// the next user in the queue.
+func (s *WaitlistService) LeaveWaitlist(ctx context.Context, eventID, userID uuid.UUID) error {
+	entry, err := s.waitlistRepo.GetByUser(ctx, eventID, userID)
+	if err != nil {
+		return ErrNotOnWaitlist
+	}
+
+	switch entry.Status {
+	case models.WaitlistStatusWaiting:
+		return s.waitlistRepo.Leave(ctx, entry.ID)
+	case models.WaitlistStatusOffered:
+	default:
+		return ErrNotOnWaitlist
+	}
+
+	err = s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		// Lock the booking so a payment cannot confirm it while it is cancelled
+		booking, err := repos.Bookings.GetByIDForUpdate(ctx, *entry.BookingID)
+		if err != nil {
+			return err
+		}
+		if booking.Status != models.BookingStatusPending {
+			return ErrNoOffer
+		}
+
+		if err := repos.Bookings.UpdateStatus(ctx, booking.ID, models.BookingStatusCancelled); err != nil {
+			return err
+		}
+		return repos.Waitlist.Leave(ctx, entry.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.TicketsReleased(ctx, eventID)
+	return nil
+}
+
+// AcceptOffer returns the pending booking offered to the user so that it can
+// be paid for
+func (s *WaitlistService) AcceptOffer(ctx context.Context, eventID, userID uuid.UUID) (*models.Booking, error) {
+	entry, err := s.waitlistRepo.GetByUser(ctx, eventID, userID)
+	if err != nil || entry.Status != models.WaitlistStatusOffered {
+		return nil, ErrNoOffer
+	}
+
+	booking, err := s.bookingRepo.GetByID(ctx, *entry.BookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The offer expires with the booking's payment deadline
+	if booking.Status != models.BookingStatusPending || booking.PaymentDeadline.Before(time.Now()) {
+		return nil, ErrNoOffer
+	}
+
+	return booking, nil
+}
+
+// OfferTickets offers the event's available tickets to its waitlist, first
+// come first served. Each offer is a pending booking that expires after the
+// offer TTL like any unpaid booking, releasing its tickets to the next user.
+// An entry the available tickets cannot satisfy keeps its place, and entries
+// behind it for the same ticket type are not served before it. An entry whose
+// user is over a booking limit is skipped but keeps waiting.
+func (s *WaitlistService) OfferTickets(ctx context.Context, eventID uuid.UUID) ([]*models.WaitlistEntry, error) {
+	var offered []*models.WaitlistEntry
+
+	err := s.uow.WithinTransaction(ctx, func(repos *repository.Repositories) error {
+		// Lock the event like ReserveTickets so offers and bookings see the same
+		// availability
+		event, err := repos.Events.GetByIDForUpdate(ctx, eventID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if event.DateTime.Before(now) {
+			return nil
+		}
+
+		entries, err := repos.Waitlist.GetWaitingForUpdate(ctx, eventID)
+		if err != nil {
+			return err
+		}
+
+		available := make(map[uuid.UUID]int, len(event.TicketTypes))
+		prices := make(map[uuid.UUID]models.Money, len(event.TicketTypes))
+		for _, ticketType := range event.TicketTypes {
+			available[ticketType.ID] = ticketType.Available
+			prices[ticketType.ID] = ticketType.Price
+		}
+
+		blocked := make(map[uuid.UUID]bool)
+		for _, entry := range entries {
+			if blocked[entry.TicketTypeID] {
+				continue
+			}
+			if available[entry.TicketTypeID] < entry.Quantity {
+				blocked[entry.TicketTypeID] = true
+				continue
+			}
+
+			err := s.limits.check(ctx, repos, entry.UserID, eventID, entry.Quantity)
+			if errors.Is(err, ErrTooManyPendingBookings) || errors.Is(err, ErrTicketLimitExceeded) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			deadline := now.Add(s.offerTTL)
+			unitPrice := prices[entry.TicketTypeID]
+			booking := &models.Booking{
+				UserID:   entry.UserID,
+				EventID:  eventID,
+				Quantity: entry.Quantity,
+				Items: []*models.BookingItem{{
+					TicketTypeID: entry.TicketTypeID,
+					Quantity:     entry.Quantity,
+					UnitPrice:    unitPrice,
+				}},
+				Status:          models.BookingStatusPending,
+				TotalAmount:     unitPrice.Mul(entry.Quantity),
+				DiscountAmount:  models.Zero(event.Currency),
+				PaymentDeadline: &deadline,
+			}
+			if err := repos.Bookings.Create(ctx, booking); err != nil {
+				return fmt.Errorf("failed to create booking: %w", err)
+			}
+			if err := repos.Waitlist.Offer(ctx, entry.ID, booking.ID); err != nil {
+				return err
+			}
+
+			available[entry.TicketTypeID] -= entry.Quantity
+			entry.Status = models.WaitlistStatusOffered
+			entry.Position = 0
+			entry.BookingID = &booking.ID
+			entry.OfferExpiresAt = &deadline
+			offered = append(offered, entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return offered, nil
+}
+
+// TicketsReleased offers released tickets to the event's waitlist. Failures
+// are logged; the waitlist processor tries again later.
+func (s *WaitlistService) TicketsReleased(ctx context.Context, eventID uuid.UUID) {
+	// The tickets are already released, so offer them even if the caller has
+	// gone away
+	offered, err := s.OfferTickets(context.WithoutCancel(ctx), eventID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to offer released tickets", logging.EventID(eventID), logging.Err(err))
+		return
+	}
+
+	for _, entry := range offered {
+		s.logger.InfoContext(ctx, "Offered released tickets to waitlist entry", logging.EventID(eventID), logging.BookingID(*entry.BookingID), slog.String("waitlist_entry_id", entry.ID.String()))
+	}
+}
+
+// ProcessWaitlists offers available tickets to every upcoming event's
+// waitlist. It catches tickets that were released without a notification,
+// e.g. when an event's allocation was raised.
+func (s *WaitlistService) ProcessWaitlists(ctx context.Context) error {
+	eventIDs, err := s.waitlistRepo.GetEventIDsWithWaiting(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get waitlisted events: %w", err)
+	}
+
+	for _, eventID := range eventIDs {
+		if ctx.Err() != nil {
+			break
+		}
+		s.TicketsReleased(ctx, eventID)
+	}
+
+	return nil
+}
+
+// StartWaitlistProcessor runs ProcessWaitlists every minute until ctx is
+// cancelled.
+func (s *WaitlistService) StartWaitlistProcessor(ctx context.Context) {
+	s.logger.Info("Starting waitlist processor")
+
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ProcessWaitlists(ctx); err != nil {
+				s.logger.Error("Failed to process waitlists", logging.Err(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockWaitlistRepository struct {
+	mock.Mock
+}
+
+func (m *MockWaitlistRepository) Create(ctx context.Context, entry *models.WaitlistEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockWaitlistRepository) GetByUser(ctx context.Context, eventID, userID uuid.UUID) (*models.WaitlistEntry, error) {
+	args := m.Called(eventID, userID)
+	entry, _ := args.Get(0).(*models.WaitlistEntry)
+	return entry, args.Error(1)
+}
+
+func (m *MockWaitlistRepository) GetByEventID(ctx context.Context, eventID uuid.UUID, filter *models.WaitlistFilter) (*models.Page[*models.WaitlistEntry], error) {
+	args := m.Called(eventID, filter)
+	return args.Get(0).(*models.Page[*models.WaitlistEntry]), args.Error(1)
+}
+
+func (m *MockWaitlistRepository) GetWaitingForUpdate(ctx context.Context, eventID uuid.UUID) ([]*models.WaitlistEntry, error) {
+	args := m.Called(eventID)
+	return args.Get(0).([]*models.WaitlistEntry), args.Error(1)
+}
+
+func (m *MockWaitlistRepository) GetEventIDsWithWaiting(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called()
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockWaitlistRepository) Offer(ctx context.Context, id, bookingID uuid.UUID) error {
+	args := m.Called(id, bookingID)
+	return args.Error(0)
+}
+
+func (m *MockWaitlistRepository) Leave(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+type MockReleaseListener struct {
+	mock.Mock
+}
+
+func (m *MockReleaseListener) TicketsReleased(ctx context.Context, eventID uuid.UUID) {
+	m.Called(eventID)
+}
+
+func newWaitlistTestService() (*WaitlistService, *MockWaitlistRepository, *MockBookingRepository, *MockEventRepository) {
+	mockWaitlistRepo := &MockWaitlistRepository{}
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+
+	uow := newMockUnitOfWork(mockBookingRepo, mockEventRepo)
+	uow.repos.Waitlist = mockWaitlistRepo
+
+	service := NewWaitlistService(mockWaitlistRepo, mockBookingRepo, mockEventRepo, uow, 30*time.Minute, logging.Discard())
+	return service, mockWaitlistRepo, mockBookingRepo, mockEventRepo
+}
+
+func waitingEntry(event *models.Event, ticketType *models.TicketType, quantity int) *models.WaitlistEntry {
+	return &models.WaitlistEntry{
+		ID:           uuid.New(),
+		EventID:      event.ID,
+		UserID:       uuid.New(),
+		TicketTypeID: ticketType.ID,
+		Quantity:     quantity,
+		Status:       models.WaitlistStatusWaiting,
+	}
+}
+
+func TestWaitlistService_OfferTickets_FirstComeFirstServed(t *testing.T) {
+	// Setup
+	service, mockWaitlistRepo, mockBookingRepo, mockEventRepo := newWaitlistTestService()
+
+	event := newTieredEvent()
+	standard, vip := event.TicketTypes[0], event.TicketTypes[1]
+	standard.Available = 3
+	vip.Available = 1
+
+	first := waitingEntry(event, standard, 2)
+	second := waitingEntry(event, standard, 2) // only 1 standard ticket left
+	third := waitingEntry(event, standard, 1)  // must not overtake the second entry
+	fourth := waitingEntry(event, vip, 1)      // another ticket type's queue
+
+	// Mock expectations
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+	mockWaitlistRepo.On("GetWaitingForUpdate", event.ID).Return([]*models.WaitlistEntry{first, second, third, fourth}, nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Booking).ID = uuid.New()
+	}).Return(nil)
+	mockWaitlistRepo.On("Offer", first.ID, mock.Anything).Return(nil)
+	mockWaitlistRepo.On("Offer", fourth.ID, mock.Anything).Return(nil)
+
+	// Test
+	offered, err := service.OfferTickets(context.Background(), event.ID)
+
+	// Assertions
+	require.NoError(t, err)
+	require.Len(t, offered, 2)
+	assert.Equal(t, first.ID, offered[0].ID)
+	assert.Equal(t, fourth.ID, offered[1].ID)
+	assert.Equal(t, models.WaitlistStatusOffered, offered[0].Status)
+	assert.NotNil(t, offered[0].OfferExpiresAt)
+
+	booking := mockBookingRepo.Calls[0].Arguments.Get(0).(*models.Booking)
+	assert.Equal(t, first.UserID, booking.UserID)
+	assert.Equal(t, models.BookingStatusPending, booking.Status)
+	assert.Equal(t, usd(6000), booking.TotalAmount)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), *booking.PaymentDeadline, time.Minute)
+	assert.Equal(t, booking.ID, *offered[0].BookingID)
+
+	mockWaitlistRepo.AssertNotCalled(t, "Offer", second.ID, mock.Anything)
+	mockWaitlistRepo.AssertNotCalled(t, "Offer", third.ID, mock.Anything)
+	mockWaitlistRepo.AssertExpectations(t)
+}
+
+func TestWaitlistService_JoinWaitlist_TicketsAvailable(t *testing.T) {
+	// Setup
+	service, mockWaitlistRepo, _, mockEventRepo := newWaitlistTestService()
+
+	event := newTieredEvent()
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+
+	// Test
+	_, err := service.JoinWaitlist(context.Background(), event.ID, uuid.New(), &models.JoinWaitlistRequest{
+		Quantity:     2,
+		TicketTypeID: event.TicketTypes[1].ID.String(), // 5 VIP tickets left
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrTicketsAvailable)
+	mockWaitlistRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestWaitlistService_JoinWaitlist_SoldOut(t *testing.T) {
+	// Setup
+	service, mockWaitlistRepo, _, mockEventRepo := newWaitlistTestService()
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	vip := event.TicketTypes[1]
+	vip.Available = 0
+	position := &models.WaitlistEntry{Status: models.WaitlistStatusWaiting, Position: 4}
+
+	// Mock expectations: nothing is released in the meantime
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+	mockWaitlistRepo.On("Create", mock.AnythingOfType("*models.WaitlistEntry")).Return(nil)
+	mockWaitlistRepo.On("GetWaitingForUpdate", event.ID).Return([]*models.WaitlistEntry{waitingEntry(event, vip, 2)}, nil)
+	mockWaitlistRepo.On("GetByUser", event.ID, userID).Return(position, nil)
+
+	// Test
+	entry, err := service.JoinWaitlist(context.Background(), event.ID, userID, &models.JoinWaitlistRequest{
+		Quantity:     2,
+		TicketTypeID: vip.ID.String(),
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, 4, entry.Position)
+	created := mockWaitlistRepo.Calls[0].Arguments.Get(0).(*models.WaitlistEntry)
+	assert.Equal(t, userID, created.UserID)
+	assert.Equal(t, vip.ID, created.TicketTypeID)
+	mockWaitlistRepo.AssertNotCalled(t, "Offer", mock.Anything, mock.Anything)
+}
+
+func TestWaitlistService_OfferTickets_SkipsUsersOverLimit(t *testing.T) {
+	// Setup
+	service, mockWaitlistRepo, mockBookingRepo, mockEventRepo := newWaitlistTestService()
+	service.SetLimits(BookingLimits{MaxTicketsPerEvent: 4})
+
+	event := newTieredEvent()
+	standard := event.TicketTypes[0]
+	standard.Available = 2
+
+	first := waitingEntry(event, standard, 2)  // already has 3 tickets
+	second := waitingEntry(event, standard, 2) // served in its place
+
+	// Mock expectations
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+	mockWaitlistRepo.On("GetWaitingForUpdate", event.ID).Return([]*models.WaitlistEntry{first, second}, nil)
+	mockBookingRepo.On("CountUserBookings", first.UserID, event.ID).Return(&models.UserBookingCounts{EventTickets: 3}, nil)
+	mockBookingRepo.On("CountUserBookings", second.UserID, event.ID).Return(&models.UserBookingCounts{}, nil)
+	mockBookingRepo.On("Create", mock.AnythingOfType("*models.Booking")).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Booking).ID = uuid.New()
+	}).Return(nil)
+	mockWaitlistRepo.On("Offer", second.ID, mock.Anything).Return(nil)
+
+	// Test
+	offered, err := service.OfferTickets(context.Background(), event.ID)
+
+	// Assertions
+	require.NoError(t, err)
+	require.Len(t, offered, 1)
+	assert.Equal(t, second.ID, offered[0].ID)
+	assert.Equal(t, models.WaitlistStatusWaiting, first.Status)
+	mockWaitlistRepo.AssertNotCalled(t, "Offer", first.ID, mock.Anything)
+	mockWaitlistRepo.AssertExpectations(t)
+}
+
+func TestWaitlistService_JoinWaitlist_TicketLimitExceeded(t *testing.T) {
+	// Setup
+	service, mockWaitlistRepo, mockBookingRepo, mockEventRepo := newWaitlistTestService()
+	service.SetLimits(BookingLimits{MaxTicketsPerEvent: 4})
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	vip := event.TicketTypes[1]
+	vip.Available = 0
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockBookingRepo.On("CountUserBookings", userID, event.ID).Return(&models.UserBookingCounts{EventTickets: 3}, nil)
+
+	// Test
+	_, err := service.JoinWaitlist(context.Background(), event.ID, userID, &models.JoinWaitlistRequest{
+		Quantity:     2,
+		TicketTypeID: vip.ID.String(),
+	})
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrTicketLimitExceeded)
+	mockWaitlistRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestWaitlistService_LeaveWaitlist_DeclinesOffer(t *testing.T) {
+	// Setup
+	service, mockWaitlistRepo, mockBookingRepo, mockEventRepo := newWaitlistTestService()
+
+	userID := uuid.New()
+	event := newTieredEvent()
+	bookingID := uuid.New()
+	entry := &models.WaitlistEntry{ID: uuid.New(), Status: models.WaitlistStatusOffered, BookingID: &bookingID}
+
+	// Mock expectations: the offered booking is cancelled and its tickets are
+	// offered to the rest of the queue
+	mockWaitlistRepo.On("GetByUser", event.ID, userID).Return(entry, nil)
+	mockBookingRepo.On("GetByIDForUpdate", bookingID).Return(&models.Booking{ID: bookingID, Status: models.BookingStatusPending}, nil)
+	mockBookingRepo.On("UpdateStatus", bookingID, models.BookingStatusCancelled).Return(nil)
+	mockWaitlistRepo.On("Leave", entry.ID).Return(nil)
+	mockEventRepo.On("GetByIDForUpdate", event.ID).Return(event, nil)
+	mockWaitlistRepo.On("GetWaitingForUpdate", event.ID).Return([]*models.WaitlistEntry{}, nil)
+
+	// Test
+	err := service.LeaveWaitlist(context.Background(), event.ID, userID)
+
+	// Assertions
+	require.NoError(t, err)
+	mockBookingRepo.AssertExpectations(t)
+	mockWaitlistRepo.AssertExpectations(t)
+}
+
+func TestBookingService_CancelBooking_ReleasesTickets(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	mockEventRepo := &MockEventRepository{}
+	listener := &MockReleaseListener{}
+	service := NewBookingService(mockBookingRepo, mockEventRepo, newMockUnitOfWork(mockBookingRepo, mockEventRepo), 15, logging.Discard())
+	service.SetReleaseListener(listener)
+
+	userID := uuid.New()
+	booking := &models.Booking{ID: uuid.New(), UserID: userID, EventID: uuid.New(), Status: models.BookingStatusPending}
+
+	// Mock expectations
+	mockBookingRepo.On("GetByID", booking.ID).Return(booking, nil)
+	mockBookingRepo.On("UpdatePendingStatus", booking.ID, models.BookingStatusCancelled).Return(nil)
+	listener.On("TicketsReleased", booking.EventID).Return()
+
+	// Test
+	err := service.CancelBooking(context.Background(), booking.ID, &models.Caller{UserID: userID, Role: models.RoleCustomer})
+
+	// Assertions
+	require.NoError(t, err)
+	listener.AssertExpectations(t)
+}
+
+func TestPaymentService_ProcessExpiredBookings_ReleasesTickets(t *testing.T) {
+	// Setup
+	mockBookingRepo := &MockBookingRepository{}
+	listener := &MockReleaseListener{}
+	service := newTestPaymentService(mockBookingRepo, SimulatorModeSucceed)
+	service.SetReleaseListener(listener)
+
+	expired := &models.Booking{ID: uuid.New(), EventID: uuid.New(), Status: models.BookingStatusPending}
+
+	// Mock expectations
+	mockBookingRepo.On("GetExpiredBookings").Return([]*models.Booking{expired}, nil)
+	mockBookingRepo.On("Expire", expired.ID).Return(nil)
+	listener.On("TicketsReleased", expired.EventID).Return()
+
+	// Test
+	err := service.ProcessExpiredBookings(context.Background())
+
+	// Assertions
+	require.NoError(t, err)
+	listener.AssertExpectations(t)
+}
@@ -0,0 +1,702 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Headers of webhook deliveries
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const (
+	webhookSecretPrefix  = "whsec_"
+	webhookConsumerGroup = "webhooks"
+
+	// maximum number of deliveries sent at once
+	webhookBatchSize = 20
+
+	// how long an event read by a consumer that stopped waits before another
+	// consumer claims it
+	webhookClaimIdle = time.Minute
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	// ErrInvalidWebhook is returned for subscriptions that cannot be saved
+	ErrInvalidWebhook = errors.New("invalid webhook")
+
+	// errWebhookAddressBlocked is returned for deliveries to an address that
+	// is not public
+	errWebhookAddressBlocked = errors.New("webhook address is not public")
+
+	// ErrInvalidWebhookSignature is returned by VerifyWebhookSignature for
+	// bodies that were not signed with the secret or were signed too long ago
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// webhookBody is the JSON body of a delivery. ID is the outbox event's, so it
+// is the same for every attempt and subscription.
+type webhookBody struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookService manages webhook subscriptions and delivers booking events to
+// them. Deliveries are queued from the outbox stream, so every booking status
+// change made by BookingService, PaymentService or the waitlist reaches them.
+type WebhookService struct {
+	webhookRepo repository.WebhookRepositoryInterface
+	eventRepo   repository.EventRepositoryInterface
+	rdb         *redis.Client
+	client      *http.Client
+	stream      string
+	consumer    string
+	policy      RetryPolicy
+	logger      *slog.Logger
+}
+
+// NewWebhookService returns a service that reads booking events from stream
+// and gives each delivery attempt timeout to complete
+func NewWebhookService(webhookRepo repository.WebhookRepositoryInterface, eventRepo repository.EventRepositoryInterface, rdb *redis.Client, stream string, timeout time.Duration, policy RetryPolicy, logger *slog.Logger) *WebhookService {
+	consumer, err := os.Hostname()
+	if err != nil {
+		consumer = "webhooks"
+	}
+
+	// Addresses are checked as connections are made, so a host that resolved
+	// to a public address when it was saved cannot be pointed elsewhere later.
+	// Deliveries do not go through a proxy, which would hide the address.
+	dialer := &net.Dialer{Timeout: timeout, Control: checkWebhookDial}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		eventRepo:   eventRepo,
+		rdb:         rdb,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// A redirect fails the attempt rather than being followed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		stream:   stream,
+		consumer: consumer,
+		policy:   policy,
+		logger:   logger,
+	}
+}
+
+// CreateWebhook subscribes the caller to booking events. Organizers can only
+// subscribe to events they organize; admins can also subscribe to all events.
+func (s *WebhookService) CreateWebhook(ctx context.Context, caller *models.Caller, req *models.CreateWebhookRequest) (*models.WebhookSubscription, error) {
+	subscription := &models.WebhookSubscription{
+		UserID:     caller.UserID,
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		Active:     true,
+	}
+
+	if req.EventID != "" {
+		eventID, err := uuid.Parse(req.EventID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event ID: %w", err)
+		}
+		subscription.EventID = &eventID
+	}
+
+	if err := s.checkEventAccess(ctx, caller, subscription.EventID); err != nil {
+		return nil, err
+	}
+	if err := checkWebhookURL(ctx, subscription.URL); err != nil {
+		return nil, err
+	}
+
+	if subscription.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		subscription.Secret = secret
+	}
+
+	if err := s.webhookRepo.Create(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// checkEventAccess checks that caller may receive the booking events of
+// eventID, or of every event when it is nil. Deliveries carry customer and
+// payment details, so only admins and the event's organizer may.
+func (s *WebhookService) checkEventAccess(ctx context.Context, caller *models.Caller, eventID *uuid.UUID) error {
+	if caller.IsAdmin() {
+		return nil
+	}
+	if eventID == nil {
+		return fmt.Errorf("%w: event_id is required", ErrInvalidWebhook)
+	}
+
+	event, err := s.eventRepo.GetByID(ctx, *eventID)
+	if err != nil {
+		return fmt.Errorf("%w: event not found", ErrInvalidWebhook)
+	}
+
+	return checkEventOrganizer(caller, event)
+}
+
+// checkWebhookURL checks that rawURL is an https URL whose host only resolves
+// to public addresses, so that deliveries cannot be aimed at the service's own
+// network
+func checkWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an https URL", ErrInvalidWebhook)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: cannot resolve %s", ErrInvalidWebhook, u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s does not resolve to a public address", ErrInvalidWebhook, u.Hostname())
+		}
+	}
+
+	return nil
+}
+
+// checkWebhookDial refuses connections to addresses that are not public
+func checkWebhookDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", errWebhookAddressBlocked, host)
+	}
+
+	return nil
+}
+
+// nonPublicPrefixes are the special-purpose ranges webhooks are not delivered
+// to: unspecified, loopback, private, shared, link-local, documentation,
+// benchmarking, multicast and reserved addresses, and the IPv6 translation and
+// protocol ranges that can reach them
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/23"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// isPublicIP reports whether ip is outside every non-public range. IPv4
+// addresses mapped into IPv6 are checked as IPv4.
+func isPublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// newWebhookSecret returns a random signing secret
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// GetWebhooks returns the caller's subscriptions, without their secrets
+func (s *WebhookService) GetWebhooks(ctx context.Context, caller *models.Caller) ([]*models.WebhookSubscription, error) {
+	subscriptions, err := s.webhookRepo.GetByUserID(ctx, caller.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+
+	return subscriptions, nil
+}
+
+// GetWebhook returns a subscription of the caller, or of anyone for admins,
+// without its secret
+func (s *WebhookService) GetWebhook(ctx context.Context, id uuid.UUID, caller *models.Caller) (*models.WebhookSubscription, error) {
+	subscription, err := s.getOwnedWebhook(ctx, id, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+func (s *WebhookService) getOwnedWebhook(ctx context.Context, id uuid.UUID, caller *models.Caller) (*models.WebhookSubscription, error) {
+	subscription, err := s.webhookRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.UserID != caller.UserID && !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	return subscription, nil
+}
+
+// UpdateWebhook changes a subscription. The secret is only returned if it was
+// changed.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id uuid.UUID, caller *models.Caller, req *models.UpdateWebhookRequest) (*models.WebhookSubscription, error) {
+	subscription, err := s.getOwnedWebhook(ctx, id, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	// An organizer can no longer change a subscription to an event they do
+	// not organize, such as one made before events recorded their organizer
+	if err := s.checkEventAccess(ctx, caller, subscription.EventID); err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := checkWebhookURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		subscription.EventTypes = req.EventTypes
+	}
+	if req.Secret != nil {
+		subscription.Secret = *req.Secret
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+
+	if err := s.webhookRepo.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	if req.Secret == nil {
+		subscription.Secret = ""
+	}
+
+	return subscription, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id uuid.UUID, caller *models.Caller) error {
+	if _, err := s.getOwnedWebhook(ctx, id, caller); err != nil {
+		return err
+	}
+
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+// GetDeliveries returns one page of a subscription's deliveries, newest first
+func (s *WebhookService) GetDeliveries(ctx context.Context, id uuid.UUID, caller *models.Caller, filter *models.WebhookDeliveryFilter) (*models.Page[*models.WebhookDelivery], error) {
+	if _, err := s.getOwnedWebhook(ctx, id, caller); err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepo.GetDeliveries(ctx, id, filter)
+}
+
+// ReplayDelivery sends a delivery again, whatever its status, with a fresh
+// set of attempts
+func (s *WebhookService) ReplayDelivery(ctx context.Context, id, deliveryID uuid.UUID, caller *models.Caller) (*models.WebhookDelivery, error) {
+	if _, err := s.getOwnedWebhook(ctx, id, caller); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhookRepo.ReplayDelivery(ctx, id, deliveryID)
+	if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	return delivery, err
+}
+
+// HandleEvent queues a delivery of a booking event to each active subscription
+// to its type, for all events or the booking's event. Handling an event again
+// queues nothing new.
+func (s *WebhookService) HandleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	var booking struct {
+		EventID uuid.UUID `json:"event_id"`
+	}
+	if err := json.Unmarshal(event.Payload, &booking); err != nil {
+		return fmt.Errorf("invalid %s payload: %w", event.Type, err)
+	}
+
+	subscriptions, err := s.webhookRepo.GetMatching(ctx, event.Type, booking.EventID)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(webhookBody{
+		ID:        strconv.FormatInt(event.ID, 10),
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook body: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		delivery := &models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			OutboxEventID:  event.ID,
+			EventType:      event.Type,
+			Payload:        body,
+		}
+		if _, err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// StartEventConsumer queues deliveries for the booking events published to
+// the outbox stream until ctx is cancelled. It reads them in a consumer group,
+// acknowledging each event once its deliveries are queued.
+func (s *WebhookService) StartEventConsumer(ctx context.Context) {
+	s.logger.Info("Starting webhook event consumer")
+
+	for ctx.Err() == nil {
+		err := s.createConsumerGroup(ctx)
+		if err == nil {
+			break
+		}
+		if ctx.Err() == nil {
+			s.logger.Error("Failed to create webhook consumer group", logging.Err(err))
+			sleep(ctx, 5*time.Second)
+		}
+	}
+
+	for ctx.Err() == nil {
+		if err := s.consumeEvents(ctx, time.Second); err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to consume booking events for webhooks", logging.Err(err))
+			sleep(ctx, 5*time.Second)
+		}
+	}
+}
+
+// createConsumerGroup creates the consumer group unless it exists. A new
+// group starts at the beginning of the stream, so the events published before
+// the consumer first ran get their deliveries too. The relay trims the stream
+// to about OUTBOX_STREAM_MAX_LEN entries whether or not they have been read, so
+// events are lost if the consumer falls further behind than that.
+func (s *WebhookService) createConsumerGroup(ctx context.Context) error {
+	err := s.rdb.XGroupCreateMkStream(ctx, s.stream, webhookConsumerGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	return nil
+}
+
+// consumeEvents handles one batch of events: events another consumer read
+// but did not acknowledge within webhookClaimIdle, or else new events, waiting
+// up to block for them
+func (s *WebhookService) consumeEvents(ctx context.Context, block time.Duration) error {
+	messages, _, err := s.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   s.stream,
+		Group:    webhookConsumerGroup,
+		Consumer: s.consumer,
+		MinIdle:  webhookClaimIdle,
+		Start:    "0-0",
+		Count:    100,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to claim stale events: %w", err)
+	}
+
+	if len(messages) == 0 {
+		streams, err := s.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    webhookConsumerGroup,
+			Consumer: s.consumer,
+			Streams:  []string{s.stream, ">"},
+			Count:    100,
+			Block:    block,
+		}).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read events: %w", err)
+		}
+		messages = streams[0].Messages
+	}
+
+	for _, message := range messages {
+		event, err := parseOutboxMessage(message)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Skipping malformed booking event", slog.String("message_id", message.ID), logging.Err(err))
+		} else if err := s.HandleEvent(ctx, event); err != nil {
+			// Left unacknowledged, so it is claimed again later
+			return err
+		}
+
+		if err := s.rdb.XAck(ctx, s.stream, webhookConsumerGroup, message.ID).Err(); err != nil {
+			return fmt.Errorf("failed to acknowledge event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// StartDispatcher sends due webhook deliveries until ctx is cancelled,
+// finishing the attempts in progress
+func (s *WebhookService) StartDispatcher(ctx context.Context) {
+	s.logger.Info("Starting webhook dispatcher")
+
+	for ctx.Err() == nil {
+		sent, err := s.DeliverDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to deliver webhooks", logging.Err(err))
+		}
+
+		if sent < webhookBatchSize {
+			sleep(ctx, time.Second)
+		}
+	}
+}
+
+// DeliverDue attempts the deliveries that are due, at the same time, and
+// returns how many it attempted
+func (s *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	// Claim the deliveries for longer than an attempt can take
+	deliveries, err := s.webhookRepo.ClaimDueDeliveries(ctx, webhookBatchSize, time.Now().Add(s.client.Timeout+time.Minute))
+	if err != nil {
+		return 0, err
+	}
+
+	// Attempts in progress are completed even when shutdown begins
+	attemptCtx := context.WithoutCancel(ctx)
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			s.attemptDelivery(attemptCtx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// attemptDelivery sends a delivery and records the outcome. A failed attempt
+// is retried with backoff until the retry policy's attempts are used up.
+func (s *WebhookService) attemptDelivery(ctx context.Context, delivery *models.WebhookDelivery) {
+	ctx = logging.With(ctx,
+		slog.String("webhook_id", delivery.SubscriptionID.String()),
+		slog.String("delivery_id", delivery.ID.String()),
+	)
+
+	subscription, err := s.webhookRepo.GetByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load webhook for delivery", logging.Err(err))
+		return
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = nil
+	delivery.LastError = nil
+
+	if !subscription.Active {
+		err = errors.New("webhook is disabled")
+		delivery.Attempts = s.policy.MaxAttempts
+	} else {
+		var status int
+		status, err = s.send(ctx, subscription, delivery, now)
+		if status != 0 {
+			delivery.ResponseStatus = &status
+		}
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliveryStatusSucceeded
+	case delivery.Attempts >= s.policy.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryStatusFailed
+	default:
+		delivery.Status = models.WebhookDeliveryStatusPending
+		next := now.Add(s.policy.Backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	if err != nil {
+		message := err.Error()
+		delivery.LastError = &message
+		s.logger.WarnContext(ctx, "Webhook delivery attempt failed", slog.Int("attempt", delivery.Attempts), logging.Err(err))
+	}
+
+	if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record webhook delivery", logging.Err(err))
+	}
+}
+
+// send posts a delivery to its subscription and returns the response status,
+// or 0 if there was no response. Only a 2xx status is a success.
+func (s *WebhookService) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	if req.URL.Scheme != "https" {
+		return 0, errors.New("webhook URL is not https")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(subscription.Secret, now, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain a little of the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the signature header of a body sent at timestamp, in the
+// form t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + webhookHMAC(secret, t, body)
+}
+
+// VerifyWebhookSignature checks the signature header of a body received by a
+// subscriber, rejecting signatures made more than tolerance before now
+func VerifyWebhookSignature(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidWebhookSignature
+	}
+
+	expected := webhookHMAC(secret, timestamp, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidWebhookSignature
+}
+
+func webhookHMAC(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
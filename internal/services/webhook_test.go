@@ -0,0 +1,561 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/models"
+	"ticket-booking-system/internal/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) Create(ctx context.Context, subscription *models.WebhookSubscription) error {
+	args := m.Called(subscription)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	// Return a copy, as the service clears secrets of what it returns
+	subscription := *args.Get(0).(*models.WebhookSubscription)
+	return &subscription, args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.WebhookSubscription, error) {
+	args := m.Called(userID)
+	subscriptions, _ := args.Get(0).([]*models.WebhookSubscription)
+	return subscriptions, args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetMatching(ctx context.Context, eventType string, eventID uuid.UUID) ([]*models.WebhookSubscription, error) {
+	args := m.Called(eventType, eventID)
+	subscriptions, _ := args.Get(0).([]*models.WebhookSubscription)
+	return subscriptions, args.Error(1)
+}
+
+func (m *MockWebhookRepository) Update(ctx context.Context, subscription *models.WebhookSubscription) error {
+	args := m.Called(subscription)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	args := m.Called(delivery)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*models.WebhookDelivery, error) {
+	args := m.Called(limit, leaseUntil)
+	deliveries, _ := args.Get(0).([]*models.WebhookDelivery)
+	return deliveries, args.Error(1)
+}
+
+func (m *MockWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetDeliveries(ctx context.Context, subscriptionID uuid.UUID, filter *models.WebhookDeliveryFilter) (*models.Page[*models.WebhookDelivery], error) {
+	args := m.Called(subscriptionID, filter)
+	page, _ := args.Get(0).(*models.Page[*models.WebhookDelivery])
+	return page, args.Error(1)
+}
+
+func (m *MockWebhookRepository) ReplayDelivery(ctx context.Context, subscriptionID, id uuid.UUID) (*models.WebhookDelivery, error) {
+	args := m.Called(subscriptionID, id)
+	delivery, _ := args.Get(0).(*models.WebhookDelivery)
+	return delivery, args.Error(1)
+}
+
+var testWebhookPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+
+func newTestWebhookService(rdb *redis.Client) (*WebhookService, *MockWebhookRepository, *MockEventRepository) {
+	mockWebhookRepo := &MockWebhookRepository{}
+	mockEventRepo := &MockEventRepository{}
+	return NewWebhookService(mockWebhookRepo, mockEventRepo, rdb, "booking_events", 5*time.Second, testWebhookPolicy, logging.Discard()), mockWebhookRepo, mockEventRepo
+}
+
+// webhookReceiver is a subscriber endpoint that verifies signatures and
+// answers with status
+type webhookReceiver struct {
+	t        *testing.T
+	secret   string
+	status   int
+	received []*http.Request
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	require.NoError(r.t, err)
+
+	err = VerifyWebhookSignature(r.secret, req.Header.Get(WebhookSignatureHeader), body, 5*time.Minute, time.Now())
+	assert.NoError(r.t, err)
+
+	r.received = append(r.received, req)
+	w.WriteHeader(r.status)
+}
+
+// newTrustedReceiverServer serves receiver over TLS and has service trust it.
+// The server listens on loopback, so the service's client, which refuses
+// addresses that are not public, is replaced with the server's.
+func newTrustedReceiverServer(t *testing.T, service *WebhookService, receiver *webhookReceiver) *httptest.Server {
+	server := httptest.NewTLSServer(receiver)
+	t.Cleanup(server.Close)
+
+	service.client.Transport = server.Client().Transport
+	return server
+}
+
+func newPendingDelivery(subscriptionID uuid.UUID, attempts int) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: subscriptionID,
+		OutboxEventID:  1,
+		EventType:      models.BookingEventConfirmed,
+		Payload:        json.RawMessage(`{"id":"1","type":"booking.confirmed"}`),
+		Status:         models.WebhookDeliveryStatusPending,
+		Attempts:       attempts,
+	}
+}
+
+func TestSignWebhook_Verify(t *testing.T) {
+	// Setup
+	body := []byte(`{"id":"1"}`)
+	now := time.Now()
+	header := SignWebhook("whsec_test-secret", now, body)
+
+	// Assertions
+	assert.NoError(t, VerifyWebhookSignature("whsec_test-secret", header, body, time.Minute, now))
+	assert.ErrorIs(t, VerifyWebhookSignature("whsec_other-secret", header, body, time.Minute, now), ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, VerifyWebhookSignature("whsec_test-secret", header, []byte(`{"id":"2"}`), time.Minute, now), ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, VerifyWebhookSignature("whsec_test-secret", header, body, time.Minute, now.Add(2*time.Minute)), ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, VerifyWebhookSignature("whsec_test-secret", "v1=abc", body, time.Minute, now), ErrInvalidWebhookSignature)
+}
+
+func TestWebhookService_CreateWebhook_GeneratesSecret(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, mockEventRepo := newTestWebhookService(nil)
+	caller := &models.Caller{UserID: uuid.New(), Role: models.RoleOrganizer}
+	event := &models.Event{ID: uuid.New(), OrganizerID: &caller.UserID}
+	req := &models.CreateWebhookRequest{
+		URL:        "https://93.184.216.34/hooks",
+		EventTypes: []string{models.BookingEventConfirmed},
+		EventID:    event.ID.String(),
+	}
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+	mockWebhookRepo.On("Create", mock.AnythingOfType("*models.WebhookSubscription")).Return(nil)
+
+	// Test
+	subscription, err := service.CreateWebhook(context.Background(), caller, req)
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, caller.UserID, subscription.UserID)
+	assert.True(t, subscription.Active)
+	assert.Contains(t, subscription.Secret, webhookSecretPrefix)
+	mockWebhookRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_CreateWebhook_OnlyOwnEvents(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, mockEventRepo := newTestWebhookService(nil)
+	organizerA := uuid.New()
+	caller := &models.Caller{UserID: uuid.New(), Role: models.RoleOrganizer}
+	event := &models.Event{ID: uuid.New(), OrganizerID: &organizerA}
+
+	// Mock expectations
+	mockEventRepo.On("GetByID", event.ID).Return(event, nil)
+
+	// Test
+	_, allEventsErr := service.CreateWebhook(context.Background(), caller, &models.CreateWebhookRequest{
+		URL:        "https://93.184.216.34/hooks",
+		EventTypes: []string{models.BookingEventConfirmed},
+	})
+	_, otherEventErr := service.CreateWebhook(context.Background(), caller, &models.CreateWebhookRequest{
+		URL:        "https://93.184.216.34/hooks",
+		EventTypes: []string{models.BookingEventConfirmed},
+		EventID:    event.ID.String(),
+	})
+
+	// Assertions
+	assert.ErrorIs(t, allEventsErr, ErrInvalidWebhook)
+	assert.ErrorIs(t, otherEventErr, ErrForbidden)
+	mockWebhookRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestWebhookService_CreateWebhook_AdminAllEvents(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, _ := newTestWebhookService(nil)
+	caller := &models.Caller{UserID: uuid.New(), Role: models.RoleAdmin}
+
+	// Mock expectations
+	mockWebhookRepo.On("Create", mock.AnythingOfType("*models.WebhookSubscription")).Return(nil)
+
+	// Test
+	subscription, err := service.CreateWebhook(context.Background(), caller, &models.CreateWebhookRequest{
+		URL:        "https://93.184.216.34/hooks",
+		EventTypes: []string{models.BookingEventConfirmed},
+	})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Nil(t, subscription.EventID)
+	mockWebhookRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_CreateWebhook_RejectsInternalURLs(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, _ := newTestWebhookService(nil)
+	caller := &models.Caller{UserID: uuid.New(), Role: models.RoleAdmin}
+	urls := []string{
+		"http://93.184.216.34/hooks",
+		"https://127.0.0.1:6379/",
+		"https://localhost/hooks",
+		"https://169.254.169.254/latest/meta-data",
+		"https://10.0.0.5/hooks",
+		"https://192.168.1.1/hooks",
+		"https://[::1]/hooks",
+		"https://0.0.0.0/hooks",
+		"https://100.64.0.1/hooks",
+		"https://198.18.0.1/hooks",
+		"https://[::ffff:127.0.0.1]/hooks",
+		"https://[fd00::1]/hooks",
+	}
+
+	for _, rawURL := range urls {
+		// Test
+		_, err := service.CreateWebhook(context.Background(), caller, &models.CreateWebhookRequest{
+			URL:        rawURL,
+			EventTypes: []string{models.BookingEventConfirmed},
+		})
+
+		// Assertions
+		assert.ErrorIs(t, err, ErrInvalidWebhook, rawURL)
+	}
+	mockWebhookRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	public := []string{"93.184.216.34", "1.1.1.1", "2606:4700:4700::1111"}
+	nonPublic := []string{
+		"0.1.2.3", "10.1.2.3", "100.64.0.1", "100.127.255.254", "127.0.0.1",
+		"169.254.169.254", "172.16.0.1", "192.0.0.8", "192.0.2.1", "192.88.99.1",
+		"192.168.1.1", "198.18.0.1", "198.51.100.1", "203.0.113.10", "224.0.0.1",
+		"255.255.255.255", "::", "::1", "::ffff:10.0.0.1", "64:ff9b::a9fe:a9fe",
+		"100::1", "2001::1", "2001:db8::1", "fd00::1", "fe80::1", "ff02::1",
+	}
+
+	for _, address := range public {
+		assert.True(t, isPublicIP(net.ParseIP(address)), address)
+	}
+	for _, address := range nonPublic {
+		assert.False(t, isPublicIP(net.ParseIP(address)), address)
+	}
+}
+
+func TestWebhookService_DeliverDue_RefusesInternalAddress(t *testing.T) {
+	// Setup: the receiver is on loopback and the service's own client is kept
+	service, mockWebhookRepo, _ := newTestWebhookService(nil)
+	receiver := &webhookReceiver{t: t, secret: "whsec_test-secret", status: http.StatusOK}
+	server := httptest.NewTLSServer(receiver)
+	defer server.Close()
+
+	subscription := &models.WebhookSubscription{ID: uuid.New(), URL: server.URL, Secret: receiver.secret, Active: true}
+	delivery := newPendingDelivery(subscription.ID, 0)
+
+	// Mock expectations
+	mockWebhookRepo.On("ClaimDueDeliveries", webhookBatchSize, mock.AnythingOfType("time.Time")).Return([]*models.WebhookDelivery{delivery}, nil)
+	mockWebhookRepo.On("GetByID", subscription.ID).Return(subscription, nil)
+	mockWebhookRepo.On("UpdateDelivery", delivery).Return(nil)
+
+	// Test
+	_, err := service.DeliverDue(context.Background())
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Empty(t, receiver.received)
+	assert.Nil(t, delivery.ResponseStatus)
+	require.NotNil(t, delivery.LastError)
+	assert.Contains(t, *delivery.LastError, errWebhookAddressBlocked.Error())
+}
+
+func TestWebhookService_GetWebhook_Forbidden(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, _ := newTestWebhookService(nil)
+	subscription := &models.WebhookSubscription{ID: uuid.New(), UserID: uuid.New(), Secret: "whsec_test-secret"}
+	caller := &models.Caller{UserID: uuid.New(), Role: models.RoleOrganizer}
+
+	// Mock expectations
+	mockWebhookRepo.On("GetByID", subscription.ID).Return(subscription, nil)
+
+	// Test
+	_, err := service.GetWebhook(context.Background(), subscription.ID, caller)
+	_, replayErr := service.ReplayDelivery(context.Background(), subscription.ID, uuid.New(), caller)
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ErrorIs(t, replayErr, ErrForbidden)
+	mockWebhookRepo.AssertNotCalled(t, "ReplayDelivery", mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_GetWebhook_RedactsSecret(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, _ := newTestWebhookService(nil)
+	caller := &models.Caller{UserID: uuid.New(), Role: models.RoleOrganizer}
+	subscription := &models.WebhookSubscription{ID: uuid.New(), UserID: caller.UserID, Secret: "whsec_test-secret"}
+
+	// Mock expectations
+	mockWebhookRepo.On("GetByID", subscription.ID).Return(subscription, nil)
+
+	// Test
+	result, err := service.GetWebhook(context.Background(), subscription.ID, caller)
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Empty(t, result.Secret)
+}
+
+func TestWebhookService_HandleEvent_QueuesMatchingSubscriptions(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, _ := newTestWebhookService(nil)
+	eventID := uuid.New()
+	payload, _ := json.Marshal(map[string]string{"id": uuid.New().String(), "event_id": eventID.String()})
+	event := &models.OutboxEvent{ID: 42, Type: models.BookingEventConfirmed, Payload: payload, CreatedAt: time.Now()}
+	subscriptions := []*models.WebhookSubscription{{ID: uuid.New()}, {ID: uuid.New()}}
+
+	// Mock expectations
+	mockWebhookRepo.On("GetMatching", models.BookingEventConfirmed, eventID).Return(subscriptions, nil)
+	for _, subscription := range subscriptions {
+		subscriptionID := subscription.ID
+		mockWebhookRepo.On("CreateDelivery", mock.MatchedBy(func(delivery *models.WebhookDelivery) bool {
+			var body webhookBody
+			return delivery.SubscriptionID == subscriptionID &&
+				delivery.OutboxEventID == 42 &&
+				json.Unmarshal(delivery.Payload, &body) == nil &&
+				body.ID == "42" && body.Type == models.BookingEventConfirmed && string(body.Data) == string(payload)
+		})).Return(true, nil).Once()
+	}
+
+	// Test
+	err := service.HandleEvent(context.Background(), event)
+
+	// Assertions
+	require.NoError(t, err)
+	mockWebhookRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_DeliverDue_Succeeds(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, _ := newTestWebhookService(nil)
+	receiver := &webhookReceiver{t: t, secret: "whsec_test-secret", status: http.StatusNoContent}
+	server := newTrustedReceiverServer(t, service, receiver)
+
+	subscription := &models.WebhookSubscription{ID: uuid.New(), URL: server.URL, Secret: receiver.secret, Active: true}
+	delivery := newPendingDelivery(subscription.ID, 0)
+
+	// Mock expectations
+	mockWebhookRepo.On("ClaimDueDeliveries", webhookBatchSize, mock.AnythingOfType("time.Time")).Return([]*models.WebhookDelivery{delivery}, nil)
+	mockWebhookRepo.On("GetByID", subscription.ID).Return(subscription, nil)
+	mockWebhookRepo.On("UpdateDelivery", delivery).Return(nil)
+
+	// Test
+	sent, err := service.DeliverDue(context.Background())
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, receiver.received, 1)
+	assert.Equal(t, models.BookingEventConfirmed, receiver.received[0].Header.Get(WebhookEventHeader))
+	assert.Equal(t, delivery.ID.String(), receiver.received[0].Header.Get(WebhookDeliveryHeader))
+	assert.Equal(t, models.WebhookDeliveryStatusSucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	require.NotNil(t, delivery.ResponseStatus)
+	assert.Equal(t, http.StatusNoContent, *delivery.ResponseStatus)
+	assert.Nil(t, delivery.LastError)
+}
+
+func TestWebhookService_DeliverDue_RetriesWithBackoff(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, _ := newTestWebhookService(nil)
+	receiver := &webhookReceiver{t: t, secret: "whsec_test-secret", status: http.StatusServiceUnavailable}
+	server := newTrustedReceiverServer(t, service, receiver)
+
+	subscription := &models.WebhookSubscription{ID: uuid.New(), URL: server.URL, Secret: receiver.secret, Active: true}
+	delivery := newPendingDelivery(subscription.ID, 0)
+
+	// Mock expectations
+	mockWebhookRepo.On("ClaimDueDeliveries", webhookBatchSize, mock.AnythingOfType("time.Time")).Return([]*models.WebhookDelivery{delivery}, nil)
+	mockWebhookRepo.On("GetByID", subscription.ID).Return(subscription, nil)
+	mockWebhookRepo.On("UpdateDelivery", delivery).Return(nil)
+
+	// Test
+	before := time.Now()
+	_, err := service.DeliverDue(context.Background())
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Len(t, receiver.received, 1)
+	assert.Equal(t, models.WebhookDeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	require.NotNil(t, delivery.ResponseStatus)
+	assert.Equal(t, http.StatusServiceUnavailable, *delivery.ResponseStatus)
+	require.NotNil(t, delivery.LastError)
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.True(t, delivery.NextAttemptAt.After(before.Add(testWebhookPolicy.Backoff(1)/2)))
+}
+
+func TestWebhookService_DeliverDue_FailsAfterLastAttempt(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, _ := newTestWebhookService(nil)
+	receiver := &webhookReceiver{t: t, secret: "whsec_test-secret", status: http.StatusInternalServerError}
+	server := newTrustedReceiverServer(t, service, receiver)
+
+	subscription := &models.WebhookSubscription{ID: uuid.New(), URL: server.URL, Secret: receiver.secret, Active: true}
+	delivery := newPendingDelivery(subscription.ID, testWebhookPolicy.MaxAttempts-1)
+
+	// Mock expectations
+	mockWebhookRepo.On("ClaimDueDeliveries", webhookBatchSize, mock.AnythingOfType("time.Time")).Return([]*models.WebhookDelivery{delivery}, nil)
+	mockWebhookRepo.On("GetByID", subscription.ID).Return(subscription, nil)
+	mockWebhookRepo.On("UpdateDelivery", delivery).Return(nil)
+
+	// Test
+	_, err := service.DeliverDue(context.Background())
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryStatusFailed, delivery.Status)
+	assert.Equal(t, testWebhookPolicy.MaxAttempts, delivery.Attempts)
+	assert.Nil(t, delivery.NextAttemptAt)
+}
+
+func TestWebhookService_DeliverDue_DisabledSubscription(t *testing.T) {
+	// Setup
+	service, mockWebhookRepo, _ := newTestWebhookService(nil)
+	receiver := &webhookReceiver{t: t, secret: "whsec_test-secret", status: http.StatusOK}
+	server := newTrustedReceiverServer(t, service, receiver)
+
+	subscription := &models.WebhookSubscription{ID: uuid.New(), URL: server.URL, Secret: receiver.secret, Active: false}
+	delivery := newPendingDelivery(subscription.ID, 0)
+
+	// Mock expectations
+	mockWebhookRepo.On("ClaimDueDeliveries", webhookBatchSize, mock.AnythingOfType("time.Time")).Return([]*models.WebhookDelivery{delivery}, nil)
+	mockWebhookRepo.On("GetByID", subscription.ID).Return(subscription, nil)
+	mockWebhookRepo.On("UpdateDelivery", delivery).Return(nil)
+
+	// Test
+	_, err := service.DeliverDue(context.Background())
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Empty(t, receiver.received)
+	assert.Equal(t, models.WebhookDeliveryStatusFailed, delivery.Status)
+}
+
+func TestWebhookService_ConsumeEvents(t *testing.T) {
+	// Setup
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	service, mockWebhookRepo, _ := newTestWebhookService(rdb)
+	ctx := context.Background()
+
+	// The event is published before the consumer first runs, and the group
+	// is only created once
+	event := newBookingEvent(7, uuid.New(), models.BookingEventCreated)
+	require.NoError(t, rdb.XAdd(ctx, &redis.XAddArgs{Stream: "booking_events", Values: outboxMessageValues(event)}).Err())
+	require.NoError(t, service.createConsumerGroup(ctx))
+	require.NoError(t, service.createConsumerGroup(ctx))
+
+	// Mock expectations
+	mockWebhookRepo.On("GetMatching", models.BookingEventCreated, uuid.Nil).Return([]*models.WebhookSubscription(nil), nil).Once()
+
+	// Test
+	err := service.consumeEvents(ctx, time.Millisecond)
+
+	// Assertions
+	require.NoError(t, err)
+	mockWebhookRepo.AssertExpectations(t)
+	pending, err := rdb.XPending(ctx, "booking_events", webhookConsumerGroup).Result()
+	require.NoError(t, err)
+	assert.Zero(t, pending.Count)
+}
+
+func TestWebhookRepository_GetMatching_OnlyOrganizerAndAdmins(t *testing.T) {
+	db := setupTestDB(t)
+
+	userRepo := repository.NewUserRepository(db)
+	eventRepo := repository.NewEventRepository(db, nil)
+	webhookRepo := repository.NewWebhookRepository(db)
+	uow := repository.NewUnitOfWork(db, nil, logging.Discard())
+
+	organizerA := createTestUser(t, userRepo, models.RoleOrganizer)
+	organizerB := createTestUser(t, userRepo, models.RoleOrganizer)
+	admin := createTestUser(t, userRepo, models.RoleAdmin)
+
+	price := usd(1000)
+	event, err := NewEventService(eventRepo, repository.NewVenueRepository(db), nil, uow).CreateEvent(context.Background(),
+		&models.Caller{UserID: organizerA.ID, Role: organizerA.Role},
+		&models.CreateEventRequest{Name: "Webhook Test Event", DateTime: time.Now().Add(24 * time.Hour), TotalTickets: 10, TicketPrice: &price},
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { eventRepo.Delete(context.Background(), event.ID) })
+
+	// Subscriptions saved directly, as the service would refuse organizer B's
+	subscribe := func(userID uuid.UUID, eventID *uuid.UUID) *models.WebhookSubscription {
+		subscription := &models.WebhookSubscription{
+			UserID:     userID,
+			URL:        "https://93.184.216.34/hooks",
+			Secret:     "whsec_test-secret",
+			EventTypes: []string{models.BookingEventConfirmed},
+			EventID:    eventID,
+			Active:     true,
+		}
+		require.NoError(t, webhookRepo.Create(context.Background(), subscription))
+		return subscription
+	}
+	ownEvent := subscribe(organizerA.ID, &event.ID)
+	adminAll := subscribe(admin.ID, nil)
+	subscribe(organizerB.ID, &event.ID)
+	subscribe(organizerB.ID, nil)
+
+	// Test
+	subscriptions, err := webhookRepo.GetMatching(context.Background(), models.BookingEventConfirmed, event.ID)
+
+	// Assertions: organizer B receives nothing of organizer A's event
+	require.NoError(t, err)
+	var ids []uuid.UUID
+	for _, subscription := range subscriptions {
+		ids = append(ids, subscription.ID)
+	}
+	assert.Contains(t, ids, ownEvent.ID)
+	assert.Contains(t, ids, adminAll.ID)
+	for _, subscription := range subscriptions {
+		assert.NotEqual(t, organizerB.ID, subscription.UserID)
+	}
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook traces Redis commands and pipelines made on behalf of a traced
+// operation. Commands without a parent span, such as the polling of the
+// background workers, are not traced. Command arguments are left out of spans
+// as they can hold job payloads.
+type RedisHook struct{}
+
+// InstrumentRedis adds a RedisHook to rdb
+func InstrumentRedis(rdb *redis.Client) {
+	rdb.AddHook(RedisHook{})
+}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !HasParent(ctx) {
+			return next(ctx, cmd)
+		}
+
+		ctx, span := startRedisSpan(ctx, cmd.FullName(), cmd.Name())
+		defer span.End()
+
+		err := next(ctx, cmd)
+		recordRedisError(span, err)
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !HasParent(ctx) {
+			return next(ctx, cmds)
+		}
+
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+
+		ctx, span := startRedisSpan(ctx, "pipeline", strings.Join(names, " "))
+		defer span.End()
+		span.SetAttributes(attribute.Int("db.redis.num_cmd", len(cmds)))
+
+		err := next(ctx, cmds)
+		recordRedisError(span, err)
+		return err
+	}
+}
+
+func startRedisSpan(ctx context.Context, name, operation string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "redis "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemRedis,
+			semconv.DBOperation(operation),
+		),
+	)
+}
+
+// recordRedisError marks span as failed, unless err is a missing key or an
+// empty blocking read, which are ordinary results
+func recordRedisError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
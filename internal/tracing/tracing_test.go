@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that records spans for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func TestInjectExtract(t *testing.T) {
+	recordSpans(t)
+
+	ctx, span := Tracer().Start(context.Background(), "request")
+	defer span.End()
+
+	carrier := Inject(ctx)
+	require.Contains(t, carrier, "traceparent")
+
+	extracted := Extract(context.Background(), carrier)
+	assert.True(t, HasParent(extracted))
+
+	_, job := Tracer().Start(extracted, "job")
+	defer job.End()
+	assert.Equal(t, span.SpanContext().TraceID(), job.SpanContext().TraceID())
+}
+
+func TestInject_NoSpan(t *testing.T) {
+	recordSpans(t)
+
+	assert.Nil(t, Inject(context.Background()))
+	assert.False(t, HasParent(Extract(context.Background(), nil)))
+}
+
+func TestRedisHook_TracesOnlyWithParent(t *testing.T) {
+	recorder := recordSpans(t)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	InstrumentRedis(rdb)
+
+	// Background polling is not traced
+	require.NoError(t, rdb.LPush(context.Background(), "queue", "job").Err())
+	assert.Empty(t, recorder.Ended())
+
+	ctx, parent := Tracer().Start(context.Background(), "request")
+	require.NoError(t, rdb.LPush(ctx, "queue", "job").Err())
+	err := rdb.Get(ctx, "missing").Err()
+	require.ErrorIs(t, err, redis.Nil)
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LLen(ctx, "queue")
+		pipe.LRange(ctx, "queue", 0, -1)
+		return nil
+	})
+	require.NoError(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	assert.Equal(t, "redis lpush", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, "redis get", spans[1].Name())
+	assert.Equal(t, "Unset", spans[1].Status().Code.String(), "a missing key is not an error")
+	assert.Equal(t, "redis pipeline", spans[2].Name())
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
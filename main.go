@@ -1,85 +1,375 @@
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"ticket-booking-system/internal/config"
 	"ticket-booking-system/internal/database"
 	"ticket-booking-system/internal/handlers"
+	"ticket-booking-system/internal/lifecycle"
+	"ticket-booking-system/internal/logging"
+	"ticket-booking-system/internal/metrics"
+	"ticket-booking-system/internal/middleware"
+	"ticket-booking-system/internal/models"
 	"ticket-booking-system/internal/repository"
 	"ticket-booking-system/internal/services"
+	"ticket-booking-system/internal/tracing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Initialize logging; the standard library logger writes through it too
+	logger, err := logging.New(os.Stdout, cfg.LogLevel)
+	if err != nil {
+		slog.Error("Invalid logging configuration", logging.Err(err))
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	// Tokens and tickets signed with a known secret can be forged, so it is
+	// only accepted in dev mode
+	if insecure := cfg.InsecureSecrets(); len(insecure) > 0 {
+		if !cfg.DevMode {
+			fatal(logger, "Signing secrets must be set outside dev mode",
+				fmt.Errorf("%s not set", strings.Join(insecure, ", ")))
+		}
+		logger.Warn("Using insecure default signing secrets in dev mode", slog.Any("secrets", insecure))
+	}
+
+	// Initialize tracing before the database and Redis clients are created
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.TracingOTLPEndpoint,
+		OTLPInsecure: cfg.TracingOTLPInsecure,
+		SampleRatio:  cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal(logger, "Failed to set up tracing", err)
+	}
+
 	// Initialize database
 	db, err := database.NewConnection(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal(logger, "Failed to connect to database", err)
 	}
-	defer db.Close()
 
 	// Run migrations
-	if err := database.RunMigrations(cfg.DatabaseURL); err != nil {
-		log.Fatal("Failed to run migrations:", err)
+	if err := database.RunMigrations(cfg.DatabaseURL, logger); err != nil {
+		fatal(logger, "Failed to run migrations", err)
 	}
 
 	// Initialize Redis
 	rdb := redis.NewClient(&redis.Options{
 		Addr: cfg.RedisURL,
 	})
+	tracing.InstrumentRedis(rdb)
+
+	holdTTL := time.Duration(cfg.HoldTTL) * time.Minute
 
-	// Initialize repositories
-	eventRepo := repository.NewEventRepository(db)
+	// Initialize repositories. A hold lives for at most two TTLs, as it can be
+	// extended once.
+	holdRepo := repository.NewHoldRepository(rdb, 2*holdTTL)
+	eventRepo := repository.NewEventRepository(db, holdRepo)
 	userRepo := repository.NewUserRepository(db)
 	bookingRepo := repository.NewBookingRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
+	venueRepo := repository.NewVenueRepository(db)
+	promoCodeRepo := repository.NewPromoCodeRepository(db)
+	waitlistRepo := repository.NewWaitlistRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	scannerRepo := repository.NewScannerRepository(db)
+	scanRepo := repository.NewScanRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	unitOfWork := repository.NewUnitOfWork(db, holdRepo, logger)
+
+	// Initialize payment gateway
+	simulatorMode, err := services.ParseSimulatorMode(cfg.PaymentSimulatorMode)
+	if err != nil {
+		fatal(logger, "Invalid payment gateway configuration", err)
+	}
+	paymentGateway := services.NewSimulatorGateway(services.SimulatorConfig{
+		Mode:        simulatorMode,
+		Latency:     time.Duration(cfg.PaymentSimulatorLatency) * time.Millisecond,
+		FailureRate: cfg.PaymentSimulatorFailureRate,
+	})
 
 	// Initialize services
-	eventService := services.NewEventService(eventRepo)
+	eventService := services.NewEventService(eventRepo, venueRepo, holdRepo, unitOfWork)
+	ticketTypeService := services.NewTicketTypeService(eventRepo, unitOfWork)
+	promoCodeService := services.NewPromoCodeService(promoCodeRepo, eventRepo)
+	venueService := services.NewVenueService(venueRepo, unitOfWork)
 	userService := services.NewUserService(userRepo)
-	bookingService := services.NewBookingService(bookingRepo, eventRepo, cfg.PaymentDeadline)
-	paymentService := services.NewPaymentService(rdb, bookingRepo)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, time.Duration(cfg.IdempotencyKeyTTL)*time.Hour)
+	authService := services.NewAuthService(
+		userRepo,
+		cfg.JWTSecret,
+		time.Duration(cfg.JWTAccessTTL)*time.Minute,
+		time.Duration(cfg.JWTRefreshTTL)*time.Hour,
+	)
+	bookingService := services.NewBookingService(bookingRepo, eventRepo, unitOfWork, cfg.PaymentDeadline, logger)
+	holdService := services.NewHoldService(holdRepo, eventRepo, unitOfWork, holdTTL)
+	ticketSigner := services.NewTicketSigner(cfg.TicketSigningKey)
+	ticketService := services.NewTicketService(ticketRepo, bookingRepo, ticketSigner)
+	checkInService := services.NewCheckInService(ticketRepo, scannerRepo, scanRepo, eventRepo, unitOfWork, ticketSigner)
+	paymentService := services.NewPaymentService(
+		rdb,
+		bookingRepo,
+		refundRepo,
+		paymentGateway,
+		time.Duration(cfg.PaymentGatewayTimeout)*time.Second,
+		services.RetryPolicy{
+			MaxAttempts: cfg.PaymentMaxAttempts,
+			BaseDelay:   time.Duration(cfg.PaymentRetryBaseDelay) * time.Second,
+			MaxDelay:    time.Duration(cfg.PaymentRetryMaxDelay) * time.Second,
+		},
+		logger,
+	)
+	waitlistService := services.NewWaitlistService(
+		waitlistRepo,
+		bookingRepo,
+		eventRepo,
+		unitOfWork,
+		time.Duration(cfg.WaitlistOfferTTL)*time.Minute,
+		logger,
+	)
 
-	// Start payment processor in background
-	go paymentService.StartProcessor()
+	outboxRelay := services.NewOutboxRelay(
+		unitOfWork,
+		rdb,
+		cfg.OutboxStream,
+		int64(cfg.OutboxStreamMaxLen),
+		time.Duration(cfg.OutboxPollInterval)*time.Millisecond,
+		logger,
+	)
 
-	// Start expired booking processor in background
-	go paymentService.StartExpiredBookingProcessor()
+	// Webhooks receive booking events from the outbox stream, so every booking
+	// status change reaches them
+	webhookService := services.NewWebhookService(
+		webhookRepo,
+		eventRepo,
+		rdb,
+		cfg.OutboxStream,
+		time.Duration(cfg.WebhookTimeout)*time.Second,
+		services.RetryPolicy{
+			MaxAttempts: cfg.WebhookMaxAttempts,
+			BaseDelay:   time.Duration(cfg.WebhookRetryBaseDelay) * time.Second,
+			MaxDelay:    time.Duration(cfg.WebhookRetryMaxDelay) * time.Second,
+		},
+		logger,
+	)
+
+	// Readiness requires Postgres, Redis and a clean schema; a stalled worker
+	// only degrades it. The payment processor beats every few seconds while
+	// idle and the expiry processor every minute.
+	healthService := services.NewHealthService(time.Duration(cfg.HealthCheckTimeout) * time.Millisecond)
+	healthService.AddCheck("postgres", true, func(ctx context.Context, _ *models.HealthCheck) error {
+		return db.PingContext(ctx)
+	})
+	healthService.AddCheck("redis", true, func(ctx context.Context, _ *models.HealthCheck) error {
+		return rdb.Ping(ctx).Err()
+	})
+	healthService.AddCheck("migrations", true, services.MigrationCheck(func(ctx context.Context) (uint, bool, error) {
+		return database.MigrationVersion(ctx, db)
+	}))
+	healthService.AddHeartbeat("payment_processor", paymentService.ProcessorHeartbeat(), time.Minute)
+	healthService.AddHeartbeat("expired_booking_processor", paymentService.ExpiryHeartbeat(), 3*time.Minute)
+
+	// Initialize metrics
+	appMetrics := metrics.New(prometheus.NewRegistry())
+	appMetrics.RegisterDB(db)
+	appMetrics.RegisterPaymentQueue(paymentService)
+	appMetrics.RegisterSellThrough(eventRepo)
+	bookingService.SetMetrics(appMetrics)
+	holdService.SetMetrics(appMetrics)
+	paymentService.SetMetrics(appMetrics)
+	outboxRelay.SetMetrics(appMetrics)
+
+	// Offer tickets released by cancellations, refunds, declined payments and
+	// expired bookings to the event's waitlist
+	bookingService.SetReleaseListener(waitlistService)
+	paymentService.SetReleaseListener(waitlistService)
+
+	// Cap unpaid bookings, holds, waitlist offers and tickets per customer, so
+	// that bots cannot tie up an event's inventory
+	bookingLimits := services.BookingLimits{
+		MaxPendingBookings: cfg.MaxPendingBookingsPerUser,
+		MaxTicketsPerEvent: cfg.MaxTicketsPerEventPerUser,
+	}
+	bookingService.SetLimits(bookingLimits)
+	holdService.SetLimits(bookingLimits)
+	waitlistService.SetLimits(bookingLimits)
+
+	rateLimits, err := services.ParseRateLimits(cfg.RateLimits)
+	if err != nil {
+		fatal(logger, "Invalid rate limit configuration", err)
+	}
+	rateLimiter := services.NewRateLimiter(rdb)
+
+	// Create the bootstrap admin account if configured
+	if cfg.AdminEmail != "" && cfg.AdminPassword != "" {
+		if err := userService.EnsureAdmin(context.Background(), cfg.AdminEmail, cfg.AdminPassword); err != nil {
+			fatal(logger, "Failed to create admin user", err)
+		}
+	}
 
 	// Initialize handlers
 	eventHandler := handlers.NewEventHandler(eventService)
+	venueHandler := handlers.NewVenueHandler(venueService)
+	ticketTypeHandler := handlers.NewTicketTypeHandler(ticketTypeService)
+	promoCodeHandler := handlers.NewPromoCodeHandler(promoCodeService)
 	userHandler := handlers.NewUserHandler(userService)
 	bookingHandler := handlers.NewBookingHandler(bookingService, paymentService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	waitlistHandler := handlers.NewWaitlistHandler(waitlistService, paymentService)
+	holdHandler := handlers.NewHoldHandler(holdService, bookingService, paymentService)
+	ticketHandler := handlers.NewTicketHandler(ticketService)
+	checkInHandler := handlers.NewCheckInHandler(checkInService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	authHandler := handlers.NewAuthHandler(authService, userService)
+	healthHandler := handlers.NewHealthHandler(healthService)
 
 	// Setup routes
-	router := setupRoutes(eventHandler, userHandler, bookingHandler)
+	router := setupRoutes(
+		time.Duration(cfg.RequestTimeout)*time.Second,
+		logger,
+		appMetrics,
+		eventHandler,
+		venueHandler,
+		ticketTypeHandler,
+		userHandler,
+		bookingHandler,
+		paymentHandler,
+		promoCodeHandler,
+		waitlistHandler,
+		holdHandler,
+		ticketHandler,
+		checkInHandler,
+		webhookHandler,
+		authHandler,
+		healthHandler,
+		authService,
+		checkInService,
+		idempotencyService,
+		middleware.RateLimit(rateLimiter, rateLimits, logger),
+	)
+
+	// Only take the client IP, which anonymous requests are rate limited by,
+	// from X-Forwarded-For when it was set by a trusted proxy
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		fatal(logger, "Invalid trusted proxy configuration", err)
+	}
 
-	// Start server
+	// Create server
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Register background workers and resources; shutdown runs HTTP first,
+	// then workers, then Redis and the database
+	manager := lifecycle.NewManager(server, time.Duration(cfg.ShutdownTimeout)*time.Second, logger)
+	manager.Go("payment processor", paymentService.StartProcessor)
+	manager.Go("expired booking processor", paymentService.StartExpiredBookingProcessor)
+	manager.Go("waitlist processor", waitlistService.StartWaitlistProcessor)
+	manager.Go("outbox relay", outboxRelay.StartRelay)
+	manager.Go("webhook event consumer", webhookService.StartEventConsumer)
+	manager.Go("webhook dispatcher", webhookService.StartDispatcher)
+	manager.OnShutdown("tracing", func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdownTracing(ctx)
+	})
+	manager.OnShutdown("redis", rdb.Close)
+	manager.OnShutdown("database", db.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := manager.Run(ctx); err != nil {
+		fatal(logger, "Server stopped with error", err)
 	}
 }
 
-func setupRoutes(eventHandler *handlers.EventHandler, userHandler *handlers.UserHandler, bookingHandler *handlers.BookingHandler) *gin.Engine {
-	router := gin.Default()
+// fatal logs err and exits
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, logging.Err(err))
+	os.Exit(1)
+}
+
+func setupRoutes(
+	requestTimeout time.Duration,
+	logger *slog.Logger,
+	appMetrics *metrics.Metrics,
+	eventHandler *handlers.EventHandler,
+	venueHandler *handlers.VenueHandler,
+	ticketTypeHandler *handlers.TicketTypeHandler,
+	userHandler *handlers.UserHandler,
+	bookingHandler *handlers.BookingHandler,
+	paymentHandler *handlers.PaymentHandler,
+	promoCodeHandler *handlers.PromoCodeHandler,
+	waitlistHandler *handlers.WaitlistHandler,
+	holdHandler *handlers.HoldHandler,
+	ticketHandler *handlers.TicketHandler,
+	checkInHandler *handlers.CheckInHandler,
+	webhookHandler *handlers.WebhookHandler,
+	authHandler *handlers.AuthHandler,
+	healthHandler *handlers.HealthHandler,
+	authService *services.AuthService,
+	checkInService *services.CheckInService,
+	idempotencyService *services.IdempotencyService,
+	rateLimited gin.HandlerFunc,
+) *gin.Engine {
+	router := gin.New()
+
+	// Tag every request and its log records with a request ID
+	router.Use(middleware.RequestID())
+
+	// Trace requests, continuing the trace of callers that send a traceparent
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		switch r.URL.Path {
+		case "/metrics", "/healthz", "/readyz":
+			return false
+		}
+		return true
+	})))
+
+	// Log each request once handled, including those that panic, with the
+	// trace and the caller it was made by. Successful scrapes and probes are
+	// only logged at debug level.
+	router.Use(middleware.AccessLog(logger, "/metrics", "/healthz", "/readyz"))
+	router.Use(middleware.Recovery(logger))
+
+	// Record request durations, including those of rejected requests
+	router.Use(middleware.Metrics(appMetrics))
 
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key, X-Request-ID, X-Scanner-Token, traceparent, tracestate")
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -89,37 +379,135 @@ func setupRoutes(eventHandler *handlers.EventHandler, userHandler *handlers.User
 		c.Next()
 	})
 
+	// Cancel handler work, including database queries, after requestTimeout
+	router.Use(middleware.Timeout(requestTimeout))
+
+	authenticate := middleware.Authenticate(authService)
+	adminOnly := middleware.RequireRole(models.RoleAdmin)
+	eventManagers := middleware.RequireRole(models.RoleAdmin, models.RoleOrganizer)
+	scannerAuth := middleware.AuthenticateScanner(checkInService)
+	idempotent := middleware.Idempotency(idempotencyService, logger)
+
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(appMetrics.Handler()))
+
+	// Liveness and readiness probes
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+
 	// API routes
 	api := router.Group("/api/v1")
 	{
+		// Auth routes
+		auth := api.Group("/auth", rateLimited)
+		{
+			auth.POST("/signup", authHandler.Signup)
+			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.Refresh)
+		}
+
 		// Event routes
 		events := api.Group("/events")
 		{
 			events.GET("", eventHandler.GetEvents)
 			events.GET("/:id", eventHandler.GetEvent)
-			events.POST("", eventHandler.CreateEvent)
-			events.PUT("/:id", eventHandler.UpdateEvent)
-			events.DELETE("/:id", eventHandler.DeleteEvent)
-			events.GET("/:id/statistics", eventHandler.GetEventStatistics)
+			events.GET("/:id/seats", eventHandler.GetSeatMap)
+			events.GET("/:id/ticket-types", ticketTypeHandler.GetTicketTypes)
+			events.POST("/:id/ticket-types", authenticate, eventManagers, ticketTypeHandler.CreateTicketType)
+			events.PUT("/:id/ticket-types/:ticket_type_id", authenticate, eventManagers, ticketTypeHandler.UpdateTicketType)
+			events.DELETE("/:id/ticket-types/:ticket_type_id", authenticate, eventManagers, ticketTypeHandler.DeleteTicketType)
+			events.POST("", authenticate, eventManagers, eventHandler.CreateEvent)
+			events.PUT("/:id", authenticate, eventManagers, eventHandler.UpdateEvent)
+			events.DELETE("/:id", authenticate, eventManagers, eventHandler.DeleteEvent)
+			events.GET("/:id/statistics", authenticate, eventManagers, eventHandler.GetEventStatistics)
+			events.POST("/:id/waitlist", authenticate, rateLimited, waitlistHandler.JoinWaitlist)
+			events.GET("/:id/waitlist", authenticate, waitlistHandler.GetPosition)
+			events.DELETE("/:id/waitlist", authenticate, waitlistHandler.LeaveWaitlist)
+			events.POST("/:id/waitlist/accept", authenticate, rateLimited, idempotent, waitlistHandler.AcceptOffer)
+			events.POST("/:id/checkin", scannerAuth, checkInHandler.CheckIn)
+			events.GET("/:id/checkin/bundle", scannerAuth, checkInHandler.GetBundle)
+			events.POST("/:id/checkin/sync", scannerAuth, checkInHandler.SyncScans)
+			events.GET("/:id/checkin/conflicts", authenticate, eventManagers, checkInHandler.GetConflicts)
+			events.GET("/:id/scanners", authenticate, eventManagers, checkInHandler.GetScanners)
+			events.POST("/:id/scanners", authenticate, eventManagers, checkInHandler.RegisterScanner)
+			events.DELETE("/:id/scanners/:scanner_id", authenticate, eventManagers, checkInHandler.RevokeScanner)
+			events.GET("/:id/scans", authenticate, eventManagers, checkInHandler.GetScans)
+		}
+
+		// Venue routes
+		venues := api.Group("/venues")
+		{
+			venues.GET("", venueHandler.GetVenues)
+			venues.GET("/:id", venueHandler.GetVenue)
+			venues.POST("", authenticate, eventManagers, venueHandler.CreateVenue)
+			venues.DELETE("/:id", authenticate, eventManagers, venueHandler.DeleteVenue)
 		}
 
 		// User routes
-		users := api.Group("/users")
+		users := api.Group("/users", authenticate)
 		{
-			users.GET("", userHandler.GetUsers)
+			users.GET("", adminOnly, userHandler.GetUsers)
 			users.GET("/:id", userHandler.GetUser)
-			users.POST("", userHandler.CreateUser)
+			users.POST("", adminOnly, userHandler.CreateUser)
 			users.PUT("/:id", userHandler.UpdateUser)
-			users.DELETE("/:id", userHandler.DeleteUser)
+			users.PUT("/:id/role", adminOnly, userHandler.UpdateUserRole)
+			users.DELETE("/:id", adminOnly, userHandler.DeleteUser)
+		}
+
+		// Current user routes
+		me := api.Group("/me", authenticate)
+		{
+			me.GET("", authHandler.Me)
+			me.GET("/bookings", bookingHandler.GetMyBookings)
 		}
 
 		// Booking routes
-		bookings := api.Group("/bookings")
+		bookings := api.Group("/bookings", authenticate, rateLimited)
 		{
-			bookings.POST("", bookingHandler.CreateBooking)
+			bookings.POST("", idempotent, bookingHandler.CreateBooking)
 			bookings.GET("/:id", bookingHandler.GetBooking)
 			bookings.PUT("/:id/cancel", bookingHandler.CancelBooking)
-			bookings.GET("/user/:user_id", bookingHandler.GetUserBookings)
+			bookings.POST("/:id/refund", bookingHandler.RefundBooking)
+			bookings.GET("/:id/tickets", ticketHandler.GetTickets)
+			bookings.GET("/:id/tickets/:ticket_id/qr", ticketHandler.GetTicketQR)
+		}
+
+		// Seat hold routes
+		holds := api.Group("/holds", authenticate, rateLimited)
+		{
+			holds.POST("", holdHandler.CreateHold)
+			holds.GET("/:id", holdHandler.GetHold)
+			holds.POST("/:id/extend", holdHandler.ExtendHold)
+			holds.DELETE("/:id", holdHandler.ReleaseHold)
+			holds.POST("/:id/checkout", idempotent, holdHandler.CheckoutHold)
+		}
+
+		// Webhook routes
+		webhooks := api.Group("/webhooks", authenticate, eventManagers)
+		{
+			webhooks.GET("", webhookHandler.GetWebhooks)
+			webhooks.POST("", webhookHandler.CreateWebhook)
+			webhooks.GET("/:id", webhookHandler.GetWebhook)
+			webhooks.PUT("/:id", webhookHandler.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+			webhooks.POST("/:id/deliveries/:delivery_id/replay", webhookHandler.ReplayDelivery)
+		}
+
+		// Admin routes
+		admin := api.Group("/admin", authenticate, adminOnly)
+		{
+			admin.GET("/payments/dlq", paymentHandler.GetDeadLetterJobs)
+			admin.POST("/payments/dlq/replay", paymentHandler.ReplayDeadLetterJobs)
+			admin.POST("/payments/dlq/:id/replay", paymentHandler.ReplayDeadLetterJob)
+
+			admin.GET("/promo-codes", promoCodeHandler.GetPromoCodes)
+			admin.GET("/promo-codes/:id", promoCodeHandler.GetPromoCode)
+			admin.POST("/promo-codes", promoCodeHandler.CreatePromoCode)
+			admin.PUT("/promo-codes/:id", promoCodeHandler.UpdatePromoCode)
+			admin.DELETE("/promo-codes/:id", promoCodeHandler.DeletePromoCode)
+
+			admin.GET("/events/:id/waitlist", waitlistHandler.GetWaitlist)
 		}
 	}
 
@@ -0,0 +1,8 @@
+-- Drop payment reference
+ALTER TABLE bookings DROP COLUMN IF EXISTS payment_reference;
+
+-- Failed bookings no longer exist as a status
+UPDATE bookings SET status = 'CANCELLED' WHERE status = 'FAILED';
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('PENDING', 'CONFIRMED', 'CANCELLED'));
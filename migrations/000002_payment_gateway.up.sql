@@ -0,0 +1,7 @@
+-- Allow bookings to fail when the payment gateway declines the payment
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('PENDING', 'CONFIRMED', 'CANCELLED', 'FAILED'));
+
+-- Reference of the captured payment at the gateway
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS payment_reference VARCHAR(64);
@@ -0,0 +1,5 @@
+-- Drop list indexes
+DROP INDEX IF EXISTS idx_events_name_trgm;
+DROP INDEX IF EXISTS idx_bookings_user_id_created_at_id;
+DROP INDEX IF EXISTS idx_users_created_at_id;
+DROP INDEX IF EXISTS idx_events_date_time_id;
@@ -0,0 +1,8 @@
+-- Keyset pagination orders every list by a sort column and then the ID
+CREATE INDEX IF NOT EXISTS idx_events_date_time_id ON events(date_time, id);
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_bookings_user_id_created_at_id ON bookings(user_id, created_at DESC, id DESC);
+
+-- Trigram index for case-insensitive event name search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_events_name_trgm ON events USING gin (name gin_trgm_ops);
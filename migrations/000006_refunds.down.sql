@@ -0,0 +1,12 @@
+-- Drop refunds and cancellation policies
+DROP TABLE IF EXISTS refunds;
+
+UPDATE bookings SET status = 'CANCELLED' WHERE status = 'REFUNDED';
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('PENDING', 'CONFIRMED', 'CANCELLED', 'FAILED'));
+
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_cancellation_policy_check;
+ALTER TABLE events DROP COLUMN IF EXISTS partial_refund_percent;
+ALTER TABLE events DROP COLUMN IF EXISTS no_refund_hours;
+ALTER TABLE events DROP COLUMN IF EXISTS full_refund_hours;
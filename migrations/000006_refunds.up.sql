@@ -0,0 +1,27 @@
+-- Per-event cancellation policy: a full refund up to full_refund_hours before
+-- the event, partial_refund_percent of the price until no_refund_hours before
+-- it, and no refund after that
+ALTER TABLE events ADD COLUMN IF NOT EXISTS full_refund_hours INTEGER NOT NULL DEFAULT 48;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS no_refund_hours INTEGER NOT NULL DEFAULT 24;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS partial_refund_percent INTEGER NOT NULL DEFAULT 50;
+ALTER TABLE events ADD CONSTRAINT events_cancellation_policy_check
+    CHECK (no_refund_hours >= 0 AND full_refund_hours >= no_refund_hours
+        AND partial_refund_percent BETWEEN 0 AND 100);
+
+-- Refunded bookings no longer hold tickets
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('PENDING', 'CONFIRMED', 'CANCELLED', 'FAILED', 'REFUNDED'));
+
+-- One refund per booking, completed once the payment gateway has returned the money
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL UNIQUE REFERENCES bookings(id) ON DELETE CASCADE,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'COMPLETED', 'FAILED')),
+    refund_reference VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refunds_status ON refunds(status);
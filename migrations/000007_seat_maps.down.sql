@@ -0,0 +1,7 @@
+-- Drop reserved seating
+DROP TABLE IF EXISTS booking_seats;
+DROP INDEX IF EXISTS idx_events_venue_id;
+ALTER TABLE events DROP COLUMN IF EXISTS venue_id;
+DROP TABLE IF EXISTS seats;
+DROP TABLE IF EXISTS venue_sections;
+DROP TABLE IF EXISTS venues;
@@ -0,0 +1,43 @@
+-- Venues are divided into sections, and each section into rows of seats
+CREATE TABLE IF NOT EXISTS venues (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    address TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS venue_sections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    venue_id UUID NOT NULL REFERENCES venues(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    position INTEGER NOT NULL,
+    UNIQUE (venue_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS seats (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    section_id UUID NOT NULL REFERENCES venue_sections(id) ON DELETE CASCADE,
+    row_label VARCHAR(10) NOT NULL,
+    row_position INTEGER NOT NULL,
+    seat_number INTEGER NOT NULL CHECK (seat_number > 0),
+    UNIQUE (section_id, row_label, seat_number)
+);
+
+-- Events with a venue use reserved seating; their tickets are the venue's seats
+ALTER TABLE events ADD COLUMN IF NOT EXISTS venue_id UUID REFERENCES venues(id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS idx_events_venue_id ON events(venue_id);
+
+-- Seats claimed by bookings. A seat is held by at most one active booking per
+-- event; the claim is released when the booking is cancelled, fails or is
+-- refunded, which frees the seat for other bookings.
+CREATE TABLE IF NOT EXISTS booking_seats (
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    seat_id UUID NOT NULL REFERENCES seats(id),
+    released BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (booking_id, seat_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_seats_active_seat
+    ON booking_seats(event_id, seat_id) WHERE NOT released;
@@ -0,0 +1,3 @@
+-- Drop ticket tiers
+DROP TABLE IF EXISTS booking_items;
+DROP TABLE IF EXISTS ticket_types;
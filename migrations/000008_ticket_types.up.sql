@@ -0,0 +1,35 @@
+-- Ticket tiers of an event, each with its own price and allocation. The sum of
+-- an event's allocations never exceeds its total_tickets, and the event's
+-- ticket_price is kept at its cheapest tier for price filters.
+CREATE TABLE IF NOT EXISTS ticket_types (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    price DECIMAL(10,2) NOT NULL CHECK (price >= 0),
+    allocation INTEGER NOT NULL CHECK (allocation > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, name)
+);
+
+-- Line items of a booking, priced when the booking was made
+CREATE TABLE IF NOT EXISTS booking_items (
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    ticket_type_id UUID NOT NULL REFERENCES ticket_types(id) ON DELETE RESTRICT,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(10,2) NOT NULL CHECK (unit_price >= 0),
+    PRIMARY KEY (booking_id, ticket_type_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_items_ticket_type_id ON booking_items(ticket_type_id);
+
+-- Existing events sell a single tier, and existing bookings are line items of it
+INSERT INTO ticket_types (event_id, name, price, allocation)
+SELECT id, 'General Admission', ticket_price, total_tickets
+FROM events;
+
+INSERT INTO booking_items (booking_id, ticket_type_id, quantity, unit_price)
+SELECT b.id, tt.id, b.quantity, ROUND(b.total_amount / b.quantity, 2)
+FROM bookings b
+JOIN ticket_types tt ON tt.event_id = b.event_id;
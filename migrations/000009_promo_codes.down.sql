@@ -0,0 +1,5 @@
+-- Drop promo codes
+DROP INDEX IF EXISTS idx_bookings_promo_code_user;
+ALTER TABLE bookings DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE bookings DROP COLUMN IF EXISTS promo_code_id;
+DROP TABLE IF EXISTS promo_codes;
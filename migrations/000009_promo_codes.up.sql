@@ -0,0 +1,30 @@
+-- Promo codes discount a booking by a percentage or a fixed amount. Codes are
+-- stored upper case and can be limited to an event or one of its ticket types.
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) NOT NULL UNIQUE CHECK (code = UPPER(code)),
+    description TEXT,
+    discount_type VARCHAR(10) NOT NULL CHECK (discount_type IN ('PERCENT', 'FIXED')),
+    discount_value DECIMAL(10,2) NOT NULL CHECK (discount_value > 0),
+    max_redemptions INTEGER CHECK (max_redemptions > 0),
+    max_per_user INTEGER CHECK (max_per_user > 0),
+    min_quantity INTEGER NOT NULL DEFAULT 1 CHECK (min_quantity > 0),
+    valid_from TIMESTAMP WITH TIME ZONE,
+    valid_until TIMESTAMP WITH TIME ZONE,
+    event_id UUID REFERENCES events(id) ON DELETE CASCADE,
+    ticket_type_id UUID REFERENCES ticket_types(id) ON DELETE CASCADE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (discount_type <> 'PERCENT' OR discount_value <= 100),
+    CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_from < valid_until),
+    CHECK (ticket_type_id IS NULL OR event_id IS NOT NULL)
+);
+
+-- The discount applied to a booking. total_amount is what the customer pays,
+-- so the list price is total_amount + discount_amount. A booking counts as a
+-- redemption of its promo code while it is pending or confirmed.
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS promo_code_id UUID REFERENCES promo_codes(id) ON DELETE RESTRICT;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_bookings_promo_code_user ON bookings(promo_code_id, user_id) WHERE promo_code_id IS NOT NULL;
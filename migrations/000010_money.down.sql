@@ -0,0 +1,24 @@
+-- Back to decimal amounts. Amounts in currencies without two decimal places
+-- are converted as if they had them.
+ALTER TABLE promo_codes ADD COLUMN IF NOT EXISTS discount_value DECIMAL(10,2);
+UPDATE promo_codes SET discount_value = CASE WHEN discount_type = 'PERCENT' THEN percent_off ELSE amount_off / 100.0 END;
+ALTER TABLE promo_codes ALTER COLUMN discount_value SET NOT NULL;
+ALTER TABLE promo_codes DROP CONSTRAINT IF EXISTS promo_codes_discount_check;
+ALTER TABLE promo_codes DROP COLUMN IF EXISTS currency;
+ALTER TABLE promo_codes DROP COLUMN IF EXISTS amount_off;
+ALTER TABLE promo_codes DROP COLUMN IF EXISTS percent_off;
+ALTER TABLE promo_codes ADD CONSTRAINT promo_codes_discount_value_check
+    CHECK (discount_value > 0 AND (discount_type <> 'PERCENT' OR discount_value <= 100));
+
+ALTER TABLE refunds ALTER COLUMN amount TYPE DECIMAL(10,2) USING amount / 100.0;
+ALTER TABLE refunds DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE bookings ALTER COLUMN discount_amount TYPE DECIMAL(10,2) USING discount_amount / 100.0;
+ALTER TABLE bookings ALTER COLUMN total_amount TYPE DECIMAL(10,2) USING total_amount / 100.0;
+ALTER TABLE bookings DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE booking_items ALTER COLUMN unit_price TYPE DECIMAL(10,2) USING unit_price / 100.0;
+ALTER TABLE ticket_types ALTER COLUMN price TYPE DECIMAL(10,2) USING price / 100.0;
+
+ALTER TABLE events ALTER COLUMN ticket_price TYPE DECIMAL(10,2) USING ticket_price / 100.0;
+ALTER TABLE events DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,31 @@
+-- Amounts are stored as integers in the minor unit of their currency, e.g.
+-- cents, so that sums are exact. Existing amounts are in USD.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE events ALTER COLUMN ticket_price TYPE BIGINT USING ROUND(ticket_price * 100);
+
+ALTER TABLE ticket_types ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100);
+ALTER TABLE booking_items ALTER COLUMN unit_price TYPE BIGINT USING ROUND(unit_price * 100);
+
+-- Bookings and refunds keep the currency they were paid in
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE bookings ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE bookings ALTER COLUMN total_amount TYPE BIGINT USING ROUND(total_amount * 100);
+ALTER TABLE bookings ALTER COLUMN discount_amount TYPE BIGINT USING ROUND(discount_amount * 100);
+
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE refunds ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE refunds ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100);
+
+-- Promo codes take off either a percentage or an amount in one currency
+ALTER TABLE promo_codes ADD COLUMN IF NOT EXISTS percent_off NUMERIC(5,2);
+ALTER TABLE promo_codes ADD COLUMN IF NOT EXISTS amount_off BIGINT;
+ALTER TABLE promo_codes ADD COLUMN IF NOT EXISTS currency CHAR(3);
+
+UPDATE promo_codes SET percent_off = discount_value WHERE discount_type = 'PERCENT';
+UPDATE promo_codes SET amount_off = ROUND(discount_value * 100), currency = 'USD' WHERE discount_type = 'FIXED';
+
+ALTER TABLE promo_codes DROP COLUMN discount_value;
+ALTER TABLE promo_codes ADD CONSTRAINT promo_codes_discount_check CHECK (
+    (discount_type = 'PERCENT' AND percent_off > 0 AND percent_off <= 100 AND amount_off IS NULL AND currency IS NULL)
+    OR (discount_type = 'FIXED' AND amount_off > 0 AND currency ~ '^[A-Z]{3}$' AND percent_off IS NULL)
+);
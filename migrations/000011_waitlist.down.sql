@@ -0,0 +1,2 @@
+-- Drop the waitlist
+DROP TABLE IF EXISTS waitlist_entries;
@@ -0,0 +1,22 @@
+-- Users waiting for tickets of a sold out ticket type, served in created_at
+-- order. An OFFERED entry holds a pending booking for the user; whether the
+-- offer was accepted or lapsed follows from that booking's status.
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ticket_type_id UUID NOT NULL REFERENCES ticket_types(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'WAITING' CHECK (status IN ('WAITING', 'OFFERED', 'LEFT')),
+    booking_id UUID REFERENCES bookings(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (status <> 'OFFERED' OR booking_id IS NOT NULL)
+);
+
+-- A user waits at most once per event
+CREATE UNIQUE INDEX IF NOT EXISTS idx_waitlist_entries_waiting_user
+    ON waitlist_entries(event_id, user_id) WHERE status = 'WAITING';
+CREATE INDEX IF NOT EXISTS idx_waitlist_entries_queue
+    ON waitlist_entries(event_id, ticket_type_id, created_at, id) WHERE status = 'WAITING';
+CREATE INDEX IF NOT EXISTS idx_waitlist_entries_user ON waitlist_entries(user_id, event_id);
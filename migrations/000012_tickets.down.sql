@@ -0,0 +1,2 @@
+-- Drop issued tickets
+DROP TABLE IF EXISTS tickets;
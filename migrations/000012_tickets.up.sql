@@ -0,0 +1,35 @@
+-- Tickets are issued when a booking is confirmed: one per booked ticket,
+-- numbered from 1 within the booking, with its seat at reserved seating
+-- events. They are revoked when the booking is refunded or cancelled.
+CREATE TABLE IF NOT EXISTS tickets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL CHECK (number > 0),
+    ticket_type_id UUID NOT NULL REFERENCES ticket_types(id) ON DELETE RESTRICT,
+    seat_id UUID REFERENCES seats(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'VALID' CHECK (status IN ('VALID', 'REVOKED')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (booking_id, number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tickets_event_id ON tickets(event_id);
+
+-- Issue tickets for bookings confirmed before tickets existed
+INSERT INTO tickets (booking_id, event_id, number, ticket_type_id, seat_id)
+SELECT u.booking_id, u.event_id, u.number, u.ticket_type_id, s.seat_id
+FROM (
+    SELECT b.id AS booking_id, b.event_id, bi.ticket_type_id,
+        ROW_NUMBER() OVER (PARTITION BY b.id ORDER BY bi.ticket_type_id, g.n) AS number
+    FROM bookings b
+    JOIN booking_items bi ON bi.booking_id = b.id
+    CROSS JOIN generate_series(1, bi.quantity) AS g(n)
+    WHERE b.status = 'CONFIRMED'
+) u
+LEFT JOIN (
+    SELECT bs.booking_id, bs.seat_id, ROW_NUMBER() OVER (PARTITION BY bs.booking_id ORDER BY bs.seat_id) AS number
+    FROM booking_seats bs
+    WHERE NOT bs.released
+) s ON s.booking_id = u.booking_id AND s.number = u.number
+ON CONFLICT (booking_id, number) DO NOTHING;
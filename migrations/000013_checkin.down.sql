@@ -0,0 +1,5 @@
+-- Drop check-in
+DROP TABLE IF EXISTS ticket_scans;
+ALTER TABLE tickets DROP COLUMN IF EXISTS checked_in_device_id;
+ALTER TABLE tickets DROP COLUMN IF EXISTS checked_in_at;
+DROP TABLE IF EXISTS scanner_devices;
@@ -0,0 +1,31 @@
+-- Gate scanners registered for an event. Devices authenticate with a token of
+-- which only the SHA-256 hash is stored.
+CREATE TABLE IF NOT EXISTS scanner_devices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    gate VARCHAR(50) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_scanner_devices_event_id ON scanner_devices(event_id);
+
+-- A ticket is checked in at most once; the first scan sets these columns
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS checked_in_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS checked_in_device_id UUID REFERENCES scanner_devices(id) ON DELETE SET NULL;
+
+-- Every scan, admitted or not, with the gate of the device at the time
+CREATE TABLE IF NOT EXISTS ticket_scans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    device_id UUID NOT NULL REFERENCES scanner_devices(id) ON DELETE CASCADE,
+    gate VARCHAR(50) NOT NULL,
+    ticket_id UUID REFERENCES tickets(id) ON DELETE SET NULL,
+    result VARCHAR(20) NOT NULL CHECK (result IN ('ADMITTED', 'DUPLICATE', 'REVOKED', 'WRONG_EVENT', 'INVALID')),
+    scanned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_scans_event ON ticket_scans(event_id, scanned_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_ticket_scans_gate ON ticket_scans(event_id, gate);
@@ -0,0 +1,7 @@
+-- Drop offline check-in
+DROP INDEX IF EXISTS idx_ticket_scans_conflicts;
+DROP INDEX IF EXISTS idx_ticket_scans_client_scan_id;
+
+ALTER TABLE ticket_scans DROP COLUMN IF EXISTS synced_at;
+ALTER TABLE ticket_scans DROP COLUMN IF EXISTS device_result;
+ALTER TABLE ticket_scans DROP COLUMN IF EXISTS client_scan_id;
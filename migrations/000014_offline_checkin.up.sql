@@ -0,0 +1,14 @@
+-- Scans a gate device decided on offline and uploaded later. client_scan_id is
+-- the device's ID for the scan, so an upload can be retried; device_result is
+-- what the device decided, while result is what the server accepted.
+ALTER TABLE ticket_scans ADD COLUMN IF NOT EXISTS client_scan_id UUID;
+ALTER TABLE ticket_scans ADD COLUMN IF NOT EXISTS device_result VARCHAR(20)
+    CHECK (device_result IN ('ADMITTED', 'DUPLICATE', 'REVOKED', 'WRONG_EVENT', 'INVALID'));
+ALTER TABLE ticket_scans ADD COLUMN IF NOT EXISTS synced_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ticket_scans_client_scan_id ON ticket_scans(device_id, client_scan_id);
+
+-- Conflicts: offline scans that let someone in with a ticket the server did not
+-- accept, e.g. because another gate had already checked it in
+CREATE INDEX IF NOT EXISTS idx_ticket_scans_conflicts ON ticket_scans(event_id, scanned_at DESC, id DESC)
+    WHERE device_result = 'ADMITTED' AND result <> 'ADMITTED';
@@ -0,0 +1,2 @@
+-- Drop the outbox
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,3 @@
+-- Drop webhooks
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
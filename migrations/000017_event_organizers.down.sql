@@ -0,0 +1,3 @@
+-- Drop event organizers
+DROP INDEX IF EXISTS idx_events_organizer_id;
+ALTER TABLE events DROP COLUMN IF EXISTS organizer_id;
@@ -0,0 +1,5 @@
+-- The organizer who created each event. Events created before organizers were
+-- recorded have none, and only admins can subscribe webhooks to them.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS organizer_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_events_organizer_id ON events(organizer_id);
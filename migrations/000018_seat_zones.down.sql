@@ -0,0 +1,3 @@
+-- Drop price zones
+ALTER TABLE ticket_types DROP COLUMN IF EXISTS zone;
+ALTER TABLE seats DROP COLUMN IF EXISTS zone;
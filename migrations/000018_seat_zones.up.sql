@@ -0,0 +1,5 @@
+-- Price zones tie reserved seats to ticket types: a seat in a zone that one of
+-- the event's ticket types is sold for can only be booked with that type.
+-- Seats and ticket types without a zone have ''.
+ALTER TABLE seats ADD COLUMN IF NOT EXISTS zone VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE ticket_types ADD COLUMN IF NOT EXISTS zone VARCHAR(50) NOT NULL DEFAULT '';
@@ -4,28 +4,41 @@
 # Make sure the application is running with: docker compose up
 
 BASE_URL="http://localhost:8080/api/v1"
+ADMIN_EMAIL="${ADMIN_EMAIL:-admin@example.com}"
+ADMIN_PASSWORD="${ADMIN_PASSWORD:-admin-password}"
 
 echo "=== Event Ticket Booking System API Test ==="
 echo
 
-# Test 1: Create a user
-echo "1. Creating a user..."
-USER_RESPONSE=$(curl -s -X POST "$BASE_URL/users" \
+# Test 1: Sign up a customer
+echo "1. Signing up a customer..."
+SIGNUP_RESPONSE=$(curl -s -X POST "$BASE_URL/auth/signup" \
   -H "Content-Type: application/json" \
   -d '{
     "name": "John Doe",
-    "email": "john@example.com"
+    "email": "john@example.com",
+    "password": "john-password"
   }')
 
-echo "User created: $USER_RESPONSE"
-USER_ID=$(echo $USER_RESPONSE | jq -r '.id')
+echo "User signed up: $SIGNUP_RESPONSE"
+USER_ID=$(echo $SIGNUP_RESPONSE | jq -r '.user.id')
+USER_TOKEN=$(echo $SIGNUP_RESPONSE | jq -r '.access_token')
 echo "User ID: $USER_ID"
 echo
 
+# Log in as the bootstrap admin to manage events
+ADMIN_TOKEN=$(curl -s -X POST "$BASE_URL/auth/login" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"email\": \"$ADMIN_EMAIL\",
+    \"password\": \"$ADMIN_PASSWORD\"
+  }" | jq -r '.access_token')
+
 # Test 2: Create an event
 echo "2. Creating an event..."
 EVENT_RESPONSE=$(curl -s -X POST "$BASE_URL/events" \
   -H "Content-Type: application/json" \
+  -H "Authorization: Bearer $ADMIN_TOKEN" \
   -d '{
     "name": "Concert 2024",
     "description": "Amazing concert event",
@@ -39,17 +52,17 @@ EVENT_ID=$(echo $EVENT_RESPONSE | jq -r '.id')
 echo "Event ID: $EVENT_ID"
 echo
 
-# Test 3: Get all events
-echo "3. Getting all events..."
-curl -s -X GET "$BASE_URL/events" | jq '.'
+# Test 3: List events
+echo "3. Listing events with availability..."
+curl -s -X GET "$BASE_URL/events?has_availability=true&limit=10" | jq '.'
 echo
 
 # Test 4: Book tickets
 echo "4. Booking tickets..."
 BOOKING_RESPONSE=$(curl -s -X POST "$BASE_URL/bookings" \
   -H "Content-Type: application/json" \
+  -H "Authorization: Bearer $USER_TOKEN" \
   -d "{
-    \"user_id\": \"$USER_ID\",
     \"event_id\": \"$EVENT_ID\",
     \"quantity\": 2
   }")
@@ -61,17 +74,20 @@ echo
 
 # Test 5: Get booking details
 echo "5. Getting booking details..."
-curl -s -X GET "$BASE_URL/bookings/$BOOKING_ID" | jq '.'
+curl -s -X GET "$BASE_URL/bookings/$BOOKING_ID" \
+  -H "Authorization: Bearer $USER_TOKEN" | jq '.'
 echo
 
 # Test 6: Get user bookings
 echo "6. Getting user bookings..."
-curl -s -X GET "$BASE_URL/bookings/user/$USER_ID" | jq '.'
+curl -s -X GET "$BASE_URL/me/bookings?status=PENDING" \
+  -H "Authorization: Bearer $USER_TOKEN" | jq '.'
 echo
 
 # Test 7: Get event statistics
 echo "7. Getting event statistics..."
-curl -s -X GET "$BASE_URL/events/$EVENT_ID/statistics" | jq '.'
+curl -s -X GET "$BASE_URL/events/$EVENT_ID/statistics" \
+  -H "Authorization: Bearer $ADMIN_TOKEN" | jq '.'
 echo
 
 # Test 8: Test concurrent booking (simulate multiple requests)
@@ -80,8 +96,8 @@ for i in {1..3}; do
   echo "Creating booking $i..."
   curl -s -X POST "$BASE_URL/bookings" \
     -H "Content-Type: application/json" \
+    -H "Authorization: Bearer $USER_TOKEN" \
     -d "{
-      \"user_id\": \"$USER_ID\",
       \"event_id\": \"$EVENT_ID\",
       \"quantity\": 1
     }" | jq '.id, .status'
@@ -90,7 +106,8 @@ echo
 
 # Test 9: Get updated event statistics
 echo "9. Getting updated event statistics..."
-curl -s -X GET "$BASE_URL/events/$EVENT_ID/statistics" | jq '.'
+curl -s -X GET "$BASE_URL/events/$EVENT_ID/statistics" \
+  -H "Authorization: Bearer $ADMIN_TOKEN" | jq '.'
 echo
 
 echo "=== Test completed ==="